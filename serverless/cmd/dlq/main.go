@@ -0,0 +1,144 @@
+// Command dlq inspects the dead-letter queues of the Aprimo pipelines and redrives or
+// abandons the messages in them, as the dlq-get and dlq-resolve functions do through the
+// API. It uses the AWS credentials of the operator running it and connects to the database
+// with the DB_* variables used by the functions, so it is usually run through the jump host.
+//
+// Usage:
+//
+//	dlq list -pipeline upload [-max 100] [-json]
+//	dlq redrive -pipeline upload -actor you@state.gov <message id>...
+//	dlq abandon -pipeline record -actor you@state.gov <message id>...
+//
+// The queue URLs are read from APRIMO_UPLOAD_QUEUE and APRIMO_UPLOAD_DLQ for the upload
+// pipeline, and from RECORD_CREATE_QUEUE and RECORD_CREATE_DLQ for the record pipeline.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/dlq"
+)
+
+const usage = `usage:
+  dlq list -pipeline upload|record [-max n] [-json]
+  dlq redrive -pipeline upload|record -actor email <message id>...
+  dlq abandon -pipeline upload|record -actor email <message id>...`
+
+// formatDate prints the date of the last error of a message, or a dash if there is none.
+func formatDate(message dlq.Message) string {
+	if message.LastErrorDate == nil {
+		return "-"
+	}
+
+	return message.LastErrorDate.Format("2006-01-02 15:04")
+}
+
+// printMessages writes the listed messages as a table.
+func printMessages(w io.Writer, messages []dlq.Message) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "MESSAGE ID\tS3 KEY\tTEAM\tUPLOADER\tRECEIVES\tERROR DATE\tLAST ERROR")
+
+	for _, m := range messages {
+		lastError := m.LastError
+
+		if m.DecodeError != "" {
+			lastError = "(" + m.DecodeError + ")"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", m.MessageId, m.S3Id, m.Team, m.Uploader, m.ReceiveCount, formatDate(m), lastError)
+	}
+
+	tw.Flush()
+}
+
+// printResults writes the outcome of an action on each message as a table.
+func printResults(w io.Writer, results []dlq.Result) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "MESSAGE ID\tS3 KEY\tSTATUS\tERROR")
+
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.MessageId, r.S3Id, r.Status, r.Error)
+	}
+
+	tw.Flush()
+}
+
+// run executes a single command, returning an error to be reported to the operator.
+func run(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	command := args[0]
+
+	if command != "list" && command != dlq.ActionRedrive && command != dlq.ActionAbandon {
+		return errors.New(usage)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	name := flags.String("pipeline", "", "the pipeline whose dead-letter queue to use, upload or record")
+	max := flags.Int("max", dlq.MaxMessages, "the most messages to list")
+	asJSON := flags.Bool("json", false, "print the messages as JSON")
+	actor := flags.String("actor", "", "the email address recorded in the audit log as having taken the action")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	pipeline, err := dlq.GetPipeline(*name)
+
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		messages, err := dlq.List(pipeline, *max)
+
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+
+			return encoder.Encode(messages)
+		}
+
+		printMessages(w, messages)
+	case dlq.ActionRedrive, dlq.ActionAbandon:
+		if *actor == "" {
+			return errors.New("-actor is required to record who took the action")
+		} else if flags.NArg() == 0 {
+			return errors.New("no message ids provided")
+		}
+
+		event := audit.Event{Actor: *actor, UserAgent: "dlq-cli"}
+		results, err := dlq.Resolve(pipeline, command, flags.Args(), event)
+
+		if err != nil {
+			return err
+		}
+
+		printResults(w, results)
+	}
+
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
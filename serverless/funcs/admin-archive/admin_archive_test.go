@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestArchiveSelf(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "No longer needed"}`, testHelpers.ExampleAdmin["email"]),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "super admin"),
+	}
+
+	resp, err := adminArchiveHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("adminArchiveHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestArchiveAdmin(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "No longer needed"}`, testHelpers.ExampleAdmin["email"]),
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := adminArchiveHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("adminArchiveHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	adminList, err := admins.RetrieveAdmins()
+	if err != nil {
+		t.Fatalf("RetrieveAdmins error %v, want nil", err)
+	}
+
+	for _, admin := range adminList {
+		if admin.Email == testHelpers.ExampleAdmin["email"] {
+			t.Fatal("Archived admin is still listed")
+		}
+	}
+}
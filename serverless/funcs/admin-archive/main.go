@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// adminArchiveHandler handles the request to archive (soft delete) an existing admin user.
+// Archived admins are hidden from the admin listing and are no longer able to log in.
+func adminArchiveHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Archive Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	superAdmin, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	} else if superAdmin == request.Id {
+		return msgs.SendCustomError(errors.New("admins cannot archive themselves"), 400)
+	}
+
+	// Ensure that the user we intend to archive exists.
+	_, exists, err := users.CheckForExistingAdminUser(request.Id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Admin User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s does not exist as an admin user", request.Id)
+
+		logs.LogError(err, "Admin User Not Found Error")
+		return msgs.SendCustomError(err, 404)
+	}
+
+	archived, err := admins.ArchiveAdmin(request.Id, superAdmin, request.Reason, audit.FromRequest(event, audit.AdminArchive, request.Id))
+
+	if err != nil {
+		logs.LogError(err, "Archive Admin User Error")
+		return msgs.SendServerError(err)
+	} else if !archived {
+		return msgs.SendCustomError(errors.New("admin has already been archived"), 409)
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+func main() {
+	lambda.Start(adminArchiveHandler)
+}
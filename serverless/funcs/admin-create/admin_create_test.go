@@ -8,6 +8,8 @@ import (
 
 	testConfig "github.com/IIP-Design/commons-gateway/test/config"
 	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -76,6 +78,28 @@ func TestCreateExistingAdmin(t *testing.T) {
 	}
 }
 
+func TestCreateArchivedAdmin(t *testing.T) {
+	archived, err := admins.ArchiveAdmin(EMAIL, testHelpers.ExampleAdmin["email"], "Testing", audit.Event{Actor: testHelpers.ExampleAdmin["email"], Action: audit.AdminArchive})
+	if !archived || err != nil {
+		t.Fatalf("ArchiveAdmin result %t/%v, want true/nil", archived, err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body: fmt.Sprintf(`{"email":"%s","givenName":"%s","familyName":"%s","role":"%s","team":"%s"}`,
+			EMAIL, GIVEN_NAME, FAMILY_NAME, "super admin", testHelpers.ExampleTeam["id"]),
+	}
+
+	resp, err := newAdminHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("newAdminHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	admin, err := admins.RetrieveAdmin(EMAIL)
+	if admin["role"] != "super admin" || err != nil {
+		t.Fatalf("RetrieveAdmin result %v/%v, want the reinstated super admin/nil", admin["role"], err)
+	}
+}
+
 func cleanupAdmins() {
 	pool := data.ConnectToDB()
 	defer pool.Close()
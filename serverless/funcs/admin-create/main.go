@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
@@ -15,7 +16,8 @@ import (
 )
 
 // handleAdminCreation coordinates all the actions associated with creating a new user.
-func handleAdminCreation(adminData data.User) (bool, error) {
+// Archived admins are restored rather than recreated.
+func handleAdminCreation(adminData data.User, event audit.Event) (bool, error) {
 	var err error
 
 	exists, user, err := users.CheckForExistingUser(adminData.Email)
@@ -23,6 +25,14 @@ func handleAdminCreation(adminData data.User) (bool, error) {
 	if err != nil {
 		logs.LogError(err, "Check For Existing User Error")
 		return exists, err
+	} else if exists && user.Archived && user.Type == "admin" {
+		err = admins.ReinstateAdmin(adminData, event)
+
+		if err != nil {
+			logs.LogError(err, "Admin Reinstatement Error")
+		}
+
+		return false, err
 	} else if exists {
 		err = fmt.Errorf("the user %s has already been registered as a user of type %s", adminData.Email, user.Type)
 
@@ -30,7 +40,7 @@ func handleAdminCreation(adminData data.User) (bool, error) {
 		return exists, err
 	}
 
-	err = admins.CreateAdmin(adminData)
+	err = admins.CreateAdmin(adminData, event)
 
 	if err != nil {
 		logs.LogError(err, "Admin Creation Error")
@@ -43,13 +53,15 @@ func handleAdminCreation(adminData data.User) (bool, error) {
 // ensures that the required data is present before continuing on to recording
 // the user's email in the list of admins.
 func newAdminHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	admin, err := data.ExtractUser(event.Body)
 
 	if err != nil {
 		return msgs.SendServerError(err)
 	}
 
-	exists, err := handleAdminCreation(admin)
+	exists, err := handleAdminCreation(admin, audit.FromRequest(event, audit.AdminCreate, admin.Email))
 
 	if exists {
 		return msgs.SendCustomError(err, 409)
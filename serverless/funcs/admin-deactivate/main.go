@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
@@ -9,32 +10,36 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 
-	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
 )
 
-// deactivateAdmin sets an existing admin's `active` status to `false`.
-func deactivateAdmin(email string) error {
-	pool := data.ConnectToDB()
-	defer pool.Close()
+// deactivateAdmin sets an existing admin's `active` status to `false`, recording
+// the provided audit event.
+func deactivateAdmin(email string, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: email}}
 
-	currentTime := time.Now()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	query := `UPDATE admins SET active = false, date_modified = $1 WHERE email = $2`
-	_, err := pool.Exec(query, currentTime, email)
+		query := `UPDATE admins SET active = false, date_modified = $1 WHERE email = $2`
+		_, err := tx.Exec(query, currentTime, email)
 
-	if err != nil {
-		logs.LogError(err, "Deactivate Admin Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Deactivate Admin Query Error")
+		}
 
-	return err
+		return err
+	})
 }
 
 // deactivateAdminHandler handles the request to deactivate an existing admin.
 // It ensures that the required data is present before continuing on to update the admin data.
 func deactivateAdminHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	username := event.QueryStringParameters["username"]
 
 	if username == "" {
@@ -54,7 +59,7 @@ func deactivateAdminHandler(ctx context.Context, event events.APIGatewayProxyReq
 		return msgs.SendServerError(err)
 	}
 
-	err = deactivateAdmin(username)
+	err = deactivateAdmin(username, audit.FromRequest(event, audit.AdminDeactivate, username))
 
 	if err != nil {
 		logs.LogError(err, "Deactivate Admin Error")
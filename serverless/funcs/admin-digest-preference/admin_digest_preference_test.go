@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// checkDigest fails the test if the example admin's digest preference is not as expected.
+func checkDigest(t *testing.T, want bool) {
+	admin, err := admins.RetrieveAdmin(testHelpers.ExampleAdmin["email"])
+	if err != nil || admin["digest"] != want {
+		t.Fatalf("RetrieveAdmin digest %v/%v, want %t/nil", admin["digest"], err, want)
+	}
+}
+
+func TestOptIn(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"digest": true}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := adminDigestPreferenceHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("adminDigestPreferenceHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	checkDigest(t, true)
+
+	// Repeating the request leaves the preference unchanged.
+	resp, err = adminDigestPreferenceHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("adminDigestPreferenceHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+}
+
+func TestOptOut(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"digest": false}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := adminDigestPreferenceHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("adminDigestPreferenceHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	checkDigest(t, false)
+}
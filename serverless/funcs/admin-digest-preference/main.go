@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// adminDigestPreferenceHandler handles the request from an admin to opt in to, or out
+// of, the daily digest of their team's activity. Admins may only change their own
+// preference, so the admin is identified from the request's token rather than its body.
+func adminDigestPreferenceHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	parsed, err := data.ParseBodyData(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Parse Body Data Error")
+		return msgs.SendServerError(err)
+	}
+
+	clientUser, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	_, err = admins.SetDigest(clientUser, parsed.Digest, audit.FromRequest(event, audit.AdminDigest, clientUser))
+
+	if err != nil {
+		logs.LogError(err, "Set Admin Digest Error")
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+func main() {
+	lambda.Start(adminDigestPreferenceHandler)
+}
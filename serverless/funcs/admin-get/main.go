@@ -16,6 +16,8 @@ import (
 
 // getAdminHandler handles the request to retrieve a single admin user based on email address.
 func getAdminHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	username := event.QueryStringParameters["username"]
 
 	if username == "" {
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestRestoreAdmin(t *testing.T) {
+	archived, err := admins.ArchiveAdmin(testHelpers.ExampleAdmin["email"], "super@test.test", "Testing", audit.Event{Actor: "super@test.test", Action: audit.AdminArchive})
+	if !archived || err != nil {
+		t.Fatalf("archive result %t/%v, want true/nil", archived, err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s"}`, testHelpers.ExampleAdmin["email"]),
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := adminRestoreHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("adminRestoreHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	admin, err := admins.RetrieveAdmin(testHelpers.ExampleAdmin["email"])
+	if admin["email"] != testHelpers.ExampleAdmin["email"] || err != nil {
+		t.Fatalf("RetrieveAdmin result %v/%v, want %s/nil", admin["email"], err, testHelpers.ExampleAdmin["email"])
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// adminRestoreHandler handles the request to restore a previously archived admin user.
+func adminRestoreHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Archive Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	// Ensure that the user we intend to restore exists.
+	_, exists, err := users.CheckForExistingAdminUser(request.Id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Admin User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s does not exist as an admin user", request.Id)
+
+		logs.LogError(err, "Admin User Not Found Error")
+		return msgs.SendCustomError(err, 404)
+	}
+
+	restored, err := admins.RestoreAdmin(request.Id, audit.FromRequest(event, audit.AdminRestore, request.Id))
+
+	if err != nil {
+		logs.LogError(err, "Restore Admin User Error")
+		return msgs.SendServerError(err)
+	} else if !restored {
+		return msgs.SendCustomError(errors.New("admin is not archived"), 409)
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+func main() {
+	lambda.Start(adminRestoreHandler)
+}
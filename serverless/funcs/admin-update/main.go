@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/teams"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
@@ -19,6 +20,8 @@ import (
 // It ensures that the required data is present before continuing on to
 // update the team data.
 func updateAdminHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	admin, err := data.ExtractAdminUser(event.Body)
 
 	if err != nil {
@@ -51,7 +54,7 @@ func updateAdminHandler(ctx context.Context, event events.APIGatewayProxyRequest
 		return msgs.SendCustomError(err, 404)
 	}
 
-	err = admins.UpdateAdmin(admin)
+	err = admins.UpdateAdmin(admin, audit.FromRequest(event, audit.AdminUpdate, admin.Email))
 
 	if err != nil {
 		logs.LogError(err, "Update Admin Error")
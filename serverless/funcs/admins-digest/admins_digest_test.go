@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.CleanupInvites(testHelpers.ExampleGuest2["email"])
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// subscribe sets whether the example admin receives the digest and clears when it was
+// last sent.
+func subscribe(t *testing.T, digest bool) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	_, err := pool.Exec(`UPDATE admins SET digest = $1, digest_sent_at = NULL WHERE email = $2`, digest, testHelpers.ExampleAdmin["email"])
+	if err != nil {
+		t.Fatalf("Update digest error: %v", err)
+	}
+}
+
+// countDigests returns the number of digests queued for the example admin.
+func countDigests(t *testing.T) int {
+	emails, err := outbox.RetrieveEmails(testHelpers.ExampleAdmin["email"])
+	if err != nil {
+		t.Fatalf("RetrieveEmails error: %v", err)
+	}
+
+	count := 0
+	for _, e := range emails {
+		if e.Template == email.TemplateDigest {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestSendDigests(t *testing.T) {
+	if err := testHelpers.AddPendingGuest(); err != nil {
+		t.Fatalf("AddPendingGuest error: %v", err)
+	}
+
+	subscribe(t, true)
+	before := countDigests(t)
+
+	summary, err := sendDigests(time.Now())
+	if err != nil || summary.Sent != 1 {
+		t.Fatalf("sendDigests result %+v/%v, want one sent/nil", summary, err)
+	}
+
+	if n := countDigests(t) - before; n != 1 {
+		t.Fatalf("Queued %d digests for the admin, want 1", n)
+	}
+
+	// The digest is only sent once per period.
+	summary, err = sendDigests(time.Now())
+	if err != nil || summary.Subscribers != 0 {
+		t.Fatalf("sendDigests result %+v/%v, want no subscribers/nil", summary, err)
+	}
+}
+
+func TestSendDigestsNotSubscribed(t *testing.T) {
+	subscribe(t, false)
+	before := countDigests(t)
+
+	summary, err := sendDigests(time.Now())
+	if err != nil || summary.Sent != 0 {
+		t.Fatalf("sendDigests result %+v/%v, want none sent/nil", summary, err)
+	}
+
+	if n := countDigests(t) - before; n != 0 {
+		t.Fatalf("Queued %d digests for the admin, want 0", n)
+	}
+}
+
+func TestBuildDigestExpired(t *testing.T) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	now := time.Now()
+
+	_, err := pool.Exec(`UPDATE invites SET expiration = $1 WHERE invitee = $2`, now.Add(-2*time.Hour), testHelpers.ExampleGuest["email"])
+	if err != nil {
+		t.Fatalf("Update expiration error: %v", err)
+	}
+
+	admin := data.User{Email: testHelpers.ExampleAdmin["email"], Team: testHelpers.ExampleTeam["id"]}
+
+	values, ok, err := buildDigest(admin, now)
+	if err != nil || !ok {
+		t.Fatalf("buildDigest result %t/%v, want true/nil", ok, err)
+	}
+
+	if len(values.Expired) != 1 || values.Expired[0].User.Email != testHelpers.ExampleGuest["email"] {
+		t.Fatalf("buildDigest expired %+v, want the example guest", values.Expired)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/digest"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// Summary reports the number of admins subscribed to the digest, the number sent one,
+// and the number whose digest could not be prepared. Admins with nothing to report are
+// counted as subscribers but not as sent.
+type Summary struct {
+	Subscribers int `json:"subscribers"`
+	Sent        int `json:"sent"`
+	Failed      int `json:"failed"`
+}
+
+// userUrl links to the page in the admin client on which a guest or admin is edited.
+func userUrl(address string, isGuest bool) string {
+	page := "/edit-admin"
+
+	if isGuest {
+		page = "/edit-user"
+	}
+
+	return os.Getenv("EMAIL_REDIRECT_URL") + page + "?id=" + url.QueryEscape(address)
+}
+
+// proposalItems converts pending proposals into digest entries linking to each guest.
+func proposalItems(proposals []digest.Proposal) []email.DigestItem {
+	items := []email.DigestItem{}
+
+	for _, proposal := range proposals {
+		items = append(items, email.DigestItem{
+			User:   proposal.Guest,
+			Detail: proposal.Proposer,
+			Date:   proposal.Expires,
+			Url:    userUrl(proposal.Guest.Email, true),
+		})
+	}
+
+	return items
+}
+
+// buildDigest gathers the activity on an admin's team to report in their digest. It
+// returns false if there is nothing to report.
+func buildDigest(admin data.User, now time.Time) (email.DigestData, bool, error) {
+	values := email.DigestData{Recipient: admin, Date: now}
+
+	invites, err := guests.RetrievePendingInvites(admin.Team)
+
+	if err != nil {
+		return values, false, err
+	}
+
+	awaiting, expiring, err := digest.SplitProposals(invites, now)
+
+	if err != nil {
+		logs.LogError(err, "Parse Pending Invites Error")
+		return values, false, err
+	}
+
+	values.Pending = proposalItems(awaiting)
+	values.Expiring = proposalItems(expiring)
+
+	expired, err := digest.RetrieveExpiredGuests(admin.Team, now.Add(-digest.Period), now)
+
+	if err != nil {
+		return values, false, err
+	}
+
+	for _, guest := range expired {
+		values.Expired = append(values.Expired, email.DigestItem{
+			User: guest.Guest,
+			Date: guest.Expired,
+			Url:  userUrl(guest.Guest.Email, true),
+		})
+	}
+
+	failed, err := digest.RetrieveFailedUploads(admin.Team, now.Add(-digest.Period))
+
+	if err != nil {
+		return values, false, err
+	}
+
+	for _, upload := range failed {
+		values.Failed = append(values.Failed, email.DigestItem{
+			User:   upload.Uploader,
+			Detail: upload.Description,
+			Date:   upload.Failed,
+			Url:    userUrl(upload.Uploader.Email, upload.IsGuest),
+		})
+	}
+
+	empty := len(values.Pending)+len(values.Expiring)+len(values.Expired)+len(values.Failed) == 0
+
+	return values, !empty, nil
+}
+
+// sendDigests sends each subscribed admin the digest of their team's activity. An admin
+// whose digest cannot be prepared is counted as failed, without holding up the digests
+// of the remaining admins, and is sent their digest by the next run.
+func sendDigests(now time.Time) (Summary, error) {
+	var summary Summary
+
+	admins, err := digest.Subscribers(now)
+
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Subscribers = len(admins)
+
+	for _, admin := range admins {
+		values, ok, err := buildDigest(admin, now)
+
+		if err != nil {
+			logs.LogError(err, "Build Digest Error")
+			summary.Failed++
+
+			continue
+		}
+
+		if !ok {
+			if err = digest.Skip(admin); err != nil {
+				logs.LogError(err, "Skip Digest Error")
+				summary.Failed++
+			}
+
+			continue
+		}
+
+		message, err := email.Render(email.TemplateDigest, admin.Locale, values)
+
+		if err != nil {
+			logs.LogError(err, "Format Digest Email Error")
+			summary.Failed++
+
+			continue
+		}
+
+		message.To = []string{admin.Email}
+
+		if err = digest.Send(admin, message); err != nil {
+			logs.LogError(err, "Send Digest Error")
+			summary.Failed++
+
+			continue
+		}
+
+		summary.Sent++
+	}
+
+	return summary, nil
+}
+
+// adminsDigestHandler sends a daily email to each admin who has opted in, listing the
+// proposed invitations awaiting approval on their team, those whose requested access
+// ends soon, the guests whose access expired in the last day, and the uploads which
+// could not be sent to Aprimo. Each entry links to the relevant page in the admin
+// client. The emails are delivered through the outbox.
+func adminsDigestHandler(ctx context.Context) (Summary, error) {
+	logs.Start(ctx, nil)
+
+	summary, err := sendDigests(time.Now())
+
+	logs.Info("Sent admin digests", "subscribers", summary.Subscribers, "sent", summary.Sent, "failed", summary.Failed)
+
+	return summary, err
+}
+
+func main() {
+	lambda.Start(adminsDigestHandler)
+}
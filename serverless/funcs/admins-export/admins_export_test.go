@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+)
+
+func TestFormatRowsByTeam(t *testing.T) {
+	list := []data.AdminUser{
+		{Active: true, User: data.User{Email: "one@test.test", Team: "one"}},
+		{Active: false, User: data.User{Email: "two@test.test", Team: "two"}},
+	}
+
+	rows := formatRows(list, "two")
+	if len(rows) != 1 || rows[0][0] != "two@test.test" || rows[0][5] != "false" {
+		t.Fatalf("formatRows result %v, want only the admin on team two", rows)
+	}
+
+	rows = formatRows(list, "")
+	if len(rows) != 2 {
+		t.Fatalf("formatRows result %v, want both admins", rows)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/spreadsheet"
+)
+
+var header = []string{"Email", "Given Name", "Family Name", "Role", "Team", "Active"}
+
+// formatRows converts the list of admin users belonging to the given team (or all
+// teams if none is provided) into spreadsheet rows.
+func formatRows(list []data.AdminUser, team string) [][]string {
+	rows := [][]string{}
+
+	for _, admin := range list {
+		if team != "" && admin.Team != team {
+			continue
+		}
+
+		rows = append(rows, []string{
+			admin.Email,
+			admin.NameFirst,
+			admin.NameLast,
+			admin.Role,
+			admin.Team,
+			strconv.FormatBool(admin.Active),
+		})
+	}
+
+	return rows
+}
+
+// exportAdminsHandler handles the request to export the list of admin users as a CSV
+// or XLSX spreadsheet, optionally filtered by team. Only super admins may export
+// admins outside of their own team.
+func exportAdminsHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	return spreadsheet.HandleExport(event, spreadsheet.Export{
+		Name:   "admins",
+		Header: header,
+		Rows: func(team string) ([][]string, error) {
+			list, err := admins.RetrieveAdmins()
+
+			if err != nil {
+				logs.LogError(err, "Retrieve Admins Error")
+			}
+
+			return formatRows(list, team), err
+		},
+	})
+}
+
+func main() {
+	lambda.Start(exportAdminsHandler)
+}
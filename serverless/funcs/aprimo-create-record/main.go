@@ -3,80 +3,96 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
-	"log"
 
 	"github.com/IIP-Design/commons-gateway/utils/aprimo"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/uploads"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-func ParseEventBody(body string) (aprimo.FileRecordInitEvent, error) {
-	var parsed aprimo.FileRecordInitEvent
+// createRecord creates the Aprimo record for the file described by a single SQS message.
+func createRecord(pool *sql.DB, record events.SQSMessage, token string) error {
+	message, err := queue.Decode[queue.AprimoRecordMessage](record)
+	if err != nil {
+		logs.LogError(err, "SQS event body parse error")
+		return err
+	}
+
+	fileInfo := aprimo.FileRecordInitEvent(message)
+
+	logs.Debug("Creating Aprimo record", "key", fileInfo.Key, "messageId", record.MessageId)
+
+	var description string
+	var team string
+	var aprimoRecordId sql.NullString
+
+	done := tracing.StartDBSpan("SELECT", "uploads")
+
+	query := "SELECT uploads.description, teams.aprimo_name, uploads.aprimo_record_id FROM uploads INNER JOIN teams ON uploads.team_id=teams.id WHERE uploads.s3_id = $1"
+	err = pool.QueryRow(query, fileInfo.Key).Scan(&description, &team, &aprimoRecordId)
+	done(err)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Upload Metadata Query Error")
+		return err
+	}
+
+	// If there's already an Aprimo ID, this is a replayed event and we don't want to act on it
+	if aprimoRecordId.Valid {
+		logs.Warn("Object already has a record, but the event was not deleted", "key", fileInfo.Key, "recordId", aprimoRecordId.String)
+		return nil
+	}
 
-	b := []byte(body)
-	err := json.Unmarshal(b, &parsed)
+	// No Aprimo record ID means this is likely a new record that we need to create
+	recordId, err := aprimo.SubmitRecord(description, fileInfo, team, token)
+	metrics.Emit(metrics.Dimensions{Team: team, Outcome: metrics.Outcome(err)}, metrics.Counter("AprimoRecords"))
 
-	return parsed, err
+	if err != nil {
+		logs.LogError(err, "Aprimo Record Create Error")
+		uploads.RecordAprimoError(fileInfo.Key, err)
+		return err
+	}
+
+	logs.Info("Created Aprimo record", "key", fileInfo.Key, "recordId", recordId)
+
+	done = tracing.StartDBSpan("UPDATE", "uploads")
+
+	query = "UPDATE uploads SET aprimo_record_id = $1, aprimo_record_dt = NOW() WHERE s3_id = $2"
+	_, err = pool.Exec(query, recordId, fileInfo.Key)
+	done(err)
+
+	if err != nil {
+		logs.LogError(err, "Aprimo Record ID Save Error")
+	}
+
+	return err
 }
 
-// handleCreateAprimoRecord initiates the creation of a new record in Aprimo.
-func handleCreateAprimoRecord(ctx context.Context, event events.SQSEvent) error {
+// handleCreateAprimoRecord initiates the creation of a new record in Aprimo for each
+// message in the batch, reporting any which fail back to SQS to be retried.
+func handleCreateAprimoRecord(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	logs.Start(ctx, event)
+
 	token, err := aprimo.GetAuthToken()
 
 	if err != nil {
 		logs.LogError(err, "Unable to Authenticate Error")
-		return err
+		return events.SQSEventResponse{}, err
 	}
 
-	log.Printf("SQS Events: %d\n", len(event.Records))
+	logs.Debug("Received SQS events", "count", len(event.Records))
 
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	for _, record := range event.Records {
-		fileInfo, err := ParseEventBody(record.Body)
-		if err != nil {
-			logs.LogError(err, "SQS event body parse error")
-			return err
-		}
-		log.Printf("Event body: %s\n", record.Body)
-
-		var description string
-		var team string
-		var aprimoRecordId sql.NullString
-
-		query := "SELECT uploads.description, teams.aprimo_name, uploads.aprimo_record_id FROM uploads INNER JOIN teams ON uploads.team_id=teams.id WHERE uploads.s3_id = $1"
-		err = pool.QueryRow(query, fileInfo.Key).Scan(&description, &team, &aprimoRecordId)
-
-		if err != nil {
-			logs.LogError(err, "Retrieve Upload Metadata Query Error")
-			return err
-		}
-
-		if !aprimoRecordId.Valid { // No Aprimo record ID means this is likely a new record that we need to create
-			recordId, err := aprimo.SubmitRecord(description, fileInfo, team, token)
-			if err != nil {
-				logs.LogError(err, "Aprimo Record Create Error")
-				return err
-			} else {
-				log.Println(recordId)
-			}
-
-			query = "UPDATE uploads SET aprimo_record_id = $1, aprimo_record_dt = NOW() WHERE s3_id = $2"
-			_, err = pool.Exec(query, recordId, fileInfo.Key)
-			if err != nil {
-				logs.LogError(err, "Aprimo Record ID Save Error")
-				return err
-			}
-		} else { // If there's already an Aprimo ID, this is a replayed event and we don't want to act on it
-			log.Printf("Object %s already has a record (%s), but the event was not deleted", fileInfo.Key, aprimoRecordId.String)
-		}
-	}
-
-	return nil
+	return queue.Consume(ctx, "aprimo-create-record", event, func(record events.SQSMessage) error {
+		return createRecord(pool, record, token)
+	}), nil
 }
 
 func main() {
@@ -4,29 +4,27 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/IIP-Design/commons-gateway/utils/aprimo"
+	"github.com/IIP-Design/commons-gateway/utils/clients"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/uploads"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
 	"github.com/IIP-Design/commons-gateway/utils/queue"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 )
 
-type WrappedS3Events struct {
-	Records []events.S3EventRecord `json:"Records"`
-}
-
 const (
 	PART_SIZE         = 19 * 1024 * 1024 // 19 MB per part
 	PARTS_TO_DOWNLOAD = 10
@@ -42,22 +40,27 @@ func min(a, b int64) int64 {
 }
 
 // lookupFileType returns the file type info if file has not already been uploaded
-// to Aprimo. Duplication is not an error, but is a reason to skip re-processing
-func lookupFileType(key string) (string, error) {
+// to Aprimo. Duplication is not an error, but is a reason to skip re-processing.
+// The trace context recorded when the file was uploaded is also returned.
+func lookupFileType(key string) (string, string, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
 	var fileType string
 	var aprimoUploadToken sql.NullString
+	var traceParent sql.NullString
+
+	end := tracing.StartDBSpan("SELECT", "uploads")
 
-	query := "SELECT file_type, aprimo_upload_token FROM uploads WHERE s3_id = $1"
-	err := pool.QueryRow(query, key).Scan(&fileType, &aprimoUploadToken)
+	query := "SELECT file_type, aprimo_upload_token, trace_parent FROM uploads WHERE s3_id = $1"
+	err := pool.QueryRow(query, key).Scan(&fileType, &aprimoUploadToken, &traceParent)
+	end(err)
 
 	// There is a value for the token, so it's already been uploaded
 	if aprimoUploadToken.Valid {
-		return "", err
+		return "", traceParent.String, err
 	} else {
-		return fileType, err
+		return fileType, traceParent.String, err
 	}
 }
 
@@ -67,8 +70,11 @@ func markFileUpload(key string, uploadToken string) error {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
+	end := tracing.StartDBSpan("UPDATE", "uploads")
+
 	query := "UPDATE uploads SET aprimo_upload_token = $1, aprimo_upload_dt = NOW() WHERE s3_id = $2"
 	_, err := pool.Exec(query, uploadToken, key)
+	end(err)
 
 	return err
 }
@@ -108,8 +114,7 @@ func uploadFileInSegments(key string, token string, downloader *manager.Download
 		s3DownloadStartByte := S3_DOWNLOAD_BYTES * downloadBlock
 		s3DownloadEndByte := min(int64(S3_DOWNLOAD_BYTES*(downloadBlock+1)-1), fileSize)
 
-		// DBG
-		fmt.Printf("bytes=%d-%d\n", s3DownloadStartByte, s3DownloadEndByte)
+		logs.Debug("Downloading file block", "start", s3DownloadStartByte, "end", s3DownloadEndByte)
 
 		data := manager.NewWriteAtBuffer(buf)
 
@@ -125,8 +130,7 @@ func uploadFileInSegments(key string, token string, downloader *manager.Download
 			logs.LogError(err, "Error Retrieving S3 Object")
 			break
 		} else {
-			// DBG
-			fmt.Printf("Downloaded %d bytes in %d ms\n", bytesDownloaded, t2-t1)
+			logs.Debug("Downloaded file block", "bytes", bytesDownloaded, "ms", t2-t1)
 		}
 
 		dataBytes := data.Bytes()
@@ -135,11 +139,11 @@ func uploadFileInSegments(key string, token string, downloader *manager.Download
 		lastSegmentIsShort := bytesDownloaded%PART_SIZE > 0
 
 		if lastSegmentIsShort {
-			fmt.Printf("Excess bytes: %d \n", bytesDownloaded%PART_SIZE)
+			logs.Debug("Downloaded partial segment", "bytes", bytesDownloaded%PART_SIZE)
 			segmentsDownloaded += 1
 		}
 
-		fmt.Printf("Downloaded %d segments\n", segmentsDownloaded)
+		logs.Debug("Downloaded segments", "segments", segmentsDownloaded)
 
 		for seg := int64(0); seg < segmentsDownloaded; seg++ {
 			start := PART_SIZE * int64(seg)
@@ -162,7 +166,7 @@ func uploadFileInSegments(key string, token string, downloader *manager.Download
 				break
 			}
 
-			fmt.Printf("Uploaded bytes %d to %d in %d ms\n", start, end, t2-t1)
+			logs.Debug("Uploaded segment", "start", start, "end", end, "ms", t2-t1)
 
 			segment += 1
 		}
@@ -178,50 +182,152 @@ func uploadFileInSegments(key string, token string, downloader *manager.Download
 		// So the final increment of the loop should give the proper value
 		uploadToken, err = aprimo.CommitFileUpload(key, segment, uri, token)
 	} else {
-		log.Println("Not ready to commit")
+		logs.Warn("File upload not ready to commit", "key", key)
 	}
 
 	return uploadToken, err
 }
 
+// segmentCount returns the number of parts in which a file of the given size is uploaded.
+func segmentCount(size int64) int64 {
+	if size <= PART_SIZE {
+		return 1
+	}
+
+	return (size + PART_SIZE - 1) / PART_SIZE
+}
+
+// emitUploadMetrics records the size, number of segments, and duration of an upload to
+// Aprimo. An upload which returns no token was not committed and is counted as a failure.
+func emitUploadMetrics(size int64, duration time.Duration, uploadToken string, err error) {
+	outcome := metrics.Outcome(err)
+
+	if uploadToken == "" {
+		outcome = metrics.Failure
+	}
+
+	metrics.Emit(
+		metrics.Dimensions{Outcome: outcome},
+		metrics.Counter("AprimoUploads"),
+		metrics.Bytes("AprimoUploadBytes", size),
+		metrics.Metric{Name: "AprimoUploadSegments", Value: float64(segmentCount(size)), Unit: metrics.UnitCount},
+		metrics.Duration("AprimoUploadDuration", duration),
+	)
+}
+
 // sendRecordEvent triggers the SQS queue that creates an Aprimo record corresponding to the uploaded file.
 func sendRecordEvent(key string, fileType string, fileToken string) (string, error) {
-	var messageId string
-	var err error
-
-	event := aprimo.FileRecordInitEvent{
+	event := queue.AprimoRecordMessage{
 		Key:       key,
 		FileType:  fileType,
 		FileToken: fileToken,
 	}
 
-	json, err := json.Marshal(event)
+	queueUrl := os.Getenv("RECORD_CREATE_QUEUE")
+
+	// Send the message to SQS.
+	return queue.Send(event, queueUrl)
+}
+
+// transferFile uploads a file to Aprimo and then triggers the creation of its record.
+func transferFile(key string, token string, downloader *manager.Downloader, bucket string, fileType string, size int64) error {
+	var uploadToken string
+	var err error
+
+	started := time.Now()
+
+	// Concerted upload for small files, segmented if necessary
+	if size <= PART_SIZE {
+		uploadToken, err = uploadSmallFile(key, token, downloader, bucket, fileType)
+	} else {
+		uploadToken, err = uploadFileInSegments(key, token, downloader, bucket, fileType, size)
+	}
+
+	emitUploadMetrics(size, time.Since(started), uploadToken, err)
 
 	if err != nil {
-		logs.LogError(err, "Failed to Marshal SQS Body")
-		return messageId, err
+		logs.LogError(err, "File Upload to Aprimo Error")
+		return err
 	}
 
-	queueUrl := os.Getenv("RECORD_CREATE_QUEUE")
+	messageId, err := sendRecordEvent(key, fileType, uploadToken)
 
-	// Send the message to SQS.
-	return queue.SendToQueue(string(json), queueUrl)
+	if err != nil {
+		logs.LogError(err, "Error Triggering the Create Record SQS Event")
+		return err
+	}
+
+	logs.Info("Object sent onwards for record creation", "key", key, "messageId", messageId)
+
+	err = markFileUpload(key, uploadToken)
+
+	if err != nil {
+		logs.LogError(err, "Mark File Upload Error")
+	}
+
+	return err
 }
 
-// extractS3DataFromSqsEvent retrieves the S3 event embedded in the SQS message.
-func extractS3DataFromSqsEvent(record events.SQSMessage) []events.S3EventRecord {
-	var parsed WrappedS3Events
+// processMessage handles the S3 events wrapped in a single SQS message. Each file's
+// transfer continues the trace recorded when the file was uploaded.
+func processMessage(message events.SQSMessage, token string, downloader *manager.Downloader) error {
+	logs.Debug("Reading SQS message", "messageId", message.MessageId)
+
+	// ...but they are wrapping one or more (should be exactly one, but handle 1..N) S3 events...
+	r, err := queue.DecodeS3Notification(message)
+
+	if err != nil {
+		logs.LogError(err, "S3 Notification Decode Error")
+		return err
+	}
+
+	// ...and need to be processed as such
+	for _, record := range r {
+		bucket := record.S3.Bucket.Name
+		key := record.S3.Object.Key
+		size := record.S3.Object.Size
+
+		logs.Debug("Initiating Aprimo upload", "key", key)
 
-	json.Unmarshal([]byte(record.Body), &parsed)
+		fileType, traceParent, err := lookupFileType(key)
+
+		if err != nil {
+			logs.LogError(err, "File Type Lookup Error")
+			return err
+		}
+
+		// Presence of a file type indicates a new record
+		if fileType == "" {
+			logs.Warn("Object has already been uploaded, but the event was not deleted", "key", key)
+			continue
+		}
+
+		done := tracing.StartFrom(
+			traceParent,
+			"aprimo.upload",
+			attribute.String("upload.key", key),
+			attribute.Int64("upload.size", size),
+		)
+		err = transferFile(key, token, downloader, bucket, fileType, size)
+		done(err)
+
+		if err != nil {
+			uploads.RecordAprimoError(key, err)
+			return err
+		}
+	}
 
-	return parsed.Records
+	return nil
 }
 
 // handleUploadFileToAprimo, which is triggered by an event on the SQSAprimoUpload queue,
 // authenticates to Aprimo, downloads the file in question from S3, and then transmits that
 // file for uploading to Aprimo. Upon a successful upload, a new SQS event is triggered
-// directing the application to create a corresponding record in Aprimo.
-func handleUploadFileToAprimo(ctx context.Context, event events.SQSEvent) error {
+// directing the application to create a corresponding record in Aprimo. Messages whose
+// files fail to transfer are reported back to SQS to be retried.
+func handleUploadFileToAprimo(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	logs.Start(ctx, event)
+
 	var err error
 
 	// Retrieve Aprimo auth token
@@ -229,83 +335,24 @@ func handleUploadFileToAprimo(ctx context.Context, event events.SQSEvent) error
 
 	if err != nil {
 		logs.LogError(err, "Unable to Authenticate to Aprimo Error")
-		return err
+		return events.SQSEventResponse{}, err
 	}
 
 	// Prepare AWS services
-	sdkConfig, err := config.LoadDefaultConfig(ctx)
+	s3Client, err := clients.GetS3()
 
 	if err != nil {
-		logs.LogError(err, "Error Loading AWS Config")
-		return err
+		return events.SQSEventResponse{}, err
 	}
 
-	s3Client := s3.NewFromConfig(sdkConfig)
-
 	downloader := manager.NewDownloader(s3Client, func(d *manager.Downloader) {
 		d.PartSize = PART_SIZE
 	})
 
 	// We are receiving SQS record(s) for increased durability...
-	for _, e := range event.Records {
-		log.Printf("Reading SQS Message ID: %s\n", e.MessageId)
-
-		// ...but they are wrapping one or more (should be exactly one, but handle 1..N) S3 events...
-		r := extractS3DataFromSqsEvent(e)
-
-		// ...and need to be processed as such
-		for _, record := range r {
-			bucket := record.S3.Bucket.Name
-			key := record.S3.Object.Key
-			size := record.S3.Object.Size
-
-			log.Printf("Initiating Aprimo upload for the file: %s\n", key)
-
-			fileType, err := lookupFileType(key)
-
-			if err != nil {
-				logs.LogError(err, "File Type Lookup Error")
-				return err
-			}
-
-			// Presence of a file type indicates a new record
-			if fileType != "" {
-				var uploadToken string
-
-				// Concerted upload for small files, segmented if necessary
-				if size <= PART_SIZE {
-					uploadToken, err = uploadSmallFile(key, token, downloader, bucket, fileType)
-				} else {
-					uploadToken, err = uploadFileInSegments(key, token, downloader, bucket, fileType, size)
-				}
-
-				if err != nil {
-					logs.LogError(err, "File Upload to Aprimo Error")
-					return err
-				} else {
-					messageId, err := sendRecordEvent(key, fileType, uploadToken)
-
-					if err != nil {
-						logs.LogError(err, "Error Triggering the Create Record SQS Event")
-						return err
-					}
-
-					log.Printf("Object %s sent onwards for record creation with message ID %s\n", key, messageId)
-
-					err = markFileUpload(key, uploadToken)
-
-					if err != nil {
-						logs.LogError(err, "Mark File Upload Error")
-						return err
-					}
-				}
-			} else { // Not a new record
-				log.Printf("Object %s has already been uploaded, but the event was not deleted\n", key)
-			}
-		}
-	}
-
-	return nil
+	return queue.Consume(ctx, "aprimo-upload-file", event, func(message events.SQSMessage) error {
+		return processMessage(message, token, downloader)
+	}), nil
 }
 
 func main() {
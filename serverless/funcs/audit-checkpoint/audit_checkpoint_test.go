@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureAudit()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestCheckpoint(t *testing.T) {
+	date := time.Now().UTC().AddDate(0, 0, -1).Format(audit.DayLayout)
+
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"date": date},
+	}
+
+	first, err := checkpointHandler(context.TODO(), event)
+	if first.StatusCode != 200 || err != nil {
+		t.Fatalf("checkpointHandler result %d/%v, want 200/nil", first.StatusCode, err)
+	}
+
+	second, err := checkpointHandler(context.TODO(), event)
+	if second.StatusCode != 200 || err != nil {
+		t.Fatalf("checkpointHandler result %d/%v, want 200/nil", second.StatusCode, err)
+	}
+
+	if first.Body != second.Body {
+		t.Fatalf("Repeated checkpoint %s does not match the original %s", second.Body, first.Body)
+	}
+}
+
+func TestCheckpointIncompleteDay(t *testing.T) {
+	date := time.Now().UTC().Format(audit.DayLayout)
+
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"date": date},
+	}
+
+	resp, _ := checkpointHandler(context.TODO(), event)
+	if resp.StatusCode != 400 {
+		t.Fatalf("checkpointHandler status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestCheckpointBadDate(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"date": "yesterday"},
+	}
+
+	resp, _ := checkpointHandler(context.TODO(), event)
+	if resp.StatusCode != 400 {
+		t.Fatalf("checkpointHandler status %d, want 400", resp.StatusCode)
+	}
+}
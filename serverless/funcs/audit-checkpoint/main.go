@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// checkpointHandler handles the request to sign the head of the audit log's hash chain
+// as it stood at the end of a given day, which defaults to yesterday. It is also run on
+// a daily schedule, in which case no date is provided and the previous day is signed.
+func checkpointHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	day := time.Now().UTC().AddDate(0, 0, -1)
+
+	if date := event.QueryStringParameters["date"]; date != "" {
+		parsed, err := time.Parse(audit.DayLayout, date)
+
+		if err != nil {
+			return msgs.SendCustomError(errors.New("date must be formatted as YYYY-MM-DD"), 400)
+		}
+
+		day = parsed
+	}
+
+	checkpoint, err := audit.CreateCheckpoint(day)
+
+	if errors.Is(err, audit.ErrIncompleteDay) {
+		return msgs.SendCustomError(err, 400)
+	} else if err != nil {
+		logs.LogError(err, "Create Audit Checkpoint Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(checkpoint)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(checkpointHandler)
+}
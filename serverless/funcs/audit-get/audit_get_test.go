@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	testHelpers.TearDownTestDb()
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := parseFilter(map[string]string{
+		"actor":  "admin@example.com",
+		"from":   "2023-12-01T00:00:00Z",
+		"limit":  "10",
+		"before": "42",
+	})
+
+	if err != nil {
+		t.Fatalf("parseFilter error %v, want nil", err)
+	} else if filter.Actor != "admin@example.com" || filter.Limit != 10 || filter.Before != 42 || filter.From.IsZero() {
+		t.Fatalf("parseFilter result %+v does not match the parameters", filter)
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	invalid := []map[string]string{
+		{"from": "yesterday"},
+		{"to": "2023-12-01"},
+		{"limit": "-1"},
+		{"before": "abc"},
+	}
+
+	for _, params := range invalid {
+		if _, err := parseFilter(params); err == nil {
+			t.Fatalf("parseFilter(%v) returned no error", params)
+		}
+	}
+}
+
+func TestGetAudit(t *testing.T) {
+	event := audit.Event{
+		Actor:  testHelpers.ExampleAdmin["email"],
+		Action: audit.GuestArchive,
+		Target: testHelpers.ExampleGuest["email"],
+	}
+
+	archived, err := guests.ArchiveGuest(testHelpers.ExampleGuest["email"], testHelpers.ExampleAdmin["email"], "Testing", event)
+	if !archived || err != nil {
+		t.Fatalf("ArchiveGuest result %t/%v, want true/nil", archived, err)
+	}
+
+	resp, err := getAuditHandler(context.TODO(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{
+			"target": testHelpers.ExampleGuest["email"],
+			"action": audit.GuestArchive,
+		},
+	})
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("getAuditHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	changeRe := regexp.MustCompile(`"guests\.archive_reason":{"before":null,"after":"Testing"}`)
+
+	if !changeRe.MatchString(resp.Body) {
+		t.Fatalf("Audit event is missing the recorded change: %s", resp.Body)
+	}
+}
+
+func TestGetAuditBadRequest(t *testing.T) {
+	resp, _ := getAuditHandler(context.TODO(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"from": "yesterday"},
+	})
+	if resp.StatusCode != 400 {
+		t.Fatalf("getAuditHandler status %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// parseTime reads an optional RFC 3339 timestamp from the query parameters.
+func parseTime(params map[string]string, name string) (time.Time, error) {
+	value := params[name]
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return parsed, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+
+	return parsed, nil
+}
+
+// parseInt reads an optional positive integer from the query parameters.
+func parseInt(params map[string]string, name string) (int64, error) {
+	value := params[name]
+
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || parsed < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+
+	return parsed, nil
+}
+
+// parseFilter converts the request's query parameters into a filter for the audit log.
+func parseFilter(params map[string]string) (audit.Filter, error) {
+	filter := audit.Filter{
+		Actor:  params["actor"],
+		Target: params["target"],
+		Action: params["action"],
+	}
+
+	var err error
+
+	if filter.From, err = parseTime(params, "from"); err != nil {
+		return filter, err
+	}
+
+	if filter.To, err = parseTime(params, "to"); err != nil {
+		return filter, err
+	}
+
+	if filter.Before, err = parseInt(params, "before"); err != nil {
+		return filter, err
+	}
+
+	limit, err := parseInt(params, "limit")
+	filter.Limit = int(limit)
+
+	return filter, err
+}
+
+// getAuditHandler handles the request to retrieve events from the audit log. The events
+// may be filtered by actor, target, action and time range, and are returned newest first.
+// The id of the last event returned may be passed as `before` to retrieve the next page.
+func getAuditHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	filter, err := parseFilter(event.QueryStringParameters)
+
+	if err != nil {
+		return msgs.SendCustomError(err, 400)
+	}
+
+	events, err := audit.RetrieveEvents(filter)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Audit Events Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(events)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(getAuditHandler)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureAudit()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestVerifyAudit(t *testing.T) {
+	event := audit.Event{
+		Actor:  testHelpers.ExampleAdmin["email"],
+		Action: audit.GuestArchive,
+		Target: testHelpers.ExampleGuest["email"],
+	}
+
+	_, err := guests.ArchiveGuest(testHelpers.ExampleGuest["email"], testHelpers.ExampleAdmin["email"], "Testing", event)
+	if err != nil {
+		t.Fatalf("ArchiveGuest error %v, want nil", err)
+	}
+
+	resp, err := verifyAuditHandler(context.TODO())
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("verifyAuditHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	validRe := regexp.MustCompile(`^{"data":{"chain":{"valid":true`)
+
+	if !validRe.MatchString(resp.Body) {
+		t.Fatalf("Audit chain failed verification: %s", resp.Body)
+	}
+}
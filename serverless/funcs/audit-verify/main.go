@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// verifyAuditHandler walks the audit log's hash chain from the first event to the most
+// recent and then checks every signed daily checkpoint against it. The response reports
+// the first broken link in the chain and the first checkpoint which no longer matches.
+func verifyAuditHandler(ctx context.Context) (msgs.Response, error) {
+	logs.Start(ctx, nil)
+
+	chain, err := audit.VerifyChain()
+
+	if err != nil {
+		logs.LogError(err, "Verify Audit Chain Error")
+		return msgs.SendServerError(err)
+	}
+
+	checkpoint, err := audit.VerifyCheckpoints()
+
+	if err != nil {
+		logs.LogError(err, "Verify Audit Checkpoints Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(map[string]any{
+		"valid":      chain.Valid && checkpoint == nil,
+		"chain":      chain,
+		"checkpoint": checkpoint,
+	})
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(verifyAuditHandler)
+}
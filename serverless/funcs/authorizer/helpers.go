@@ -62,12 +62,28 @@ func retrieveScopes(endpoint string, method string) []string {
 		} else if method == "PUT" {
 			return SuperAdmins.Array()
 		}
+	case "admin/digest-preference":
+		return StateAdmins.Array()
+	case "admin/archive":
+		return SuperAdmins.Array()
+	case "admin/restore":
+		return SuperAdmins.Array()
 	case "admins":
 		return SuperAdmins.Array()
+	case "admins/export":
+		return SuperAdmins.Array()
+	case "audit":
+		return SuperAdmins.Array()
+	case "audit/checkpoint":
+		return SuperAdmins.Array()
+	case "creds/bulk":
+		return StateAdmins.Array()
 	case "creds/propose":
 		return GuestAdmins.Array()
 	case "creds/provision":
 		return StateAdmins.Array()
+	case "dlq":
+		return SuperAdmins.Array()
 	case "guest":
 		if method == "GET" {
 			return All.Array()
@@ -78,12 +94,26 @@ func retrieveScopes(endpoint string, method string) []string {
 		}
 	case "guest/approve":
 		return StateAdmins.Array()
+	case "guest/archive":
+		return StateAdmins.Array()
+	case "guest/email":
+		return StateAdmins.Array()
+	case "guest/erase":
+		return SuperAdmins.Array()
+	case "guest/export":
+		return All.Array()
 	case "guest/password":
 		return AllGuests.Array()
 	case "guest/reauth":
 		return AllAdmins.Array()
+	case "guest/reject":
+		return StateAdmins.Array()
+	case "guest/restore":
+		return StateAdmins.Array()
 	case "guests":
 		return StateAdmins.Array()
+	case "guests/export":
+		return StateAdmins.Array()
 	case "guests/pending":
 		return StateAdmins.Array()
 	case "guests/uploaders":
@@ -96,10 +126,16 @@ func retrieveScopes(endpoint string, method string) []string {
 		} else if method == "PUT" {
 			return SuperAdmins.Array()
 		}
+	case "team/archive":
+		return SuperAdmins.Array()
+	case "team/restore":
+		return SuperAdmins.Array()
 	case "teams":
 		return AllAdmins.Array()
 	case "upload":
 		return All.Array()
+	case "uploads/export":
+		return StateAdmins.Array()
 	default:
 		return SuperAdmins.Array()
 	}
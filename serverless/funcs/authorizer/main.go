@@ -88,6 +88,8 @@ func handleAuthorizationRequest(
 	ctx context.Context,
 	event events.APIGatewayCustomAuthorizerRequest,
 ) (events.APIGatewayCustomAuthorizerResponse, error) {
+	logs.Start(ctx, event)
+
 	token := event.AuthorizationToken
 	arnInfo := parseMethodArn(event.MethodArn)
 
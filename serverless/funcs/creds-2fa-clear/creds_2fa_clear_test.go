@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -33,7 +34,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestClearMfa(t *testing.T) {
-	expiredMFACodeHandler()
+	expiredMFACodeHandler(context.TODO())
 	updated, err := checkMfaTable()
 	if !updated || err != nil {
 		t.Fatalf("expiredMFACodeHandler result %t/%v, want true/nil", updated, err)
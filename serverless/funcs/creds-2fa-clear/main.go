@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 
@@ -8,7 +10,9 @@ import (
 )
 
 // expiredMFACodeHandler deletes any 2FA request older than 20 minutes.
-func expiredMFACodeHandler() {
+func expiredMFACodeHandler(ctx context.Context) {
+	logs.Start(ctx, nil)
+
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -13,16 +12,18 @@ import (
 	"github.com/rs/xid"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/suppressions"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
 	"github.com/IIP-Design/commons-gateway/utils/queue"
 	"github.com/IIP-Design/commons-gateway/utils/randstr"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 )
 
-// registerMfaRequest saves the generated 2FA and request id to the database.
-// These values are referenced when authenticating the user.
-func registerMfaRequest(requestId xid.ID, code string) error {
+// registerMfaRequest saves the generated 2FA, request id, and requesting user to the
+// database. These values are referenced when authenticating the user.
+func registerMfaRequest(requestId xid.ID, code string, username string) error {
 	var err error
 
 	pool := data.ConnectToDB()
@@ -30,8 +31,8 @@ func registerMfaRequest(requestId xid.ID, code string) error {
 
 	currentTime := time.Now()
 
-	insertMfa := `INSERT INTO mfa( request_id, code, date_created ) VALUES ( $1, $2, $3 );`
-	_, err = pool.Exec(insertMfa, requestId.String(), code, currentTime)
+	insertMfa := `INSERT INTO mfa( request_id, code, date_created, user_id ) VALUES ( $1, $2, $3, $4 );`
+	_, err = pool.Exec(insertMfa, requestId.String(), code, currentTime, username)
 
 	if err != nil {
 		logs.LogError(err, "Save MFA Request Query Error")
@@ -41,7 +42,8 @@ func registerMfaRequest(requestId xid.ID, code string) error {
 }
 
 // initiateEmailQueue sends the 2FA code to the SQS queue
-// that manages the the sending of 2FA emails.
+// that manages the the sending of 2FA emails. No code is
+// sent to an address which has been suppressed.
 func initiateEmailQueue(username string, code string) error {
 	var err error
 
@@ -51,38 +53,29 @@ func initiateEmailQueue(username string, code string) error {
 
 	var user data.User
 
-	query := `SELECT email, first_name, last_name FROM guests WHERE email = $1;`
-	err = pool.QueryRow(query, username).Scan(&user.Email, &user.NameFirst, &user.NameLast)
+	query := `SELECT email, first_name, last_name, locale FROM guests WHERE email = $1;`
+	err = pool.QueryRow(query, username).Scan(&user.Email, &user.NameFirst, &user.NameLast, &user.Locale)
 
 	if err != nil {
 		logs.LogError(err, "Retrieve User Data Error")
 		return err
 	}
 
-	// Prepare the message sent by SQS.
-	body := map[string]any{
-		"user": user,
-		"code": code,
-	}
-
-	json, err := json.Marshal(body)
-
-	if err != nil {
-		logs.LogError(err, "Failed to Marshal SQS Body")
+	if err = suppressions.Check(user.Email); err != nil {
 		return err
 	}
 
 	queueUrl := os.Getenv("EMAIL_MFA_QUEUE")
 
 	// Send the message to SQS.
-	messageId, err := queue.SendToQueue(string(json), queueUrl)
+	messageId, err := queue.Send(queue.MFACodeMessage{User: user, Code: code}, queueUrl)
 
 	if err != nil {
 		logs.LogError(err, "Failed to Send Queue Message")
 		return err
 	}
 
-	fmt.Println("Sent message with ID: " + messageId)
+	logs.Debug("Sent 2FA queue message", "messageId", messageId)
 
 	return err
 }
@@ -90,6 +83,11 @@ func initiateEmailQueue(username string, code string) error {
 // generateMfaHandler creates a one-time code to be used as a second
 // factor when authenticating guest users.
 func generateMfaHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	end := tracing.StartRequest(ctx, "creds-2fa", event)
+	defer end()
+
 	username := event.QueryStringParameters["username"]
 
 	if username == "" {
@@ -118,7 +116,7 @@ func generateMfaHandler(ctx context.Context, event events.APIGatewayProxyRequest
 	}
 
 	// Save the 2FA request.
-	err = registerMfaRequest(requestId, code)
+	err = registerMfaRequest(requestId, code, username)
 
 	if err != nil {
 		logs.LogError(err, "Failed to Register 2FA Code")
@@ -128,7 +126,9 @@ func generateMfaHandler(ctx context.Context, event events.APIGatewayProxyRequest
 	// Email the user their code.
 	err = initiateEmailQueue(username, code)
 
-	if err != nil {
+	if errors.Is(err, suppressions.ErrSuppressed) {
+		return msgs.SendCustomError(err, 409)
+	} else if err != nil {
 		logs.LogError(err, "Failed to Send 2FA Code")
 		return msgs.SendCustomError(errors.New("internal error"), 500)
 	}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestParseInviteFile(t *testing.T) {
+	content := fmt.Sprintf(`first_name,last_name,email,role,expiration
+Jane,Doe,jane@test.test,,%s
+John,Doe,john@test.test,guest admin,2999-01-01
+`, testHelpers.FarFutureDateStr())
+
+	rows, err := parseInviteFile(content, testHelpers.ExampleTeam["id"], testHelpers.ExampleAdmin["email"])
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("parseInviteFile result %d/%v, want 2/nil", len(rows), err)
+	}
+
+	for _, row := range rows {
+		if row.reason != "" {
+			t.Fatalf("row %d rejected with %s, want valid", row.row, row.reason)
+		}
+	}
+
+	if rows[0].invite.Invitee.Role != "guest" || rows[1].invite.Invitee.Team != testHelpers.ExampleTeam["id"] {
+		t.Fatalf("parseInviteFile result %+v, want default role and team set", rows)
+	}
+}
+
+func TestParseInviteFileReasons(t *testing.T) {
+	content := `first_name,last_name,email,role,expiration
+Jane,Doe,not-an-email,guest,2999-01-01
+Jane,Doe,jane@test.test,guest,yesterday
+Jane,Doe,past@test.test,guest,2000-01-01
+,Doe,noname@test.test,guest,2999-01-01
+Jane,Doe,role@test.test,admin,2999-01-01
+Jane,Doe,dup@test.test,guest,2999-01-01
+Jane,Doe,dup@test.test,guest,2999-01-01
+`
+	want := []string{
+		ReasonInvalidEmail,
+		ReasonBadDate,
+		ReasonBadDate,
+		ReasonMissingName,
+		ReasonInvalidRole,
+		"",
+		ReasonDuplicate,
+	}
+
+	rows, err := parseInviteFile(content, testHelpers.ExampleTeam["id"], testHelpers.ExampleAdmin["email"])
+	if err != nil || len(rows) != len(want) {
+		t.Fatalf("parseInviteFile result %d/%v, want %d/nil", len(rows), err, len(want))
+	}
+
+	for i, row := range rows {
+		if row.reason != want[i] {
+			t.Fatalf("row %d rejected with %q, want %q", row.row, row.reason, want[i])
+		}
+	}
+}
+
+func TestParseInviteFileLocale(t *testing.T) {
+	content := `first_name,last_name,email,role,expiration,locale
+Jane,Doe,jane@test.test,guest,2999-01-01,fr-CA
+John,Doe,john@test.test,guest,2999-01-01,
+Juan,Doe,juan@test.test,guest,2999-01-01,klingon
+`
+
+	rows, err := parseInviteFile(content, testHelpers.ExampleTeam["id"], testHelpers.ExampleAdmin["email"])
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("parseInviteFile result %d/%v, want 3/nil", len(rows), err)
+	}
+
+	if rows[0].invite.Invitee.Locale != "fr" || rows[1].invite.Invitee.Locale != "" || rows[2].reason != ReasonBadLocale {
+		t.Fatalf("parseInviteFile result %+v, want fr, no locale, and an unsupported locale", rows)
+	}
+}
+
+func TestParseInviteFileMissingColumn(t *testing.T) {
+	_, err := parseInviteFile("first_name,last_name,email\nJane,Doe,jane@test.test\n", testHelpers.ExampleTeam["id"], testHelpers.ExampleAdmin["email"])
+	if err == nil {
+		t.Fatalf("parseInviteFile result nil, want missing column error")
+	}
+}
+
+func TestBulkMissingData(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"team": ""}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := bulkProvisionHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("bulkProvisionHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestBulkNotAdmin(t *testing.T) {
+	body := fmt.Sprintf(`{"team": "%s", "file": "first_name"}`, testHelpers.ExampleTeam["id"])
+	event := events.APIGatewayProxyRequest{
+		Body:    body,
+		Headers: testHelpers.AuthHeaders("fake@test.fail", "admin"),
+	}
+
+	resp, err := bulkProvisionHandler(context.TODO(), event)
+	if resp.StatusCode != 403 || err != nil {
+		t.Fatalf("bulkProvisionHandler result %d/%v, want 403/nil", resp.StatusCode, err)
+	}
+}
+
+func TestBulkMissingTeam(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"team": "fake", "file": "first_name"}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := bulkProvisionHandler(context.TODO(), event)
+	if resp.StatusCode != 404 || err != nil {
+		t.Fatalf("bulkProvisionHandler result %d/%v, want 404/nil", resp.StatusCode, err)
+	}
+}
+
+func TestBulkDuplicateGuest(t *testing.T) {
+	content := fmt.Sprintf(`first_name,last_name,email,role,expiration\n%s,%s,%s,guest,2999-01-01\n`,
+		testHelpers.ExampleGuest["first_name"],
+		testHelpers.ExampleGuest["last_name"],
+		testHelpers.ExampleGuest["email"],
+	)
+	body := fmt.Sprintf(`{"team": "%s", "file": "%s"}`, testHelpers.ExampleTeam["id"], content)
+	event := events.APIGatewayProxyRequest{
+		Body:    body,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := bulkProvisionHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("bulkProvisionHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+}
+
+func TestBulkQueuesCredentials(t *testing.T) {
+	content := `first_name,last_name,email,role,expiration\nBulk,Guest,bulk@test.test,guest,2999-01-01\n`
+	body := fmt.Sprintf(`{"team": "%s", "file": "%s"}`, testHelpers.ExampleTeam["id"], content)
+	event := events.APIGatewayProxyRequest{
+		Body:    body,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := bulkProvisionHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("bulkProvisionHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	var result struct {
+		JobId string `json:"jobId"`
+	}
+	json.Unmarshal([]byte(resp.Body), &result)
+
+	job, _, err := invites.RetrieveInviteJob(result.JobId)
+	if err != nil || len(job.Rows) != 1 || job.Rows[0].Status != invites.JobRowQueued {
+		t.Fatalf("RetrieveInviteJob result %+v/%v, want one queued row", job, err)
+	}
+
+	emails, err := outbox.RetrieveEmails("bulk@test.test")
+	if err != nil || len(emails) != 1 {
+		t.Fatalf("RetrieveEmails result %+v/%v, want the credentials email", emails, err)
+	}
+}
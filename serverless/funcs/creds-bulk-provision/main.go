@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/creds"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/teams"
+	"github.com/IIP-Design/commons-gateway/utils/email/provision"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
+)
+
+const (
+	MaxRows = 500
+)
+
+// The reasons reported when a row cannot be invited.
+const (
+	ReasonDuplicate    = "duplicate"
+	ReasonInvalidEmail = "invalid email"
+	ReasonBadDate      = "bad date"
+	ReasonMissingName  = "missing name"
+	ReasonInvalidRole  = "invalid role"
+	ReasonBadLocale    = "unsupported locale"
+)
+
+// The columns expected in the header of a bulk invitation file.
+// The optional locale column sets the language of the emails sent to each guest.
+var inviteColumns = []string{"first_name", "last_name", "email", "role", "expiration"}
+
+type BulkInviteRequest struct {
+	Team string `json:"team"`
+	File string `json:"file"`
+}
+
+// bulkRow pairs an invitation with its position in the uploaded file and the reason
+// it was rejected, if any.
+type bulkRow struct {
+	row    int
+	invite data.Invite
+	reason string
+}
+
+// parseExpiration accepts either a full RFC3339 timestamp or a plain date, which is
+// taken as the end of that day (UTC). The expiration must be in the future.
+func parseExpiration(value string) (time.Time, error) {
+	expires, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		var date time.Time
+		date, err = time.Parse("2006-01-02", value)
+		expires = date.Add(24*time.Hour - time.Second)
+	}
+
+	if err != nil {
+		return expires, err
+	} else if !expires.After(time.Now()) {
+		return expires, errors.New("expiration must be in the future")
+	}
+
+	return expires, nil
+}
+
+// validateRow checks a single row of the file, returning the reason it should be
+// rejected or an empty string if it is valid.
+func validateRow(row *bulkRow, expiration string) string {
+	guest := &row.invite.Invitee
+
+	address, err := mail.ParseAddress(guest.Email)
+
+	if err != nil || address.Address != guest.Email {
+		return ReasonInvalidEmail
+	}
+
+	if guest.NameFirst == "" || guest.NameLast == "" {
+		return ReasonMissingName
+	}
+
+	// Default the role to guest if not provided.
+	if guest.Role == "" {
+		guest.Role = "guest"
+	} else if guest.Role != "guest" && guest.Role != "guest admin" {
+		return ReasonInvalidRole
+	}
+
+	locale, err := data.NormalizeLocale(guest.Locale)
+
+	if err != nil {
+		return ReasonBadLocale
+	}
+
+	guest.Locale = locale
+
+	expires, err := parseExpiration(expiration)
+
+	if err != nil {
+		return ReasonBadDate
+	}
+
+	row.invite.Expires = expires
+
+	return ""
+}
+
+// parseInviteFile reads a headered CSV file into a list of invitations to the given team,
+// validating each row. Rows repeating an email address found earlier in the file are
+// marked as duplicates. An error is only returned if the file itself cannot be read.
+func parseInviteFile(content string, team string, inviter string) ([]bulkRow, error) {
+	var rows []bulkRow
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return rows, errors.New("unable to read the file header")
+	}
+
+	columns := map[string]int{}
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range inviteColumns {
+		if _, ok := columns[name]; !ok {
+			return rows, fmt.Errorf("the file header is missing the %s column", name)
+		}
+	}
+
+	seen := map[string]bool{}
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		line++
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return rows, fmt.Errorf("unable to read row %d", line)
+		}
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		row := bulkRow{row: line}
+		row.invite.Inviter = inviter
+		row.invite.Invitee = data.User{
+			Email:     value("email"),
+			NameFirst: value("first_name"),
+			NameLast:  value("last_name"),
+			Role:      value("role"),
+			Team:      team,
+			Locale:    value("locale"),
+		}
+
+		row.reason = validateRow(&row, value("expiration"))
+
+		if row.reason == "" && seen[row.invite.Invitee.Email] {
+			row.reason = ReasonDuplicate
+		}
+
+		seen[row.invite.Invitee.Email] = true
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return rows, errors.New("the file does not contain any rows")
+	} else if len(rows) > MaxRows {
+		return rows, fmt.Errorf("the file may contain at most %d rows", MaxRows)
+	}
+
+	return rows, nil
+}
+
+// jobRows records the initial status of each row of the file. Rows which failed
+// validation are failed with their reason, while the rest await their invitation.
+func jobRows(rows []bulkRow) []invites.InviteJobRow {
+	var results []invites.InviteJobRow
+
+	for _, row := range rows {
+		result := invites.InviteJobRow{
+			Row:    row.row,
+			Email:  row.invite.Invitee.Email,
+			Status: invites.JobRowPending,
+		}
+
+		if row.reason != "" {
+			result.Status = invites.JobRowFailed
+			result.Reason = row.reason
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// queueCredentials returns a notification which adds the email providing a guest with the
+// password issued along with their invitation to the outbox, and records the email against
+// their row of the job, in the same transaction that saves the invitation.
+func queueCredentials(jobId string, row int, invitee data.User) outbox.Notify {
+	return func(tx *sql.Tx, pass string) error {
+		emailId, err := provision.Enqueue(tx, invitee, pass, provision.Create)
+
+		if err != nil {
+			return err
+		}
+
+		return invites.SetJobRowEmail(tx, jobId, row, emailId)
+	}
+}
+
+// saveInvitations registers each valid row using the same rules as individual invitations,
+// queueing the email providing the guest's credentials as part of the same transaction.
+// Rows belonging to existing users are reported as duplicates. Each invitation is
+// recorded in the audit log as a separate event.
+func saveInvitations(jobId string, rows []bulkRow, results []invites.InviteJobRow, event audit.Event) {
+	for i, row := range rows {
+		if row.reason != "" {
+			continue
+		}
+
+		notify := queueCredentials(jobId, row.row, row.invite.Invitee)
+		_, err := creds.SaveInitialInvite(row.invite, false, notify, event.For(row.invite.Invitee.Email))
+
+		if err != nil && err.Error() == "user already exists" {
+			results[i].Status, results[i].Reason = invites.JobRowFailed, ReasonDuplicate
+		} else if err != nil {
+			results[i].Status, results[i].Reason = invites.JobRowFailed, err.Error()
+		} else {
+			results[i].Status = invites.JobRowQueued
+			continue
+		}
+
+		invites.UpdateInviteJobRow(jobId, row.row, results[i].Status, results[i].Reason)
+	}
+}
+
+// bulkProvisionHandler handles the request to invite several guest users to a team from
+// a CSV file. A job is recorded for the file, after which every row is validated and
+// registered in turn, along with the email containing the guest's credentials. Rather
+// than being sent within the request, the emails are delivered by the dispatcher. It
+// responds with the id of the job, which can be used to check the outcome of each row.
+func bulkProvisionHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	end := tracing.StartRequest(ctx, "creds-bulk-provision", event)
+	defer end()
+
+	var request BulkInviteRequest
+
+	err := json.Unmarshal([]byte(event.Body), &request)
+
+	if err != nil {
+		logs.LogError(err, "Extract Bulk Invite Error")
+		return msgs.SendCustomError(errors.New("unable to parse request body"), 400)
+	} else if request.Team == "" || request.File == "" {
+		return msgs.SendCustomError(errors.New("data missing from request"), 400)
+	}
+
+	inviter, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	// Ensure inviter is an active admin user.
+	_, adminActive, err := admins.CheckForActiveAdmin(inviter)
+
+	if err != nil {
+		logs.LogError(err, "Admin Check Error")
+		return msgs.SendServerError(err)
+	} else if !adminActive {
+		err = fmt.Errorf("the user %s is not authorized to add users", inviter)
+
+		logs.LogError(err, "Admin Check Error")
+		return msgs.SendCustomError(err, 403)
+	}
+
+	teamExists, err := teams.CheckForExistingTeamById(request.Team)
+
+	if err != nil {
+		logs.LogError(err, "Check For Team Error")
+		return msgs.SendServerError(err)
+	} else if !teamExists {
+		return msgs.SendCustomError(errors.New("team does not exist"), 404)
+	}
+
+	rows, err := parseInviteFile(request.File, request.Team, inviter)
+
+	if err != nil {
+		logs.LogError(err, "Parse Invite File Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	logs.Info("Registering bulk invitations", "count", len(rows), "team", request.Team, "inviter", inviter)
+
+	results := jobRows(rows)
+
+	jobId, err := invites.CreateInviteJob(request.Team, inviter, results)
+
+	if err != nil {
+		logs.LogError(err, "Create Invite Job Error")
+		return msgs.SendServerError(err)
+	}
+
+	saveInvitations(jobId, rows, results, audit.FromRequest(event, audit.GuestInvite, ""))
+
+	summary := map[string]int{}
+
+	for _, result := range results {
+		summary[result.Status]++
+	}
+
+	body, err := msgs.MarshalBody(map[string]any{
+		"jobId":   jobId,
+		"total":   len(results),
+		"summary": summary,
+	})
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(bulkProvisionHandler)
+}
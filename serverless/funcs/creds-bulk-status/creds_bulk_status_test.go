@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestStatusMissingId(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": ""},
+		Headers:               testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := bulkStatusHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("bulkStatusHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestStatusMissingJob(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": "fake"},
+		Headers:               testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := bulkStatusHandler(context.TODO(), event)
+	if resp.StatusCode != 404 || err != nil {
+		t.Fatalf("bulkStatusHandler result %d/%v, want 404/nil", resp.StatusCode, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	rows := []invites.InviteJobRow{
+		{Row: 2, Email: "bulk@test.test", Status: invites.JobRowSent},
+		{Row: 3, Email: "bad-email", Status: invites.JobRowFailed, Reason: "invalid email"},
+	}
+
+	jobId, err := invites.CreateInviteJob(testHelpers.ExampleTeam["id"], testHelpers.ExampleAdmin["email"], rows)
+	if err != nil {
+		t.Fatalf("CreateInviteJob error: %v", err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": jobId},
+		Headers:               testHelpers.AuthHeaders("other@test.test", "admin"),
+	}
+
+	resp, err := bulkStatusHandler(context.TODO(), event)
+	if resp.StatusCode != 403 || err != nil {
+		t.Fatalf("bulkStatusHandler result %d/%v, want 403/nil", resp.StatusCode, err)
+	}
+
+	event = events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": jobId},
+		Headers:               testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err = bulkStatusHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("bulkStatusHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	job, _, err := invites.RetrieveInviteJob(jobId)
+	if err != nil || len(job.Rows) != 2 || job.Summary[invites.JobRowFailed] != 1 {
+		t.Fatalf("RetrieveInviteJob result %+v/%v, want two rows with one failure", job, err)
+	}
+}
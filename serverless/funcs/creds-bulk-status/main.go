@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// bulkStatusHandler reports the outcome of each row in a bulk invitation job. Super
+// admins may view any job, whereas other admins may only view the jobs they created.
+func bulkStatusHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	id := event.QueryStringParameters["id"]
+
+	if id == "" {
+		return msgs.SendCustomError(errors.New("job id not provided"), 400)
+	}
+
+	clientUser, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	clientRole, err := jwt.ExtractClientRole(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client Role Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	job, exists, err := invites.RetrieveInviteJob(id)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Invite Job Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		return msgs.SendCustomError(errors.New("job does not exist"), 404)
+	} else if clientRole != "super admin" && clientUser != job.CreatedBy {
+		return msgs.SendCustomError(errors.New("admins may only view their own jobs"), 403)
+	}
+
+	body, err := msgs.MarshalBody(job)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(bulkStatusHandler)
+}
@@ -6,18 +6,20 @@ import (
 	"fmt"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/creds"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/email/propose"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // handleInvitation coordinates all the actions associated with inviting a guest user.
-func handleProposedInvitation(invite data.Invite) error {
+func handleProposedInvitation(invite data.Invite, event audit.Event) error {
 	var err error
 
 	// Ensure proposer is an active admin user.
@@ -33,16 +35,16 @@ func handleProposedInvitation(invite data.Invite) error {
 		return errors.New("you are not authorized to propose user invites")
 	}
 
-	fmt.Printf("Registering the invitation of %s by %s\n", invite.Invitee.Email, proposer.Email)
+	logs.Info("Registering proposed invitation", "invitee", invite.Invitee.Email, "proposer", proposer.Email)
 
-	_, err = creds.SaveInitialInvite(invite, true)
+	_, err = creds.SaveInitialInvite(invite, true, nil, event)
 
 	if err != nil {
 		logs.LogError(err, "Save Credentials Error")
 		return err
 	}
 
-	fmt.Printf("Sending %s their temporary credentials\n", invite.Invitee.Email)
+	logs.Info("Notifying admins of proposed invitation", "invitee", invite.Invitee.Email)
 
 	err = propose.MailProposedInvite(proposer, invite.Invitee)
 
@@ -55,6 +57,8 @@ func handleProposedInvitation(invite data.Invite) error {
 //  2. Provision preliminary credentials for the guest user
 //  3. Initiate the admin and guest user notifications
 func proposalHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	invite, err := data.ExtractInvite(event.Body)
 
 	if err != nil {
@@ -62,7 +66,8 @@ func proposalHandler(ctx context.Context, event events.APIGatewayProxyRequest) (
 		return msgs.SendServerError(err)
 	}
 
-	err = handleProposedInvitation(invite)
+	err = handleProposedInvitation(invite, audit.FromRequest(event, audit.GuestPropose, invite.Invitee.Email))
+	metrics.Emit(metrics.Dimensions{Team: invite.Invitee.Team, Outcome: metrics.Outcome(err)}, metrics.Counter("InvitationProposals"))
 
 	if err != nil {
 		logs.LogError(err, "Handle Proposed Invite Error")
@@ -8,6 +8,9 @@ import (
 
 	testConfig "github.com/IIP-Design/commons-gateway/test/config"
 	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -78,6 +81,40 @@ func TestAdminMiss(t *testing.T) {
 	}
 }
 
+func TestReinviteArchived(t *testing.T) {
+	email := testHelpers.ExampleGuest2["email"]
+
+	archived, err := guests.ArchiveGuest(email, testHelpers.ExampleAdmin["email"], "Testing", audit.Event{Actor: testHelpers.ExampleAdmin["email"], Action: audit.GuestArchive})
+	if !archived || err != nil {
+		t.Fatalf("ArchiveGuest result %t/%v, want true/nil", archived, err)
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	_, err = pool.Exec(`UPDATE guests SET locked = true, login_attempt = 5 WHERE email = $1`, email)
+	if err != nil {
+		t.Fatalf("Lock guest error: %v", err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body: makeJsonBody(email, testHelpers.ExampleAdmin["email"]),
+	}
+
+	resp, err := provisionHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("provisionHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	var locked bool
+	var attempts int
+
+	err = pool.QueryRow(`SELECT locked, login_attempt FROM guests WHERE email = $1`, email).Scan(&locked, &attempts)
+	if locked || attempts != 0 || err != nil {
+		t.Fatalf("Guest lock is %t/%d/%v, want false/0/nil", locked, attempts, err)
+	}
+}
+
 func makeJsonBody(inviteeEmail string, adminEmail string) string {
 	return fmt.Sprintf(`{
 		"invitee": {
@@ -8,15 +8,20 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/admins"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/creds"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/email/provision"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
 )
 
 // handleInvitation coordinates all the actions associated with inviting a guest user.
-func handleInvitation(invite data.Invite) error {
+// The email providing the guest's credentials is saved to the outbox along with the
+// invitation, so that it is retried by the dispatcher should it fail to send now.
+func handleInvitation(ctx context.Context, invite data.Invite, event audit.Event) error {
 	// Ensure inviter is an active admin user.
 	_, adminActive, err := admins.CheckForActiveAdmin(invite.Inviter)
 
@@ -30,24 +35,20 @@ func handleInvitation(invite data.Invite) error {
 		return err
 	}
 
-	fmt.Printf("Registering the invitation of %s by %s\n", invite.Invitee.Email, invite.Inviter)
+	logs.Info("Registering invitation", "invitee", invite.Invitee.Email, "inviter", invite.Inviter)
 
-	pass, err := creds.SaveInitialInvite(invite, false)
+	_, err = creds.SaveInitialInvite(invite, false, provision.Queue(invite.Invitee, provision.Create), event)
 
 	if err != nil {
 		logs.LogError(err, "Save Credentials Error")
 		return err
 	}
 
-	fmt.Printf("Sending %s their temporary credentials\n", invite.Invitee.Email)
+	logs.Info("Sending temporary credentials", "invitee", invite.Invitee.Email)
 
-	_, err = provision.MailProvisionedCreds(invite.Invitee, pass, 0)
+	outbox.Flush(ctx, invite.Invitee.Email)
 
-	if err != nil {
-		logs.LogError(err, "Mail Credentials Error")
-	}
-
-	return err
+	return nil
 }
 
 // provisionHandler handles the request to grant a guest user temporary credentials. It
@@ -56,6 +57,8 @@ func handleInvitation(invite data.Invite) error {
 //  2. Provision credentials for the guest user
 //  3. Initiate the admin and guest user notifications
 func provisionHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	invite, err := data.ExtractInvite(event.Body)
 
 	if err != nil {
@@ -63,7 +66,8 @@ func provisionHandler(ctx context.Context, event events.APIGatewayProxyRequest)
 		return msgs.SendServerError(err)
 	}
 
-	err = handleInvitation(invite)
+	err = handleInvitation(ctx, invite, audit.FromRequest(event, audit.GuestInvite, invite.Invitee.Email))
+	metrics.Emit(metrics.Dimensions{Team: invite.Invitee.Team, Outcome: metrics.Outcome(err)}, metrics.Counter("Invitations"))
 
 	if err != nil {
 		logs.LogError(err, "Handle Invite Error")
@@ -39,6 +39,8 @@ func handleCredentialRequest(username string) (creds.CredentialsData, error) {
 
 // getSaltHandler handles the request to retrieve the salt associated with a user based on the user name.
 func getSaltHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := data.ParseBodyData(event.Body)
 
 	user := parsed.Username
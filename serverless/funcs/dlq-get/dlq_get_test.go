@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestUnknownPipeline(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"pipeline": "email"},
+	}
+
+	resp, err := getDeadLettersHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("getDeadLettersHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestInvalidMax(t *testing.T) {
+	for _, max := range []string{"0", "101", "ten"} {
+		event := events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{"pipeline": "upload", "max": max},
+		}
+
+		resp, err := getDeadLettersHandler(context.TODO(), event)
+		if resp.StatusCode != 400 || err != nil {
+			t.Fatalf("getDeadLettersHandler result for max %s %d/%v, want 400/nil", max, resp.StatusCode, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/dlq"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// parseMax reads the optional maximum number of messages to list, which defaults to and
+// may not exceed the most messages read at once.
+func parseMax(params map[string]string) (int, error) {
+	value := params["max"]
+
+	if value == "" {
+		return dlq.MaxMessages, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil || parsed < 1 || parsed > dlq.MaxMessages {
+		return 0, errors.New("max must be an integer between 1 and 100")
+	}
+
+	return parsed, nil
+}
+
+// getDeadLettersHandler handles the request to list the messages in the dead-letter queue
+// of one of the Aprimo pipelines, along with the upload each message concerns.
+func getDeadLettersHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	max, err := parseMax(event.QueryStringParameters)
+
+	if err != nil {
+		return msgs.SendCustomError(err, 400)
+	}
+
+	pipeline, err := dlq.GetPipeline(event.QueryStringParameters["pipeline"])
+
+	if errors.Is(err, dlq.ErrUnknownPipeline) {
+		return msgs.SendCustomError(err, 400)
+	} else if err != nil {
+		logs.LogError(err, "Get Pipeline Error")
+		return msgs.SendServerError(err)
+	}
+
+	messages, err := dlq.List(pipeline, max)
+
+	if err != nil {
+		logs.LogError(err, "List Dead-Letter Messages Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(messages)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(getDeadLettersHandler)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestResolveBadRequest(t *testing.T) {
+	bodies := []string{
+		`{"pipeline":"upload","action":"redrive"`,
+		`{"pipeline":"upload","action":"redrive","messageIds":[]}`,
+		`{"pipeline":"upload","action":"retry","messageIds":["1"]}`,
+		`{"pipeline":"email","action":"abandon","messageIds":["1"]}`,
+	}
+
+	for _, body := range bodies {
+		resp, err := resolveDeadLettersHandler(context.TODO(), events.APIGatewayProxyRequest{Body: body})
+		if resp.StatusCode != 400 || err != nil {
+			t.Fatalf("resolveDeadLettersHandler result for %s %d/%v, want 400/nil", body, resp.StatusCode, err)
+		}
+	}
+}
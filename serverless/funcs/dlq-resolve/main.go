@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/dlq"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+type ResolveRequest struct {
+	Pipeline   string   `json:"pipeline"`
+	Action     string   `json:"action"`
+	MessageIds []string `json:"messageIds"`
+}
+
+// resolveDeadLettersHandler handles the request to redrive the chosen messages in the
+// dead-letter queue of one of the Aprimo pipelines back to their source queue, or to
+// abandon them. It responds with the outcome for each message.
+func resolveDeadLettersHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	var request ResolveRequest
+
+	err := json.Unmarshal([]byte(event.Body), &request)
+
+	if err != nil {
+		logs.LogError(err, "Extract Resolve Request Error")
+		return msgs.SendCustomError(errors.New("unable to parse request body"), 400)
+	} else if len(request.MessageIds) == 0 {
+		return msgs.SendCustomError(errors.New("no messages chosen"), 400)
+	} else if request.Action != dlq.ActionRedrive && request.Action != dlq.ActionAbandon {
+		return msgs.SendCustomError(dlq.ErrUnknownAction, 400)
+	}
+
+	pipeline, err := dlq.GetPipeline(request.Pipeline)
+
+	if errors.Is(err, dlq.ErrUnknownPipeline) {
+		return msgs.SendCustomError(err, 400)
+	} else if err != nil {
+		logs.LogError(err, "Get Pipeline Error")
+		return msgs.SendServerError(err)
+	}
+
+	results, err := dlq.Resolve(pipeline, request.Action, request.MessageIds, audit.FromRequest(event, "", ""))
+
+	if err != nil {
+		logs.LogError(err, "Resolve Dead-Letter Messages Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(results)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(resolveDeadLettersHandler)
+}
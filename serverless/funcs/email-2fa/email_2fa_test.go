@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 
 	testConfig "github.com/IIP-Design/commons-gateway/test/config"
 	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -28,28 +31,44 @@ func TestMain(m *testing.M) {
 
 func TestFmtEmailBody(t *testing.T) {
 	pattern := regexp.MustCompile(fmt.Sprintf(`(?m)<p>%s</p>`, CODE))
-	body := formatEmailBody(makeUser(), CODE)
+	message, err := formatEmail(makeUser(), CODE)
 
-	match := pattern.MatchString(body)
+	match := pattern.MatchString(message.Html)
 
-	if !match {
+	if !match || err != nil {
 		t.Fatal("Failed to match email body")
 	}
+
+	if !strings.Contains(message.Text, CODE) {
+		t.Fatal("Failed to match plain text email body")
+	}
 }
 
 func TestFmtEmail(t *testing.T) {
-	sourceEmail := os.Getenv("SOURCE_EMAIL_ADDRESS")
 	user := makeUser()
-	email := formatEmail(user, CODE, sourceEmail)
+	message, _ := formatEmail(user, CODE)
+
+	if message.To[0] != user.Email {
+		t.Fatalf(`Email ill formed: %s, expected %s`, message.To[0], user.Email)
+	}
+}
+
+func TestFmtEmailLocale(t *testing.T) {
+	user := makeUser()
+	user.Locale = "fr"
+	message, _ := formatEmail(user, CODE)
 
-	if email.Destination.ToAddresses[0] != user.Email {
-		t.Fatalf(`Email ill formed: %s, expected %s`, email.Destination.ToAddresses[0], user.Email)
+	if message.Subject != "Code de vérification pour la connexion à Content Commons" {
+		t.Fatalf(`Subject %s, expected the French subject`, message.Subject)
 	}
 }
 
 func TestSendEmail(t *testing.T) {
-	eventBody := fmt.Sprintf(`{"code":"%s","user":{"email":"%s","givenName":"%s","familyName":"%s"}}`,
-		CODE, testHelpers.ExampleGuest["email"], testHelpers.ExampleGuest["first_name"], testHelpers.ExampleGuest["last_name"])
+	eventBody, err := queue.Encode(queue.MFACodeMessage{Code: CODE, User: makeUser()})
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
 	event := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
@@ -59,9 +78,9 @@ func TestSendEmail(t *testing.T) {
 		},
 	}
 
-	err := email2FAHandler(context.TODO(), event)
-	if err != nil {
-		t.Fatalf(`Error: %v, want nil`, err)
+	resp, err := email2FAHandler(context.TODO(), event)
+	if len(resp.BatchItemFailures) != 0 || err != nil {
+		t.Fatalf(`Result %v/%v, want no failures/nil`, resp.BatchItemFailures, err)
 	}
 }
 
@@ -77,9 +96,39 @@ func TestSendEmailBadData(t *testing.T) {
 		},
 	}
 
-	err := email2FAHandler(context.TODO(), event)
-	if err == nil {
-		t.Fatal(`Error: nil, want not nil`)
+	resp, err := email2FAHandler(context.TODO(), event)
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != MESSAGE_ID || err != nil {
+		t.Fatalf(`Result %v/%v, want a failure for message %s/nil`, resp.BatchItemFailures, err, MESSAGE_ID)
+	}
+}
+
+func TestSendEmailToSink(t *testing.T) {
+	sink := &email.MemoryMailer{}
+	email.SetMailer(sink)
+	t.Cleanup(email.Reset)
+
+	eventBody, err := queue.Encode(queue.MFACodeMessage{Code: CODE, User: makeUser()})
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	event := events.SQSEvent{
+		Records: []events.SQSMessage{
+			{
+				MessageId: MESSAGE_ID,
+				Body:      eventBody,
+			},
+		},
+	}
+
+	resp, err := email2FAHandler(context.TODO(), event)
+	if len(resp.BatchItemFailures) != 0 || err != nil {
+		t.Fatalf(`Result %v/%v, want no failures/nil`, resp.BatchItemFailures, err)
+	}
+
+	sent := sink.Sent()
+	if len(sent) != 1 || sent[0].To[0] != testHelpers.ExampleGuest["email"] {
+		t.Fatalf(`Sent %d emails, want 1 to %s`, len(sent), testHelpers.ExampleGuest["email"])
 	}
 }
 
@@ -2,109 +2,71 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
-	sesTypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/email"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
 )
 
-const (
-	Subject = "Verification Code for Content Commons Login"
-	CharSet = "UTF-8"
-)
+// formatEmail renders the email providing a user with 2FA, in the user's preferred language.
+func formatEmail(user data.User, code string) (email.Message, error) {
+	message, err := email.Render(email.TemplateMFACode, user.Locale, email.MFACodeData{
+		Recipient: user,
+		Code:      code,
+	})
 
-type TwoFactorAuthData struct {
-	Code string    `json:"code"`
-	User data.User `json:"user"`
-}
+	message.To = []string{user.Email}
 
-// formatEmailBody constructs the body of the 2FA email.
-func formatEmailBody(user data.User, code string) string {
-	return fmt.Sprintf(
-		`<p>%s %s,</p>
-		<p>Please use this verification code to complete your sign in:</p>
-		<p>%s</p>
-		<p>Please note that this verification code will expire in 20 minutes. If you did not make this request, please disregard this email. </p>`,
-		user.NameFirst, user.NameLast, code,
-	)
+	return message, err
 }
 
-// formatEmail prepares the email to be sent providing a user with 2FA.
-func formatEmail(user data.User, code string, sourceEmail string) ses.SendEmailInput {
-	return ses.SendEmailInput{
-		Destination: &sesTypes.Destination{
-			CcAddresses: []string{},
-			ToAddresses: []string{
-				user.Email,
-			},
-		},
-		Content: &sesTypes.EmailContent{
-			Simple: &sesTypes.Message{
-				Body: &sesTypes.Body{
-					Html: &sesTypes.Content{
-						Charset: aws.String(CharSet),
-						Data:    aws.String(formatEmailBody(user, code)),
-					},
-				},
-				Subject: &sesTypes.Content{
-					Charset: aws.String(CharSet),
-					Data:    aws.String(Subject),
-				},
-			},
-		},
-		FromEmailAddress: &sourceEmail,
-	}
-}
+// processMessage emails the 2FA code in a single SQS message.
+func processMessage(mailer email.Mailer, message events.SQSMessage) error {
+	mfaInfo, err := queue.Decode[queue.MFACodeMessage](message)
 
-// email2FAHandler sends a guest user a 2FA code that they can use to log in.
-func email2FAHandler(ctx context.Context, event events.SQSEvent) error {
-	var err error
-
-	region := os.Getenv("AWS_SES_REGION")
-	sourceEmail := os.Getenv("SOURCE_EMAIL_ADDRESS")
+	if err != nil {
+		logs.LogError(err, fmt.Sprintf("Unable to decode body of message %s", message.MessageId))
+		return err
+	}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(region),
-	)
+	mfaEmail, err := formatEmail(mfaInfo.User, mfaInfo.Code)
 
 	if err != nil {
+		logs.LogError(err, fmt.Sprintf("Unable to format 2FA email for message %s", message.MessageId))
 		return err
 	}
 
-	sesClient := ses.NewFromConfig(cfg)
-
-	for _, message := range event.Records {
-		eventMessageId := message.MessageId
-		body := message.Body
+	_, err = mailer.Send(context.TODO(), mfaEmail)
+	metrics.Emit(metrics.Dimensions{Outcome: metrics.Outcome(err)}, metrics.Counter("MFACodeEmails"))
 
-		var mfaInfo TwoFactorAuthData
+	if err != nil {
+		logs.LogError(err, fmt.Sprintf("Unable to send 2FA code for message %s", message.MessageId))
+	}
 
-		err := json.Unmarshal([]byte(body), &mfaInfo)
+	return err
+}
 
-		if err != nil {
-			logs.LogError(err, fmt.Sprintf("Unable to unmarshal body of message %s", eventMessageId))
-			return err
-		}
+// email2FAHandler sends a guest user a 2FA code that they can use to log in. Messages
+// which could not be sent are reported back to SQS to be retried.
+func email2FAHandler(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	logs.Start(ctx, event)
 
-		emailInput := formatEmail(mfaInfo.User, mfaInfo.Code, sourceEmail)
+	mailer, err := email.GetMailer()
 
-		_, err = sesClient.SendEmail(context.TODO(), &emailInput)
-		if err != nil {
-			log.Println(err.Error())
-		}
+	if err != nil {
+		logs.LogError(err, "Get Mailer Error")
+		return events.SQSEventResponse{}, err
 	}
 
-	return nil
+	return queue.Consume(ctx, "email-2fa", event, func(message events.SQSMessage) error {
+		return processMessage(mailer, message)
+	}), nil
 }
 
 func main() {
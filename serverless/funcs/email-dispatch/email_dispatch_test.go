@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+)
+
+// failingMailer rejects every email, as SES does when throttling.
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, message email.Message) (string, error) {
+	return "", errors.New("maximum sending rate exceeded")
+}
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// enqueue adds an email for the example guest to the outbox.
+func enqueue(t *testing.T) string {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+	if err != nil {
+		t.Fatalf("Begin error: %v", err)
+	}
+
+	id, err := outbox.Enqueue(tx, email.TemplateCredentials, email.Message{
+		To:      []string{testHelpers.ExampleGuest["email"]},
+		Subject: "Content Commons Account Created",
+		Html:    "<p>Tmp-Pass-123</p>",
+		Text:    "Tmp-Pass-123",
+	})
+	if err != nil {
+		t.Fatalf("Enqueue error: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+
+	return id
+}
+
+func TestDispatch(t *testing.T) {
+	sink := &email.MemoryMailer{From: email.DefaultSender}
+	email.SetMailer(sink)
+	t.Cleanup(email.Reset)
+
+	id := enqueue(t)
+
+	summary, err := emailDispatchHandler(context.TODO())
+	if err != nil || summary.Sent < 1 {
+		t.Fatalf("emailDispatchHandler result %+v/%v, want at least one sent/nil", summary, err)
+	}
+
+	sent, err := outbox.RetrieveEmail(id)
+	if err != nil || sent.Status != outbox.StatusSent || sent.DateSent == nil {
+		t.Fatalf("RetrieveEmail result %+v/%v, want a sent email/nil", sent, err)
+	}
+}
+
+func TestDispatchRetry(t *testing.T) {
+	id := enqueue(t)
+
+	summary, err := outbox.Dispatch(context.TODO(), failingMailer{}, testHelpers.ExampleGuest["email"], outbox.BatchSize)
+	if err != nil || summary.Retrying != 1 {
+		t.Fatalf("Dispatch result %+v/%v, want one retrying/nil", summary, err)
+	}
+
+	pending, err := outbox.RetrieveEmail(id)
+	if err != nil || pending.Status != outbox.StatusPending || pending.Attempts != 1 || pending.LastError == "" {
+		t.Fatalf("RetrieveEmail result %+v/%v, want a pending email with one attempt/nil", pending, err)
+	}
+
+	// The email is not retried until its backoff has elapsed.
+	summary, err = outbox.Dispatch(context.TODO(), failingMailer{}, testHelpers.ExampleGuest["email"], outbox.BatchSize)
+	if err != nil || summary != (outbox.Summary{}) {
+		t.Fatalf("Dispatch result %+v/%v, want nothing dispatched/nil", summary, err)
+	}
+}
+
+func TestDispatchUnreadable(t *testing.T) {
+	sink := &email.MemoryMailer{From: email.DefaultSender}
+	email.SetMailer(sink)
+	t.Cleanup(email.Reset)
+
+	unreadable := enqueue(t)
+	readable := enqueue(t)
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	_, err := pool.Exec(`UPDATE email_outbox SET body_html = 'Tmp-Pass-123' WHERE id = $1`, unreadable)
+	if err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	summary, err := outbox.Dispatch(context.TODO(), sink, testHelpers.ExampleGuest["email"], outbox.BatchSize)
+	if err != nil || summary.Failed != 1 || summary.Sent < 1 {
+		t.Fatalf("Dispatch result %+v/%v, want one failed and the rest sent/nil", summary, err)
+	}
+
+	failed, err := outbox.RetrieveEmail(unreadable)
+	if err != nil || failed.Status != outbox.StatusFailed || failed.LastError == "" {
+		t.Fatalf("RetrieveEmail result %+v/%v, want a failed email with its error/nil", failed, err)
+	}
+
+	sent, err := outbox.RetrieveEmail(readable)
+	if err != nil || sent.Status != outbox.StatusSent {
+		t.Fatalf("RetrieveEmail result %+v/%v, want a sent email/nil", sent, err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// emailDispatchHandler delivers the emails waiting in the outbox, retrying those which
+// previously failed to send once their backoff has elapsed. It runs every minute.
+func emailDispatchHandler(ctx context.Context) (outbox.Summary, error) {
+	logs.Start(ctx, nil)
+
+	mailer, err := email.GetMailer()
+
+	if err != nil {
+		logs.LogError(err, "Get Mailer Error")
+		return outbox.Summary{}, err
+	}
+
+	summary, err := outbox.Dispatch(ctx, mailer, "", outbox.BatchSize)
+
+	if err != nil {
+		logs.LogError(err, "Dispatch Emails Error")
+	}
+
+	logs.Info("Dispatched emails", "sent", summary.Sent, "retrying", summary.Retrying, "failed", summary.Failed)
+
+	return summary, err
+}
+
+func main() {
+	lambda.Start(emailDispatchHandler)
+}
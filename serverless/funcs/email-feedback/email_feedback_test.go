@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/suppressions"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// fixture reads a sample SES notification shared with the queue package's tests.
+func fixture(t *testing.T, name string) events.SQSMessage {
+	body, err := os.ReadFile(filepath.Join("..", "..", "utils", "queue", "testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+
+	return events.SQSMessage{MessageId: name, Body: string(body)}
+}
+
+func TestToSuppressions(t *testing.T) {
+	tests := []struct {
+		fixture string
+		reason  string
+	}{
+		{"ses-bounce-permanent.json", suppressions.ReasonBounce},
+		{"ses-bounce-sns.json", suppressions.ReasonBounce},
+		{"ses-bounce-transient.json", ""},
+		{"ses-complaint.json", suppressions.ReasonComplaint},
+	}
+
+	for _, tt := range tests {
+		notification, err := queue.DecodeSESNotification(fixture(t, tt.fixture))
+		if err != nil {
+			t.Fatalf("DecodeSESNotification error: %v", err)
+		}
+
+		list := toSuppressions(notification)
+
+		if tt.reason == "" {
+			if len(list) != 0 {
+				t.Fatalf("toSuppressions %s result %+v, want none", tt.fixture, list)
+			}
+			continue
+		}
+
+		if len(list) != 1 || list[0].Reason != tt.reason || list[0].FeedbackId == "" || list[0].DateSuppressed.IsZero() {
+			t.Fatalf("toSuppressions %s result %+v, want one %s", tt.fixture, list, tt.reason)
+		}
+	}
+}
+
+func TestTransientBounce(t *testing.T) {
+	if err := processMessage(context.TODO(), fixture(t, "ses-bounce-transient.json")); err != nil {
+		t.Fatalf("processMessage error: %v", err)
+	}
+
+	suppressed, err := suppressions.IsSuppressed(testHelpers.ExampleGuest["email"])
+	if suppressed || err != nil {
+		t.Fatalf("IsSuppressed result %t/%v, want false/nil", suppressed, err)
+	}
+}
+
+func TestPermanentBounce(t *testing.T) {
+	sink := &email.MemoryMailer{From: email.DefaultSender}
+	email.SetMailer(sink)
+	t.Cleanup(email.Reset)
+
+	if err := processMessage(context.TODO(), fixture(t, "ses-bounce-sns.json")); err != nil {
+		t.Fatalf("processMessage error: %v", err)
+	}
+
+	suppressed, err := suppressions.IsSuppressed(testHelpers.ExampleGuest["email"])
+	if !suppressed || err != nil {
+		t.Fatalf("IsSuppressed result %t/%v, want true/nil", suppressed, err)
+	}
+
+	emails, err := outbox.RetrieveEmails(testHelpers.ExampleAdmin["email"])
+	if err != nil || len(emails) != 1 || emails[0].Template != email.TemplateUndeliverable || emails[0].Status != outbox.StatusSent {
+		t.Fatalf("RetrieveEmails result %+v/%v, want one sent undeliverable email/nil", emails, err)
+	}
+
+	// A repeated notification for the same address changes nothing.
+	if err = processMessage(context.TODO(), fixture(t, "ses-complaint.json")); err != nil {
+		t.Fatalf("processMessage error: %v", err)
+	}
+
+	emails, err = outbox.RetrieveEmails(testHelpers.ExampleAdmin["email"])
+	if err != nil || len(emails) != 1 {
+		t.Fatalf("RetrieveEmails result %+v/%v, want one email/nil", emails, err)
+	}
+
+	if err = suppressions.Check(testHelpers.ExampleGuest["email"]); err != suppressions.ErrSuppressed {
+		t.Fatalf("Check error %v, want ErrSuppressed", err)
+	}
+}
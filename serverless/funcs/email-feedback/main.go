@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/suppressions"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
+)
+
+// toSuppressions lists the addresses which a notification shows can no longer receive
+// email. Transient bounces, such as those caused by a full mailbox, suppress nothing.
+func toSuppressions(notification queue.SESNotification) []suppressions.Suppression {
+	list := []suppressions.Suppression{}
+
+	switch notification.Kind() {
+	case queue.SESBounce:
+		bounce := notification.Bounce
+
+		if bounce.BounceType != queue.SESPermanentBounce {
+			return list
+		}
+
+		for _, recipient := range bounce.BouncedRecipients {
+			detail := recipient.DiagnosticCode
+			if detail == "" {
+				detail = bounce.BounceSubType
+			}
+
+			list = append(list, suppressions.Suppression{
+				Email:          recipient.EmailAddress,
+				Reason:         suppressions.ReasonBounce,
+				Detail:         detail,
+				FeedbackId:     bounce.FeedbackId,
+				DateSuppressed: bounce.Timestamp,
+			})
+		}
+	case queue.SESComplaint:
+		complaint := notification.Complaint
+
+		for _, recipient := range complaint.ComplainedRecipients {
+			list = append(list, suppressions.Suppression{
+				Email:          recipient.EmailAddress,
+				Reason:         suppressions.ReasonComplaint,
+				Detail:         complaint.ComplaintFeedbackType,
+				FeedbackId:     complaint.FeedbackId,
+				DateSuppressed: complaint.Timestamp,
+			})
+		}
+	}
+
+	return list
+}
+
+// notifyInviter returns a notification which adds the email telling an inviter that
+// their guest can no longer be emailed to the outbox. The inviter's address is recorded
+// in notified so that the email can be sent once the suppression is saved.
+func notifyInviter(complaint bool, notified *[]string) suppressions.Notify {
+	return func(tx *sql.Tx, guest data.User, inviter data.User) error {
+		message, err := email.Render(email.TemplateUndeliverable, inviter.Locale, email.UndeliverableData{
+			Recipient: inviter,
+			Guest:     guest,
+			Complaint: complaint,
+			Url:       os.Getenv("EMAIL_REDIRECT_URL") + "?id=" + url.QueryEscape(guest.Email),
+		})
+
+		if err != nil {
+			logs.LogError(err, "Format Undeliverable Email Error")
+			return err
+		}
+
+		message.To = []string{inviter.Email}
+
+		if _, err = outbox.Enqueue(tx, email.TemplateUndeliverable, message); err != nil {
+			return err
+		}
+
+		*notified = append(*notified, inviter.Email)
+
+		return nil
+	}
+}
+
+// processMessage suppresses the addresses named by the SES notification in a single SQS
+// message. Addresses which were already suppressed are skipped.
+func processMessage(ctx context.Context, message events.SQSMessage) error {
+	notification, err := queue.DecodeSESNotification(message)
+
+	if err != nil {
+		logs.LogError(err, fmt.Sprintf("Unable to decode body of message %s", message.MessageId))
+		return err
+	}
+
+	notified := []string{}
+
+	for _, suppression := range toSuppressions(notification) {
+		event := audit.FromSystem(audit.EmailSuppress, suppression.Email, message.MessageId)
+		notify := notifyInviter(suppression.Reason == suppressions.ReasonComplaint, &notified)
+
+		suppressed, err := suppressions.Suppress(suppression, notify, event)
+
+		if err != nil {
+			logs.LogError(err, fmt.Sprintf("Unable to suppress address for message %s", message.MessageId))
+			return err
+		}
+
+		if suppressed {
+			logs.Info("Suppressed email address", "reason", suppression.Reason, "feedbackId", suppression.FeedbackId)
+		}
+	}
+
+	for _, inviter := range notified {
+		outbox.Flush(ctx, inviter)
+	}
+
+	return nil
+}
+
+// emailFeedbackHandler ingests the bounce and complaint notifications which SES publishes
+// for the emails sent by the gateway. Addresses which bounce permanently, or whose owners
+// mark an email as spam, are suppressed so that no further emails are sent to them, and
+// the admin who invited an affected guest is told that the guest cannot be reached.
+func emailFeedbackHandler(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	logs.Start(ctx, event)
+
+	return queue.Consume(ctx, "email-feedback", event, func(message events.SQSMessage) error {
+		return processMessage(ctx, message)
+	}), nil
+}
+
+func main() {
+	lambda.Start(emailFeedbackHandler)
+}
@@ -52,6 +52,17 @@ func TestApprove(t *testing.T) {
 	}
 }
 
+func TestRepeatApprove(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body: makeJsonBody(testHelpers.ExampleGuest2["email"], testHelpers.ExampleAdmin["email"]),
+	}
+
+	resp, err := guestAcceptHandler(context.TODO(), event)
+	if resp.StatusCode != 409 || err != nil {
+		t.Fatalf("guestAcceptHandler result %d/%v, want 409/nil", resp.StatusCode, err)
+	}
+}
+
 func TestUserMiss(t *testing.T) {
 	event := events.APIGatewayProxyRequest{
 		Body: makeJsonBody("fake@test.fail", testHelpers.ExampleAdmin["email"]),
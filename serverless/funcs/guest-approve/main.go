@@ -8,17 +8,20 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/email/provision"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
-	"github.com/IIP-Design/commons-gateway/utils/security/hashing"
 )
 
 // guestAcceptHandler accepts a request to invite an external partner.
 func guestAcceptHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	guest, err := data.ExtractAcceptInvite(event.Body)
 
 	if err != nil {
@@ -38,23 +41,17 @@ func guestAcceptHandler(ctx context.Context, event events.APIGatewayProxyRequest
 		return msgs.SendCustomError(errors.New("this user has not been invited"), 404)
 	}
 
-	// Regenerate credentials
-	pass, salt := hashing.GenerateCredentials()
-	hash := hashing.GenerateHash(pass, salt)
-
-	err = guests.AcceptGuest(guest, hash, salt)
+	// Regenerate credentials, queueing the email which provides them to the guest.
+	err = guests.AcceptGuest(guest, provision.Queue(invitee, provision.Create), audit.FromRequest(event, audit.GuestApprove, guest.Invitee))
 
-	if err != nil {
+	if errors.Is(err, audit.ErrNoChange) {
+		return msgs.SendCustomError(errors.New("this user has no invitation awaiting approval"), 409)
+	} else if err != nil {
 		logs.LogError(err, "Approve Invite Error")
 		return msgs.SendServerError(err)
 	}
 
-	_, err = provision.MailProvisionedCreds(invitee, pass, 0)
-
-	if err != nil {
-		logs.LogError(err, "Mail Credentials Error")
-		return msgs.SendServerError(err)
-	}
+	outbox.Flush(ctx, invitee.Email)
 
 	return msgs.SendSuccessMessage()
 }
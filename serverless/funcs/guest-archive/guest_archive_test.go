@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestArchiveGuest(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "No longer needed"}`, testHelpers.ExampleGuest["email"]),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := guestArchiveHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("guestArchiveHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	listed, err := checkGuestListed(testHelpers.ExampleGuest["email"])
+	if listed || err != nil {
+		t.Fatalf("checkGuestListed result %t/%v, want false/nil", listed, err)
+	}
+
+	resp, err = guestArchiveHandler(context.TODO(), event)
+	if resp.StatusCode != 409 || err != nil {
+		t.Fatalf("guestArchiveHandler repeat result %d/%v, want 409/nil", resp.StatusCode, err)
+	}
+}
+
+func checkGuestListed(email string) (bool, error) {
+	guestList, err := guests.RetrieveGuests("", "", false)
+
+	for _, guest := range guestList {
+		if guest.Email == email {
+			return true, err
+		}
+	}
+
+	return false, err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// guestArchiveHandler handles the request to archive (soft delete) an existing guest user.
+// Archived guests are hidden from the guest listings and are no longer able to log in.
+func guestArchiveHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Archive Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	admin, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	// Ensure that the user we intend to archive exists.
+	_, exists, err := users.CheckForExistingGuestUser(request.Id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s is not registered as a guest user", request.Id)
+
+		logs.LogError(err, "Guest User Not Found Error")
+		return msgs.SendCustomError(errors.New("user does not exist"), 404)
+	}
+
+	archived, err := guests.ArchiveGuest(request.Id, admin, request.Reason, audit.FromRequest(event, audit.GuestArchive, request.Id))
+
+	if err != nil {
+		logs.LogError(err, "Archive Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !archived {
+		return msgs.SendCustomError(errors.New("user has already been archived"), 409)
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+func main() {
+	lambda.Start(guestArchiveHandler)
+}
@@ -12,6 +12,7 @@ import (
 	testConfig "github.com/IIP-Design/commons-gateway/test/config"
 	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/logins"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -40,6 +41,7 @@ func TestMain(m *testing.M) {
 
 	cleanUpMfa()
 	testHelpers.CleanupInvites(testHelpers.ExampleGuest2["email"])
+	cleanUpLoginHistory(testHelpers.ExampleGuest2["email"])
 	testHelpers.TearDownTestDb()
 
 	os.Exit(exitVal)
@@ -70,6 +72,8 @@ func TestBadPassword(t *testing.T) {
 	if attempts2 <= attempts1 {
 		t.Fatal("Did not record failed login attempt")
 	}
+
+	checkLastAttempt(t, testHelpers.ExampleGuest["email"], false, logins.ReasonBadHash)
 }
 
 func TestBad2fa(t *testing.T) {
@@ -99,6 +103,24 @@ func TestBad2fa(t *testing.T) {
 	}
 }
 
+func TestUnknownGuest(t *testing.T) {
+	addMfa()
+
+	event := events.APIGatewayProxyRequest{
+		Body: makeJsonBody(testHelpers.ExampleCreds["pass_hash"], "unknown@test.fail", "fail"),
+	}
+
+	resp, err := authenticationHandler(context.TODO(), event)
+	if resp.StatusCode != 403 || err != nil {
+		t.Fatalf("authenticationHandler result %d/%v, want 403/nil", resp.StatusCode, err)
+	}
+
+	history, _, err := logins.RetrieveHistory("unknown@test.fail")
+	if len(history) != 0 || err != nil {
+		t.Fatalf("RetrieveHistory result %v/%v, want no attempts recorded for an unknown guest", history, err)
+	}
+}
+
 func TestPending(t *testing.T) {
 	addMfa()
 
@@ -143,6 +165,13 @@ func TestSuccess(t *testing.T) {
 	if attempts > 0 || err != nil {
 		t.Fatalf("checkLoginAttempts error or not reset: %d/%v", attempts, err)
 	}
+
+	checkLastAttempt(t, testHelpers.ExampleGuest["email"], true, "")
+
+	_, lastLogin, err := logins.RetrieveHistory(testHelpers.ExampleGuest["email"])
+	if lastLogin == nil || err != nil {
+		t.Fatalf("RetrieveHistory last login %v/%v, want a time/nil", lastLogin, err)
+	}
 }
 
 func TestLocked(t *testing.T) {
@@ -157,6 +186,8 @@ func TestLocked(t *testing.T) {
 	if resp.StatusCode != 429 || err != nil {
 		t.Fatalf("authenticationHandler result %d/%v, want 429/nil", resp.StatusCode, err)
 	}
+
+	checkLastAttempt(t, testHelpers.ExampleGuest["email"], false, logins.ReasonLocked)
 }
 
 func TestExpired(t *testing.T) {
@@ -199,6 +230,19 @@ func makeJsonBody(hash string, email string, code string) string {
 		hash, email, code, REQUEST_ID)
 }
 
+// checkLastAttempt confirms that the most recent entry in a guest's login history
+// has the expected outcome.
+func checkLastAttempt(t *testing.T, email string, success bool, reason string) {
+	history, _, err := logins.RetrieveHistory(email)
+	if err != nil || len(history) == 0 {
+		t.Fatalf("RetrieveHistory result %v/%v, want at least one attempt", history, err)
+	}
+
+	if history[0].Success != success || history[0].Reason != reason {
+		t.Fatalf("Last login attempt %+v, want success %t and reason %q", history[0], success, reason)
+	}
+}
+
 func checkLoginAttempts(email string) (int, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
@@ -222,6 +266,16 @@ func addMfa() error {
 	return err
 }
 
+func cleanUpLoginHistory(email string) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query := `DELETE FROM login_attempts WHERE email = $1`
+	_, err := pool.Exec(query, email)
+
+	return err
+}
+
 func cleanUpMfa() error {
 	pool := data.ConnectToDB()
 	defer pool.Close()
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"os"
 	"time"
@@ -12,10 +11,13 @@ import (
 
 	"github.com/IIP-Design/commons-gateway/utils/data/creds"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/logins"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
 	"github.com/IIP-Design/commons-gateway/utils/queue"
 	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 	"github.com/IIP-Design/commons-gateway/utils/turnstile"
 )
 
@@ -55,24 +57,10 @@ func clear2FA(id string) {
 
 // scheduleAccountUnlock initials and SQS message requesting user account unlock in 15 minutes.
 func scheduleAccountUnlock(email string) (string, error) {
-	var messageId string
-	var err error
-
-	event := data.GuestUnlockInitEvent{
-		Username: email,
-	}
-
-	json, err := json.Marshal(event)
-
-	if err != nil {
-		logs.LogError(err, "Failed to Marshal SQS Body")
-		return messageId, err
-	}
-
 	queueUrl := os.Getenv("UNLOCK_GUEST_ACCOUNT_QUEUE")
 
 	// Send the message to SQS.
-	return queue.SendToQueue(string(json), queueUrl)
+	return queue.Send(queue.GuestUnlockMessage{Username: email}, queueUrl)
 }
 
 // recordUnsuccessfulLoginAttempt counts the number of failed login attempts
@@ -101,13 +89,32 @@ func recordUnsuccessfulLoginAttempt(guest string) {
 			logs.LogError(err, "Update Lock Status Query Error")
 		}
 
+		metrics.Emit(metrics.Dimensions{}, metrics.Counter("Lockouts"))
 		scheduleAccountUnlock(guest)
 	}
 }
 
+// recordAttempt counts an authentication attempt by its outcome and saves it to the
+// guest's login history. Attempts made without a username, or naming someone who is
+// not a guest, cannot be attributed to a guest and are only counted.
+func recordAttempt(attempt logins.Attempt) {
+	outcome := attempt.Reason
+
+	if attempt.Success {
+		outcome = metrics.Success
+	}
+
+	metrics.Emit(metrics.Dimensions{Outcome: outcome}, metrics.Counter("LoginAttempts"))
+
+	if attempt.Email != "" {
+		logins.RecordAttempt(attempt)
+	}
+}
+
 // handleGrantAccess ensures that a user hash provided a password has matching their
-// username and if so, generates a JWT to grant them guest access.
-func handleGrantAccess(username string, clientHash string, mfaId string) (msgs.Response, error) {
+// username and if so, generates a JWT to grant them guest access. The outcome is
+// recorded in the guest's login history.
+func handleGrantAccess(username string, clientHash string, mfaId string, attempt logins.Attempt) (msgs.Response, error) {
 	if clientHash == "" || username == "" {
 		return msgs.SendCustomError(errors.New("data missing from request"), 400)
 	}
@@ -121,17 +128,25 @@ func handleGrantAccess(username string, clientHash string, mfaId string) (msgs.R
 	if credentials.Hash != clientHash {
 		logs.LogError(errors.New("incorrect password"), "Login Error")
 		recordUnsuccessfulLoginAttempt(username)
+		recordAttempt(attempt.Failed(logins.ReasonBadHash))
+		return msgs.SendCustomError(errors.New("forbidden"), 403)
+	} else if credentials.Archived {
+		logs.LogError(errors.New("archived account"), "Login Error")
+		recordAttempt(attempt.Failed(logins.ReasonArchived))
 		return msgs.SendCustomError(errors.New("forbidden"), 403)
 	} else if credentials.Expired {
 		recordUnsuccessfulLoginAttempt(username)
 		logs.LogError(errors.New("expired account"), "Login Error")
+		recordAttempt(attempt.Failed(logins.ReasonExpired))
 		return msgs.SendCustomError(errors.New("credentials expired"), 403)
 	} else if !credentials.Approved {
 		recordUnsuccessfulLoginAttempt(username)
 		logs.LogError(errors.New("guest not approved"), "Login Error")
+		recordAttempt(attempt.Failed(logins.ReasonUnapproved))
 		return msgs.SendCustomError(errors.New("user is not yet approved"), 403)
 	} else if credentials.Locked {
 		logs.LogError(errors.New("account locked"), "Login Error")
+		recordAttempt(attempt.Failed(logins.ReasonLocked))
 		return msgs.SendCustomError(errors.New("account locked"), 429)
 	}
 
@@ -151,6 +166,7 @@ func handleGrantAccess(username string, clientHash string, mfaId string) (msgs.R
 	clear2FA(mfaId)
 
 	creds.ClearUnsuccessfulLoginAttempts(username)
+	recordAttempt(attempt.Succeeded())
 
 	return msgs.PrepareResponse(body)
 }
@@ -158,6 +174,11 @@ func handleGrantAccess(username string, clientHash string, mfaId string) (msgs.R
 // authenticationHandler manages guest user authentication by either generating a JSON web
 // token for new authentication sessions or verifying an existing token for an ongoing session.
 func authenticationHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	end := tracing.StartRequest(ctx, "guest-auth", event)
+	defer end()
+
 	// Retrieve the auth data submitted by the user.
 	parsed, err := data.ParseBodyData(event.Body)
 
@@ -170,11 +191,18 @@ func authenticationHandler(ctx context.Context, event events.APIGatewayProxyRequ
 	mfaId := parsed.MFA.Id
 	mfaCode := parsed.MFA.Code
 
+	attempt := logins.Attempt{
+		Email:     username,
+		SourceIP:  event.RequestContext.Identity.SourceIP,
+		UserAgent: event.RequestContext.Identity.UserAgent,
+	}
+
 	// Verify that the provided 2FA code is valid.
 	verified := verify2FA(mfaId, mfaCode)
 
 	if !verified {
 		recordUnsuccessfulLoginAttempt(username)
+		recordAttempt(attempt.Failed(logins.ReasonBadMFA))
 		logs.LogError(errors.New("submitted 2fa codes does not match"), "Login Error")
 		return msgs.SendCustomError(errors.New("forbidden"), 403)
 	}
@@ -190,11 +218,12 @@ func authenticationHandler(ctx context.Context, event events.APIGatewayProxyRequ
 
 		if !valid || err != nil {
 			logs.LogError(err, "Turnstile error")
+			recordAttempt(attempt.Failed(logins.ReasonCaptcha))
 			return msgs.SendServerError(err)
 		}
 	}
 
-	return handleGrantAccess(username, clientHash, mfaId)
+	return handleGrantAccess(username, clientHash, mfaId, attempt)
 }
 
 func main() {
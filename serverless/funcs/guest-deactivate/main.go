@@ -2,11 +2,12 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
-	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
@@ -16,28 +17,31 @@ import (
 )
 
 // deactivateGuest opens a database connection and sets the given guest's
-// access expiration date to the current time.
-func deactivateGuest(email string) error {
-	pool := data.ConnectToDB()
-	defer pool.Close()
+// access expiration date to the current time, recording the provided audit event.
+func deactivateGuest(email string, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "recent_invites", Column: "invitee", Key: email}}
 
-	currentTime := time.Now()
-	deactivatedTime := currentTime.Add(time.Duration(-1) * time.Minute)
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+		deactivatedTime := currentTime.Add(time.Duration(-1) * time.Minute)
 
-	query := `UPDATE invites SET expiration = $1 WHERE invitee = $2`
-	_, err := pool.Exec(query, deactivatedTime, email)
+		query := `UPDATE invites SET expiration = $1 WHERE invitee = $2`
+		_, err := tx.Exec(query, deactivatedTime, email)
 
-	if err != nil {
-		logs.LogError(err, "Deactivate Guest Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Deactivate Guest Query Error")
+		}
 
-	return err
+		return err
+	})
 }
 
 // guestDeactivateHandler handles the request to edit an existing guest user.
 // It ensures that the required data is present before continuing on to
 // update the team data.
 func guestDeactivateHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	id := event.QueryStringParameters["id"]
 
 	if id == "" {
@@ -57,7 +61,7 @@ func guestDeactivateHandler(ctx context.Context, event events.APIGatewayProxyReq
 		return msgs.SendCustomError(errors.New("user does not exist"), 404)
 	}
 
-	err = deactivateGuest(id)
+	err = deactivateGuest(id, audit.FromRequest(event, audit.GuestDeactivate, id))
 
 	if err != nil {
 		logs.LogError(err, "Deactivate Guest User Error")
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// enqueue adds an email for the example guest to the outbox with the given template and status.
+func enqueue(t *testing.T, template string, status string) string {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+	if err != nil {
+		t.Fatalf("Begin error: %v", err)
+	}
+
+	id, err := outbox.Enqueue(tx, template, email.Message{
+		To:      []string{testHelpers.ExampleGuest["email"]},
+		Subject: "Content Commons Access Expiring",
+		Html:    "<p>Your access expires soon.</p>",
+	})
+	if err != nil {
+		t.Fatalf("Enqueue error: %v", err)
+	}
+
+	_, err = tx.Exec(`UPDATE email_outbox SET status = $1, attempts = $2 WHERE id = $3`, status, outbox.MaxAttempts, id)
+	if err != nil {
+		t.Fatalf("Update status error: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+
+	return id
+}
+
+func TestResendFailedEmail(t *testing.T) {
+	sink := &email.MemoryMailer{From: email.DefaultSender}
+	email.SetMailer(sink)
+	t.Cleanup(email.Reset)
+
+	id := enqueue(t, email.TemplateGuestExpiring, outbox.StatusFailed)
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s"}`, id),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "super admin"),
+	}
+
+	resp, err := resendEmailHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("resendEmailHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	var sent outbox.Email
+	json.Unmarshal([]byte(resp.Body), &sent)
+
+	if sent.Status != outbox.StatusSent || sent.Attempts != 1 || len(sink.Sent()) != 1 {
+		t.Fatalf("resendEmailHandler returned %+v, want an email sent on its first attempt", sent)
+	}
+}
+
+func TestResendUnfailedEmail(t *testing.T) {
+	id := enqueue(t, email.TemplateGuestExpiring, outbox.StatusSent)
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s"}`, id),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "super admin"),
+	}
+
+	resp, err := resendEmailHandler(context.TODO(), event)
+	if resp.StatusCode != 409 || err != nil {
+		t.Fatalf("resendEmailHandler result %d/%v, want 409/nil", resp.StatusCode, err)
+	}
+}
+
+func TestResendPurgedEmail(t *testing.T) {
+	sink := &email.MemoryMailer{From: email.DefaultSender}
+	email.SetMailer(sink)
+	t.Cleanup(email.Reset)
+
+	id := enqueue(t, email.TemplateCredentials, outbox.StatusFailed)
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	_, err := pool.Exec(`UPDATE email_outbox SET body_html = NULL, body_text = NULL WHERE id = $1`, id)
+	if err != nil {
+		t.Fatalf("Purge content error: %v", err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s"}`, id),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "super admin"),
+	}
+
+	resp, err := resendEmailHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("resendEmailHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	var sent outbox.Email
+	json.Unmarshal([]byte(resp.Body), &sent)
+
+	if sent.Id == id || sent.Template != email.TemplateCredentials || sent.Status != outbox.StatusSent || len(sink.Sent()) != 1 {
+		t.Fatalf("resendEmailHandler returned %+v, want new credentials sent in a new email", sent)
+	}
+}
+
+func TestResendUnknownEmail(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"id": "nonexistent"}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "super admin"),
+	}
+
+	resp, err := resendEmailHandler(context.TODO(), event)
+	if resp.StatusCode != 404 || err != nil {
+		t.Fatalf("resendEmailHandler result %d/%v, want 404/nil", resp.StatusCode, err)
+	}
+
+	event.Body = `{"id": ""}`
+
+	resp, err = resendEmailHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("resendEmailHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
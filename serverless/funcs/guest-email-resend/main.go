@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/creds"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/suppressions"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/email/provision"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+type ResendRequest struct {
+	Id string `json:"id"`
+}
+
+// resendEmailHandler handles the request to resend an email to a guest user which could
+// not be delivered. The email is returned to the outbox and an immediate attempt is made
+// to send it, after which any remaining retries are left to the dispatcher. The password
+// in a failed credentials email is deleted, so rather than resending it the guest is
+// issued new credentials in a new email. Emails to an address which has been suppressed
+// cannot be resent.
+func resendEmailHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	var request ResendRequest
+
+	err := json.Unmarshal([]byte(event.Body), &request)
+
+	if err != nil {
+		logs.LogError(err, "Extract Resend Request Error")
+		return msgs.SendCustomError(errors.New("unable to parse request body"), 400)
+	} else if request.Id == "" {
+		return msgs.SendCustomError(errors.New("email id not provided"), 400)
+	}
+
+	e, err := outbox.RetrieveEmail(request.Id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return msgs.SendCustomError(errors.New("email does not exist"), 404)
+	} else if err != nil {
+		logs.LogError(err, "Retrieve Email Error")
+		return msgs.SendServerError(err)
+	}
+
+	err = suppressions.Check(e.Recipient)
+
+	if errors.Is(err, suppressions.ErrSuppressed) {
+		return msgs.SendCustomError(err, 409)
+	} else if err != nil {
+		logs.LogError(err, "Check Email Suppression Error")
+		return msgs.SendServerError(err)
+	}
+
+	id := e.Id
+	err = outbox.Resend(id, audit.FromRequest(event, audit.GuestEmailResend, e.Recipient))
+
+	if errors.Is(err, outbox.ErrPurged) {
+		id, err = reissueCredentials(e.Recipient, event)
+	}
+
+	if errors.Is(err, outbox.ErrNotFailed) || errors.Is(err, outbox.ErrPurged) {
+		return msgs.SendCustomError(err, 409)
+	} else if err != nil {
+		logs.LogError(err, "Resend Email Error")
+		return msgs.SendServerError(err)
+	}
+
+	outbox.Flush(ctx, e.Recipient)
+
+	e, err = outbox.RetrieveEmail(id)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Email Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(e)
+
+	if err != nil {
+		logs.LogError(err, "Marshal Body Error")
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+// reissueCredentials resets the password of the guest to whom a purged credentials email
+// was addressed, and returns the id of the email which provides the new password. It
+// returns ErrPurged if the recipient is no longer a guest.
+func reissueCredentials(recipient string, event events.APIGatewayProxyRequest) (string, error) {
+	user, exists, err := users.CheckForExistingGuestUser(recipient)
+
+	if err != nil {
+		logs.LogError(err, "Check For Guest User Error")
+		return "", err
+	} else if !exists {
+		return "", outbox.ErrPurged
+	}
+
+	var id string
+
+	notify := func(tx *sql.Tx, pass string) (err error) {
+		id, err = provision.Enqueue(tx, user, pass, provision.Reset)
+
+		return err
+	}
+
+	_, err = creds.ResetPassword(recipient, notify, audit.FromRequest(event, audit.GuestPasswordReset, recipient))
+
+	if err != nil {
+		logs.LogError(err, "Reset Password Error")
+	}
+
+	return id, err
+}
+
+func main() {
+	lambda.Start(resendEmailHandler)
+}
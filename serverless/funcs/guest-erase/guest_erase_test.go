@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var erasedPseudonym string
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureAudit()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	cleanupErasure(erasedPseudonym)
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestEraseGuest(t *testing.T) {
+	row := invites.InviteJobRow{Row: 1, Email: testHelpers.ExampleGuest["email"], Status: invites.JobRowSent}
+
+	_, err := invites.CreateInviteJob(testHelpers.ExampleTeam["id"], testHelpers.ExampleAdmin["email"], []invites.InviteJobRow{row})
+	if err != nil {
+		t.Fatalf("CreateInviteJob error: %v", err)
+	}
+
+	// Record an earlier change to the guest, which must no longer be linked to them.
+	subjects := []audit.Subject{{Table: "guests", Column: "email", Key: testHelpers.ExampleGuest["email"]}}
+	err = audit.Transact(audit.FromSystem(audit.GuestUpdate, testHelpers.ExampleGuest["email"], ""), subjects, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE guests SET first_name = 'Renamed' WHERE email = $1`, testHelpers.ExampleGuest["email"])
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Record update error: %v", err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "Requested by partner"}`, testHelpers.ExampleGuest["email"]),
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := guestEraseHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("guestEraseHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	var body struct {
+		Data guests.ErasureReceipt `json:"data"`
+	}
+
+	err = json.Unmarshal([]byte(resp.Body), &body)
+	if err != nil || body.Data.Pseudonym == "" || body.Data.InvitesPseudonymized != 1 || body.Data.JobRowsPseudonymized != 1 || !body.Data.AuditKeyDestroyed {
+		t.Fatalf("guestEraseHandler receipt %+v/%v, want one pseudonymized invite and job row and a destroyed audit key", body.Data, err)
+	}
+
+	erasedPseudonym = body.Data.Pseudonym
+
+	remaining, err := countGuestRecords(testHelpers.ExampleGuest["email"])
+	if remaining != 0 || err != nil {
+		t.Fatalf("countGuestRecords result %d/%v, want 0/nil", remaining, err)
+	}
+
+	recorded, err := audit.RetrieveEvents(audit.Filter{Target: testHelpers.ExampleGuest["email"]})
+	if len(recorded) != 0 || err != nil {
+		t.Fatalf("RetrieveEvents result %d/%v, want no events linked to the erased guest", len(recorded), err)
+	}
+
+	remaining, err = countAuditMentions(testHelpers.ExampleGuest["email"])
+	if remaining != 0 || err != nil {
+		t.Fatalf("countAuditMentions result %d/%v, want 0/nil", remaining, err)
+	}
+}
+
+func TestMissErasure(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"id": "wrong@test.fail", "reason": "Requested by partner"}`,
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := guestEraseHandler(context.TODO(), event)
+	if resp.StatusCode != 404 || err != nil {
+		t.Fatalf("guestEraseHandler result %d/%v, want 404/nil", resp.StatusCode, err)
+	}
+}
+
+func countGuestRecords(email string) (int, error) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	var count int
+	query :=
+		`SELECT ( SELECT COUNT(*) FROM guests WHERE email = $1 ) +
+		 ( SELECT COUNT(*) FROM invites WHERE invitee = $1 ) +
+		 ( SELECT COUNT(*) FROM all_users WHERE guest_id = $1 ) +
+		 ( SELECT COUNT(*) FROM invite_job_rows WHERE email = $1 )`
+	err := pool.QueryRow(query, email).Scan(&count)
+
+	return count, err
+}
+
+// countAuditMentions counts the audit events which include an email address or the
+// renamed guest's name anywhere in their actor, target, or changes.
+func countAuditMentions(email string) (int, error) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	var count int
+	query :=
+		`SELECT COUNT(*) FROM audit_events
+		 WHERE LOWER( actor ) = LOWER( $1 ) OR LOWER( target ) = LOWER( $1 )
+		 OR changes::text ILIKE '%' || $1 || '%' OR changes::text LIKE '%Renamed%'`
+	err := pool.QueryRow(query, email).Scan(&count)
+
+	return count, err
+}
+
+// cleanupErasure removes the pseudonymized guest left behind by the erasure test,
+// since the standard teardown only knows about the original guest email.
+func cleanupErasure(pseudonym string) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	pool.Exec(`DELETE FROM erasures WHERE pseudonym = $1`, pseudonym)
+	pool.Exec(`DELETE FROM invites WHERE invitee = $1`, pseudonym)
+	pool.Exec(`DELETE FROM all_users WHERE guest_id = $1`, pseudonym)
+	pool.Exec(`DELETE FROM guests WHERE email = $1`, pseudonym)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// guestEraseHandler handles the request to permanently erase a guest user's personal data.
+// The guest's records are pseudonymized rather than deleted so that their uploads may be
+// retained for auditing. It responds with a receipt detailing the erasure.
+func guestEraseHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Erasure Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	superAdmin, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	// Ensure that the user we intend to erase exists.
+	_, exists, err := users.CheckForExistingGuestUser(request.Id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s is not registered as a guest user", request.Id)
+
+		logs.LogError(err, "Guest User Not Found Error")
+		return msgs.SendCustomError(errors.New("user does not exist"), 404)
+	}
+
+	// The target is left blank since the erasure is recorded against the guest's pseudonym.
+	receipt, err := guests.EraseGuest(request.Id, superAdmin, request.Reason, audit.FromRequest(event, audit.GuestErase, ""))
+
+	if err != nil {
+		logs.LogError(err, "Erase Guest User Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(receipt)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(guestEraseHandler)
+}
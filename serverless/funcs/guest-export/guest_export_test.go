@@ -0,0 +1,87 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestExportOtherGuest(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": testHelpers.ExampleGuest["email"], "format": "json"},
+		Headers:               testHelpers.AuthHeaders("other@test.test", "guest"),
+	}
+
+	resp, err := guestExportHandler(context.TODO(), event)
+	if resp.StatusCode != 403 || err != nil {
+		t.Fatalf("guestExportHandler result %d/%v, want 403/nil", resp.StatusCode, err)
+	}
+}
+
+func TestExportBadFormat(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": testHelpers.ExampleGuest["email"], "format": "pdf"},
+		Headers:               testHelpers.AuthHeaders(testHelpers.ExampleGuest["email"], "guest"),
+	}
+
+	resp, err := guestExportHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("guestExportHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestExportMissingGuest(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"id": "wrong@test.fail", "format": "json"},
+		Headers:               testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := guestExportHandler(context.TODO(), event)
+	if resp.StatusCode != 404 || err != nil {
+		t.Fatalf("guestExportHandler result %d/%v, want 404/nil", resp.StatusCode, err)
+	}
+}
+
+func TestRetrieveExport(t *testing.T) {
+	export, err := guests.RetrieveGuestExport(testHelpers.ExampleGuest["email"])
+	if err != nil || export.Profile.Email != testHelpers.ExampleGuest["email"] || export.Profile.Locale == "" || len(export.Invites) != 1 {
+		t.Fatalf("RetrieveGuestExport result %+v/%v, want guest profile and one invite", export, err)
+	}
+}
+
+func TestBundleAsZip(t *testing.T) {
+	bundle, err := bundleAsZip(guests.GuestExport{})
+	if err != nil {
+		t.Fatalf("bundleAsZip error %v, want nil", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil || len(archive.File) != 9 {
+		t.Fatalf("bundleAsZip produced %v/%v, want nine files", archive, err)
+	}
+}
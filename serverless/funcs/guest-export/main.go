@@ -0,0 +1,169 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rs/xid"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+	"github.com/IIP-Design/commons-gateway/utils/storage"
+)
+
+const (
+	LifetimeSecs = 900
+)
+
+// bundleAsZip packages each section of the export as a separate JSON file within a ZIP archive.
+func bundleAsZip(export guests.GuestExport) ([]byte, error) {
+	var buf bytes.Buffer
+
+	sections := []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", export.Profile},
+		{"login_state.json", export.LoginState},
+		{"login_history.json", export.LoginHistory},
+		{"invites.json", export.Invites},
+		{"password_changes.json", export.PasswordChanges},
+		{"uploads.json", export.Uploads},
+		{"emails.json", export.Emails},
+		{"suppression.json", export.Suppression},
+		{"reminders.json", export.Reminders},
+	}
+
+	archive := zip.NewWriter(&buf)
+
+	for _, section := range sections {
+		file, err := archive.Create(section.name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := json.MarshalIndent(section.content, "", "  ")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = file.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	err := archive.Close()
+
+	return buf.Bytes(), err
+}
+
+// guestExportHandler handles a subject access request, assembling all of the data
+// held about a guest user into a JSON or ZIP bundle. The bundle is saved to S3 and
+// a short-lived link to download it is returned. Super admins may export any guest,
+// whereas guests may only export their own data.
+func guestExportHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	id := event.QueryStringParameters["id"]
+	format := event.QueryStringParameters["format"]
+
+	if id == "" {
+		return msgs.SendCustomError(errors.New("user id not provided"), 400)
+	}
+
+	if format == "" {
+		format = "json"
+	} else if format != "json" && format != "zip" {
+		return msgs.SendCustomError(errors.New("format must be one of json or zip"), 400)
+	}
+
+	clientUser, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	clientRole, err := jwt.ExtractClientRole(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client Role Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	} else if clientRole != "super admin" && clientUser != id {
+		return msgs.SendCustomError(errors.New("users may only export their own data"), 403)
+	}
+
+	// Ensure that the user we intend to export exists.
+	_, exists, err := users.CheckForExistingGuestUser(id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s is not registered as a guest user", id)
+
+		logs.LogError(err, "Guest User Not Found Error")
+		return msgs.SendCustomError(errors.New("user does not exist"), 404)
+	}
+
+	export, err := guests.RetrieveGuestExport(id)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Guest Export Error")
+		return msgs.SendServerError(err)
+	}
+
+	var bundle []byte
+	var contentType string
+
+	if format == "zip" {
+		bundle, err = bundleAsZip(export)
+		contentType = "application/zip"
+	} else {
+		bundle, err = json.MarshalIndent(export, "", "  ")
+		contentType = "application/json"
+	}
+
+	if err != nil {
+		logs.LogError(err, "Bundle Guest Export Error")
+		return msgs.SendServerError(err)
+	}
+
+	// Use a random key so that export locations cannot be guessed from the user's email.
+	key := fmt.Sprintf("subject-access/%s.%s", xid.New().String(), format)
+	lifetime := time.Duration(LifetimeSecs * int64(time.Second))
+
+	url, err := storage.ShareExport(key, bundle, contentType, lifetime)
+
+	if err != nil {
+		logs.LogError(err, "Share Guest Export Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(map[string]any{
+		"downloadURL": url,
+		"expires":     time.Now().Add(lifetime),
+	})
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(guestExportHandler)
+}
@@ -9,13 +9,20 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/logins"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
 )
 
-// getGuestHandler handles the request to retrieve a single admin user based on email address.
+// getGuestHandler handles the request to retrieve a single guest user based on email address.
+// Admins are also shown the guest's recent login history and their last successful login,
+// as well as the delivery status of the recent emails sent to the guest.
 func getGuestHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	id := event.QueryStringParameters["id"]
 
 	if id == "" {
@@ -42,6 +49,25 @@ func getGuestHandler(ctx context.Context, event events.APIGatewayProxyRequest) (
 		return msgs.SendServerError(err)
 	}
 
+	// Only admins may see when and from where a guest has logged in.
+	role, _ := jwt.ExtractClientRole(event.Headers["Authorization"])
+
+	if role == "super admin" || role == "admin" {
+		guest.LoginHistory, guest.LastLogin, err = logins.RetrieveHistory(id)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Login History Error")
+			return msgs.SendServerError(err)
+		}
+
+		guest.Emails, err = outbox.RetrieveEmails(id)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Emails Error")
+			return msgs.SendServerError(err)
+		}
+	}
+
 	body, err := msgs.MarshalBody(guest)
 
 	if err != nil {
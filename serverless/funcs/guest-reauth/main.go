@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/email/propose"
 	"github.com/IIP-Design/commons-gateway/utils/email/provision"
@@ -19,6 +21,8 @@ import (
 )
 
 func guestReauthHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	// Need client role to determine reauthorization logic
 	scope, err := jwt.ExtractClientRole(event.Headers["Authorization"])
 
@@ -49,8 +53,15 @@ func guestReauthHandler(ctx context.Context, event events.APIGatewayProxyRequest
 		return msgs.SendCustomError(errors.New("this user has not been registered"), 404)
 	}
 
+	// Admins reauthorize the guest directly, so any new password is emailed to them.
+	var notify outbox.Notify
+
+	if !clientIsGuestAdmin {
+		notify = provision.Queue(user, provision.Reauth)
+	}
+
 	// Try to reauthorize
-	pass, status, err := guests.Reauthorize(guest, clientIsGuestAdmin)
+	pass, status, err := guests.Reauthorize(guest, clientIsGuestAdmin, notify, audit.FromRequest(event, audit.GuestReauthorize, guest.Email))
 
 	// May indicate a conflict (they have a pending request) or server error
 	if err != nil {
@@ -79,13 +90,8 @@ func guestReauthHandler(ctx context.Context, event events.APIGatewayProxyRequest
 			return msgs.SendServerError(err)
 		}
 	} else if pass != "" {
-		// For admins, only send an email if they need to re-up their password
-		_, err = provision.MailProvisionedCreds(user, pass, 1)
-
-		if err != nil {
-			logs.LogError(err, "Mail Provisioned Creds Error")
-			return msgs.SendServerError(err)
-		}
+		// For admins, an email is only queued if the guest needs to re-up their password
+		outbox.Flush(ctx, user.Email)
 	}
 
 	return msgs.SendSuccessMessage()
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/creds"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	testHelpers.TearDownTestDb()
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = testHelpers.AddPendingGuest()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.CleanupInvites(testHelpers.ExampleGuest2["email"])
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// countPending returns the number of proposed invitations awaiting approval on the example team.
+func countPending(t *testing.T) int {
+	invites, err := guests.RetrievePendingInvites(testHelpers.ExampleTeam["id"])
+	if err != nil {
+		t.Fatalf("RetrievePendingInvites error: %v", err)
+	}
+
+	return len(invites)
+}
+
+func TestReject(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "Contract ended"}`, testHelpers.ExampleGuest2["email"]),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := guestRejectHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("guestRejectHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	// The invite remains pending, so that the guest cannot log in, but is not awaiting approval.
+	pending, err := testHelpers.CheckGuestPending(testHelpers.ExampleGuest2["email"])
+	if !pending || err != nil {
+		t.Fatalf("CheckGuestPending result %t/%v, want true/nil", pending, err)
+	}
+
+	if n := countPending(t); n != 0 {
+		t.Fatalf("RetrievePendingInvites returned %d invites, want 0", n)
+	}
+
+	emails, err := outbox.RetrieveEmails(testHelpers.ExampleGuest["email"])
+	if err != nil || len(emails) == 0 || emails[0].Template != email.TemplateRejected {
+		t.Fatalf("RetrieveEmails result %+v/%v, want a rejected email for the proposer", emails, err)
+	}
+
+	guest, err := guests.RetrieveGuest(testHelpers.ExampleGuest2["email"])
+	if err != nil || len(guest.Invites) != 1 || guest.Invites[0].Pending || guest.Invites[0].RejectReason != "Contract ended" {
+		t.Fatalf("RetrieveGuest invites %+v/%v, want one rejected invite", guest.Invites, err)
+	}
+}
+
+func TestRepeatReject(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "Contract ended"}`, testHelpers.ExampleGuest2["email"]),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := guestRejectHandler(context.TODO(), event)
+	if resp.StatusCode != 409 || err != nil {
+		t.Fatalf("guestRejectHandler result %d/%v, want 409/nil", resp.StatusCode, err)
+	}
+}
+
+func TestRejectNoReason(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": ""}`, testHelpers.ExampleGuest2["email"]),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := guestRejectHandler(context.TODO(), event)
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("guestRejectHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestRejectMiss(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"id": "fake@test.fail", "reason": "Contract ended"}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := guestRejectHandler(context.TODO(), event)
+	if resp.StatusCode != 404 || err != nil {
+		t.Fatalf("guestRejectHandler result %d/%v, want 404/nil", resp.StatusCode, err)
+	}
+}
+
+func TestRepropose(t *testing.T) {
+	invite := data.Invite{
+		Invitee: data.User{
+			Email:     testHelpers.ExampleGuest2["email"],
+			NameFirst: testHelpers.ExampleGuest2["first_name"],
+			NameLast:  testHelpers.ExampleGuest2["last_name"],
+			Role:      testHelpers.ExampleGuest2["role"],
+			Team:      testHelpers.ExampleTeam["id"],
+		},
+		Proposer: testHelpers.ExampleGuest["email"],
+		Expires:  time.Now().Add(30 * 24 * time.Hour),
+	}
+
+	event := audit.FromSystem(audit.GuestPropose, invite.Invitee.Email, "")
+
+	if _, err := creds.SaveInitialInvite(invite, true, nil, event); err != nil {
+		t.Fatalf("SaveInitialInvite error: %v, want a rejected guest to be proposed again", err)
+	}
+
+	if n := countPending(t); n != 1 {
+		t.Fatalf("RetrievePendingInvites returned %d invites, want 1", n)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// notifyProposer returns a function which adds the email telling a guest admin that their
+// proposal was rejected, and why, to the outbox. The proposer's address is recorded in
+// notified so that the email can be sent once the rejection is saved.
+func notifyProposer(reason string, notified *string) guests.RejectNotify {
+	return func(tx *sql.Tx, guest data.User, proposer data.User) error {
+		message, err := email.Render(email.TemplateRejected, proposer.Locale, email.RejectedData{
+			Recipient: proposer,
+			Guest:     guest,
+			Reason:    reason,
+			Url:       os.Getenv("EMAIL_REDIRECT_URL"),
+		})
+
+		if err != nil {
+			logs.LogError(err, "Format Rejected Email Error")
+			return err
+		}
+
+		message.To = []string{proposer.Email}
+
+		if _, err = outbox.Enqueue(tx, email.TemplateRejected, message); err != nil {
+			return err
+		}
+
+		*notified = proposer.Email
+
+		return nil
+	}
+}
+
+// guestRejectHandler handles the request to decline a proposed invitation. The rejecting
+// admin, the reason, and the time are recorded on the invite, which is removed from the
+// pending invites, and the guest admin who proposed it is emailed. The guest may later be
+// proposed again.
+func guestRejectHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Reject Request Error")
+		return msgs.SendCustomError(err, 400)
+	} else if request.Reason == "" {
+		return msgs.SendCustomError(errors.New("a reason for the rejection is required"), 400)
+	}
+
+	admin, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	// Ensure that the user whose invite we intend to reject exists.
+	_, exists, err := users.CheckForExistingGuestUser(request.Id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s is not registered as a guest user", request.Id)
+
+		logs.LogError(err, "Guest User Not Found Error")
+		return msgs.SendCustomError(errors.New("this user has not been invited"), 404)
+	}
+
+	var notified string
+
+	rejected, err := guests.RejectGuest(
+		request.Id,
+		admin,
+		request.Reason,
+		notifyProposer(request.Reason, &notified),
+		audit.FromRequest(event, audit.GuestReject, request.Id),
+	)
+
+	if err != nil {
+		logs.LogError(err, "Reject Invite Error")
+		return msgs.SendServerError(err)
+	} else if !rejected {
+		return msgs.SendCustomError(errors.New("this user has no invitation awaiting approval"), 409)
+	}
+
+	if notified != "" {
+		outbox.Flush(ctx, notified)
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+func main() {
+	lambda.Start(guestRejectHandler)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestRestoreGuest(t *testing.T) {
+	archived, err := guests.ArchiveGuest(testHelpers.ExampleGuest["email"], testHelpers.ExampleAdmin["email"], "Testing", audit.Event{Actor: testHelpers.ExampleAdmin["email"], Action: audit.GuestArchive})
+	if !archived || err != nil {
+		t.Fatalf("archive result %t/%v, want true/nil", archived, err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s"}`, testHelpers.ExampleGuest["email"]),
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], "admin"),
+	}
+
+	resp, err := guestRestoreHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("guestRestoreHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	guest, err := guests.RetrieveGuest(testHelpers.ExampleGuest["email"])
+	if guest.Email != testHelpers.ExampleGuest["email"] || err != nil {
+		t.Fatalf("RetrieveGuest result %s/%v, want %s/nil", guest.Email, err, testHelpers.ExampleGuest["email"])
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// guestRestoreHandler handles the request to restore a previously archived guest user.
+func guestRestoreHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Archive Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	// Ensure that the user we intend to restore exists.
+	_, exists, err := users.CheckForExistingGuestUser(request.Id)
+
+	if err != nil {
+		logs.LogError(err, "Check For Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !exists {
+		err = fmt.Errorf("%s is not registered as a guest user", request.Id)
+
+		logs.LogError(err, "Guest User Not Found Error")
+		return msgs.SendCustomError(errors.New("user does not exist"), 404)
+	}
+
+	restored, err := guests.RestoreGuest(request.Id, audit.FromRequest(event, audit.GuestRestore, request.Id))
+
+	if err != nil {
+		logs.LogError(err, "Restore Guest User Error")
+		return msgs.SendServerError(err)
+	} else if !restored {
+		return msgs.SendCustomError(errors.New("user is not archived"), 409)
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+func main() {
+	lambda.Start(guestRestoreHandler)
+}
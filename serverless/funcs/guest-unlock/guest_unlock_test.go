@@ -9,6 +9,7 @@ import (
 	testConfig "github.com/IIP-Design/commons-gateway/test/config"
 	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -37,7 +38,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestUnlockMiss(t *testing.T) {
-	eventBody := fmt.Sprintf(`{"username":"%s"}`, "fake@test.fail")
+	eventBody := unlockBody(t, "fake@test.fail")
 	event := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
@@ -48,8 +49,8 @@ func TestUnlockMiss(t *testing.T) {
 	}
 
 	resp, err := unlockGuestHandler(context.TODO(), event)
-	if resp.StatusCode != 200 || err != nil {
-		t.Fatalf("unlockGuestHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	if len(resp.BatchItemFailures) != 0 || err != nil {
+		t.Fatalf("unlockGuestHandler result %v/%v, want no failures/nil", resp.BatchItemFailures, err)
 	}
 
 	g1Locked, err := checkGuestLocked(testHelpers.ExampleGuest["email"])
@@ -64,7 +65,7 @@ func TestUnlockMiss(t *testing.T) {
 }
 
 func TestUnlock(t *testing.T) {
-	eventBody := fmt.Sprintf(`{"username":"%s"}`, testHelpers.ExampleGuest["email"])
+	eventBody := unlockBody(t, testHelpers.ExampleGuest["email"])
 	event := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
@@ -75,8 +76,8 @@ func TestUnlock(t *testing.T) {
 	}
 
 	resp, err := unlockGuestHandler(context.TODO(), event)
-	if resp.StatusCode != 200 || err != nil {
-		t.Fatalf("unlockGuestHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	if len(resp.BatchItemFailures) != 0 || err != nil {
+		t.Fatalf("unlockGuestHandler result %v/%v, want no failures/nil", resp.BatchItemFailures, err)
 	}
 
 	g1Locked, err := checkGuestLocked(testHelpers.ExampleGuest["email"])
@@ -90,6 +91,35 @@ func TestUnlock(t *testing.T) {
 	}
 }
 
+func TestUnlockBadData(t *testing.T) {
+	event := events.SQSEvent{
+		Records: []events.SQSMessage{
+			{
+				MessageId: MESSAGE_ID,
+				Body:      `{username:}`,
+			},
+			{
+				MessageId: "12345",
+				Body:      unlockBody(t, "fake@test.fail"),
+			},
+		},
+	}
+
+	resp, err := unlockGuestHandler(context.TODO(), event)
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != MESSAGE_ID || err != nil {
+		t.Fatalf("unlockGuestHandler result %v/%v, want a failure for message %s/nil", resp.BatchItemFailures, err, MESSAGE_ID)
+	}
+}
+
+func unlockBody(t *testing.T, email string) string {
+	body, err := queue.Encode(queue.GuestUnlockMessage{Username: email})
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	return body
+}
+
 func checkGuestLocked(email string) (bool, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
@@ -2,63 +2,51 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
 	"fmt"
 
-	"github.com/IIP-Design/commons-gateway/utils/data/creds"
-	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
-	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-// unlockGuestAccount resets a given guest's account back to unlocked.
-func unlockGuestAccount(guest string) error {
-	pool := data.ConnectToDB()
-	defer pool.Close()
+// unlockGuestAccount resets a given guest's account back to unlocked and clears their
+// unsuccessful login attempts, recording the provided audit event.
+func unlockGuestAccount(guest string, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "guests", Column: "email", Key: guest}}
 
-	query := `UPDATE guests SET locked = false WHERE email = $1;`
-	_, err := pool.Exec(query, guest)
-
-	if err != nil {
-		logs.LogError(err, "Unlock Account Query Error")
-	}
-
-	return err
-}
-
-// unlockGuestHandler manages SQS messages to set a locked guest user's account back to unlocked status.
-func unlockGuestHandler(ctx context.Context, event events.SQSEvent) (msgs.Response, error) {
-	for _, message := range event.Records {
-		eventMessageId := message.MessageId
-		body := message.Body
-
-		var eventData data.GuestUnlockInitEvent
-
-		err := json.Unmarshal([]byte(body), &eventData)
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		query := `UPDATE guests SET locked = false, login_attempt = 0, login_date = NULL WHERE email = $1;`
+		_, err := tx.Exec(query, guest)
 
 		if err != nil {
-			logs.LogError(err, fmt.Sprintf("Unable to unmarshal body of message %s", eventMessageId))
-			return msgs.SendServerError(err)
+			logs.LogError(err, "Unlock Account Query Error")
 		}
 
-		err = unlockGuestAccount(eventData.Username)
-
-		if err != nil {
-			return msgs.SendServerError(err)
-		}
+		return err
+	})
+}
 
-		// Reset the login counter
-		err = creds.ClearUnsuccessfulLoginAttempts(eventData.Username)
+// processMessage unlocks the guest account named in a single SQS message.
+func processMessage(message events.SQSMessage) error {
+	eventData, err := queue.Decode[queue.GuestUnlockMessage](message)
 
-		if err != nil {
-			return msgs.SendServerError(err)
-		}
+	if err != nil {
+		logs.LogError(err, fmt.Sprintf("Unable to decode body of message %s", message.MessageId))
+		return err
 	}
 
-	return msgs.SendSuccessMessage()
+	return unlockGuestAccount(eventData.Username, audit.FromSystem(audit.GuestUnlock, eventData.Username, message.MessageId))
+}
+
+// unlockGuestHandler manages SQS messages to set a locked guest user's account back to unlocked status.
+func unlockGuestHandler(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	logs.Start(ctx, event)
+
+	return queue.Consume(ctx, "guest-unlock", event, processMessage), nil
 }
 
 func main() {
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
 	"github.com/IIP-Design/commons-gateway/utils/data/teams"
@@ -20,6 +21,8 @@ import (
 // It ensures that the required data is present before continuing on to
 // update the team data.
 func guestUpdateHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	guest, err := data.ExtractGuestUser(event.Body)
 
 	if err != nil {
@@ -50,7 +53,7 @@ func guestUpdateHandler(ctx context.Context, event events.APIGatewayProxyRequest
 		return msgs.SendCustomError(errors.New("no team with the provided id exists"), 404)
 	}
 
-	err = guests.UpdateGuest(guest)
+	err = guests.UpdateGuest(guest, audit.FromRequest(event, audit.GuestUpdate, guest.Email))
 
 	if err != nil {
 		logs.LogError(err, "Update Guest Error")
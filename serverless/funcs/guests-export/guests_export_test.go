@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+)
+
+func TestFormatRows(t *testing.T) {
+	list := []data.GuestUser{
+		{Expires: "2030-01-01T00:00:00Z", Pending: true, User: data.User{Email: "guest@test.test", Role: "guest"}},
+	}
+
+	rows := formatRows(list)
+	if len(rows) != 1 || rows[0][5] != "true" {
+		t.Fatalf("formatRows result %v, want one pending guest", rows)
+	}
+}
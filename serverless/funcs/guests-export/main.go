@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/spreadsheet"
+)
+
+// GuestFilters holds the options of a guest export in addition to its format and team.
+type GuestFilters struct {
+	NeverUsed bool   `json:"neverUsed"`
+	Role      string `json:"role"`
+}
+
+var header = []string{"Email", "Given Name", "Family Name", "Role", "Team", "Pending", "Expires", "Last Login"}
+
+// formatRows converts the list of guest users into spreadsheet rows.
+func formatRows(list []data.GuestUser) [][]string {
+	rows := make([][]string, len(list))
+
+	for i, guest := range list {
+		lastLogin := ""
+
+		if guest.LastLogin != nil {
+			lastLogin = guest.LastLogin.Format(time.RFC3339)
+		}
+
+		rows[i] = []string{
+			guest.Email,
+			guest.NameFirst,
+			guest.NameLast,
+			guest.Role,
+			guest.Team,
+			strconv.FormatBool(guest.Pending),
+			guest.Expires,
+			lastLogin,
+		}
+	}
+
+	return rows
+}
+
+// exportGuestsHandler handles the request to export the list of guest users as a CSV
+// or XLSX spreadsheet. It accepts the same team and role filters as the guest listing,
+// although only super admins may export guests outside of their own team.
+func exportGuestsHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	var filters GuestFilters
+
+	return spreadsheet.HandleExport(event, spreadsheet.Export{
+		Name:    "guests",
+		Header:  header,
+		Filters: &filters,
+		Rows: func(team string) ([][]string, error) {
+			list, err := guests.RetrieveGuests(team, filters.Role, filters.NeverUsed)
+
+			if err != nil {
+				logs.LogError(err, "Retrieve Guests Error")
+			}
+
+			return formatRows(list), err
+		},
+	})
+}
+
+func main() {
+	lambda.Start(exportGuestsHandler)
+}
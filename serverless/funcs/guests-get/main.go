@@ -5,6 +5,7 @@ import (
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -13,8 +14,11 @@ import (
 
 // getGuestsHandler handles the request to retrieve a list of guest users.
 // If a 'team' argument is provided in the body of the request it will filter
-// the response to show only the guests assigned to that team.
+// the response to show only the guests assigned to that team. Setting 'neverUsed'
+// limits the response to guests who have never successfully logged in.
 func getGuestsHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := data.ParseBodyData(event.Body)
 
 	team := parsed.TeamId
@@ -24,7 +28,7 @@ func getGuestsHandler(ctx context.Context, event events.APIGatewayProxyRequest)
 		return msgs.SendServerError(err)
 	}
 
-	guests, err := guests.RetrieveGuests(team, role)
+	guests, err := guests.RetrieveGuests(team, role, parsed.NeverUsed)
 
 	if err != nil {
 		return msgs.SendServerError(err)
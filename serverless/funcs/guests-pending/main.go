@@ -5,6 +5,7 @@ import (
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -15,6 +16,8 @@ import (
 // If a 'team' argument is provided in the body of the request it will filter
 // the response to show only the guests assigned to that team.
 func getPendingInvitesHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := data.ParseBodyData(event.Body)
 
 	team := parsed.TeamId
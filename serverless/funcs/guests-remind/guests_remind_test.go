@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/reminders"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// expireIn sets the example guest's access to expire after a given duration.
+func expireIn(t *testing.T, remaining time.Duration) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	_, err := pool.Exec(`UPDATE invites SET expiration = $1 WHERE invitee = $2`, time.Now().Add(remaining), testHelpers.ExampleGuest["email"])
+	if err != nil {
+		t.Fatalf("Update expiration error: %v", err)
+	}
+}
+
+// countEmails returns the number of emails queued for a recipient with a given template.
+func countEmails(t *testing.T, recipient string, template string) int {
+	emails, err := outbox.RetrieveEmails(recipient)
+	if err != nil {
+		t.Fatalf("RetrieveEmails error: %v", err)
+	}
+
+	count := 0
+	for _, e := range emails {
+		if e.Template == template {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestRemindGuests(t *testing.T) {
+	expireIn(t, 60*time.Hour)
+
+	summary, err := remindGuests([]int{14, 3, 1}, time.Now(), queueReminders)
+	if err != nil || summary.Sent != 1 {
+		t.Fatalf("remindGuests result %+v/%v, want one sent/nil", summary, err)
+	}
+
+	if n := countEmails(t, testHelpers.ExampleGuest["email"], email.TemplateExpiring); n != 1 {
+		t.Fatalf("Queued %d expiring emails for the guest, want 1", n)
+	}
+
+	if n := countEmails(t, testHelpers.ExampleAdmin["email"], email.TemplateGuestExpiring); n != 1 {
+		t.Fatalf("Queued %d guest expiring emails for the inviter, want 1", n)
+	}
+
+	// The reminder for a window is only sent once.
+	summary, err = remindGuests([]int{14, 3, 1}, time.Now(), queueReminders)
+	if err != nil || summary.Sent != 0 || summary.Due != 1 {
+		t.Fatalf("remindGuests result %+v/%v, want one due and none sent/nil", summary, err)
+	}
+
+	// The next window sends another reminder.
+	expireIn(t, 12*time.Hour)
+
+	summary, err = remindGuests([]int{14, 3, 1}, time.Now(), queueReminders)
+	if err != nil || summary.Sent != 1 {
+		t.Fatalf("remindGuests result %+v/%v, want one sent/nil", summary, err)
+	}
+}
+
+func TestRemindGuestsOutsideWindows(t *testing.T) {
+	expireIn(t, 30*24*time.Hour)
+
+	summary, err := remindGuests([]int{14, 3, 1}, time.Now(), queueReminders)
+	if err != nil || summary.Due != 0 {
+		t.Fatalf("remindGuests result %+v/%v, want none due/nil", summary, err)
+	}
+}
+
+func TestRemindGuestsFailure(t *testing.T) {
+	expireIn(t, 10*24*time.Hour)
+
+	failing := func(tx *sql.Tx, reminder reminders.Reminder, inviter data.User) error {
+		return errors.New("unable to queue reminder")
+	}
+
+	summary, err := remindGuests([]int{14, 3, 1}, time.Now(), failing)
+	if err != nil || summary.Due != 1 || summary.Sent != 0 || summary.Failed != 1 {
+		t.Fatalf("remindGuests result %+v/%v, want one due and failed/nil", summary, err)
+	}
+
+	// The failed reminder was not recorded, so it is sent by the next run.
+	summary, err = remindGuests([]int{14, 3, 1}, time.Now(), queueReminders)
+	if err != nil || summary.Sent != 1 {
+		t.Fatalf("remindGuests result %+v/%v, want one sent/nil", summary, err)
+	}
+}
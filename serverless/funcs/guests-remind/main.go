@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/data/reminders"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// Summary reports the number of guests found to be expiring, the number reminded, and
+// the number whose reminder could not be sent. Guests who were reminded by an earlier
+// run are counted as due but not as sent.
+type Summary struct {
+	Due    int `json:"due"`
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+}
+
+// queueReminders adds the reminder emails for a guest, and for the user who invited them
+// if they are known, to the outbox.
+func queueReminders(tx *sql.Tx, reminder reminders.Reminder, inviter data.User) error {
+	reauthUrl := os.Getenv("EMAIL_REDIRECT_URL") + "?id=" + url.QueryEscape(reminder.Guest.Email)
+
+	message, err := email.Render(email.TemplateExpiring, reminder.Guest.Locale, email.ExpiringData{
+		Recipient: reminder.Guest,
+		Inviter:   inviter,
+		Expires:   reminder.Expires,
+		Url:       reauthUrl,
+	})
+
+	if err != nil {
+		logs.LogError(err, "Format Expiring Email Error")
+		return err
+	}
+
+	message.To = []string{reminder.Guest.Email}
+
+	if _, err = outbox.Enqueue(tx, email.TemplateExpiring, message); err != nil {
+		return err
+	}
+
+	if inviter.Email == "" {
+		return nil
+	}
+
+	message, err = email.Render(email.TemplateGuestExpiring, inviter.Locale, email.GuestExpiringData{
+		Recipient: inviter,
+		Guest:     reminder.Guest,
+		Expires:   reminder.Expires,
+		Url:       reauthUrl,
+	})
+
+	if err != nil {
+		logs.LogError(err, "Format Guest Expiring Email Error")
+		return err
+	}
+
+	message.To = []string{inviter.Email}
+
+	_, err = outbox.Enqueue(tx, email.TemplateGuestExpiring, message)
+
+	return err
+}
+
+// remindGuests sends a reminder to each guest whose access expires within one of the
+// windows, queueing its emails with notify. A guest whose reminder cannot be sent is
+// counted as failed, without holding up the reminders of the remaining guests, and is
+// reminded by the next run.
+func remindGuests(windows []int, now time.Time, notify reminders.Notify) (Summary, error) {
+	var summary Summary
+
+	due, err := reminders.Due(windows, now)
+
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Due = len(due)
+
+	for _, reminder := range due {
+		sent, err := reminders.Send(reminder, notify)
+
+		if err != nil {
+			logs.LogError(err, "Send Reminder Error")
+			summary.Failed++
+
+			continue
+		}
+
+		if sent {
+			summary.Sent++
+		}
+	}
+
+	return summary, nil
+}
+
+// guestsRemindHandler reminds guests, and the users who invited them, that the guests'
+// access is about to expire. It runs daily, sending each guest one reminder as their
+// expiration enters each of the windows configured by `REMINDER_DAYS`. The emails are
+// delivered through the outbox.
+func guestsRemindHandler(ctx context.Context) (Summary, error) {
+	logs.Start(ctx, nil)
+
+	windows, err := reminders.ParseWindows(os.Getenv("REMINDER_DAYS"))
+
+	if err != nil {
+		logs.LogError(err, "Parse Reminder Windows Error")
+		return Summary{}, err
+	}
+
+	summary, err := remindGuests(windows, time.Now(), queueReminders)
+
+	logs.Info("Reminded expiring guests", "due", summary.Due, "sent", summary.Sent, "failed", summary.Failed)
+
+	return summary, err
+}
+
+func main() {
+	lambda.Start(guestsRemindHandler)
+}
@@ -10,8 +10,24 @@ import (
 func TestInitDb(t *testing.T) {
 	testConfig.ConfigureDb()
 
-	resp, err := initDBHandler(context.TODO())
+	resp, err := initDBHandler(context.TODO(), InitEvent{})
 	if resp.StatusCode != 200 || err != nil {
 		t.Fatalf("initDBHandler result %d/%v, want 200/nil", resp.StatusCode, err)
 	}
 }
+
+func TestMigrationStatus(t *testing.T) {
+	testConfig.ConfigureDb()
+
+	resp, err := initDBHandler(context.TODO(), InitEvent{Action: "status"})
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("initDBHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+}
+
+func TestUnknownAction(t *testing.T) {
+	resp, err := initDBHandler(context.TODO(), InitEvent{Action: "sideways"})
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("initDBHandler result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
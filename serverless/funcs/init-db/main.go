@@ -2,33 +2,96 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	initdb "github.com/IIP-Design/commons-gateway/utils/data/init"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
 
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-// initDBHandler handles the request to set up the database.
-func initDBHandler(ctx context.Context) (msgs.Response, error) {
-	migExists := initdb.CheckForTable("migrations")
+// InitEvent optionally selects the migration action to perform. When invoked
+// without an action, the database is set up and all pending migrations applied.
+type InitEvent struct {
+	Action string `json:"action"`
+}
+
+// checkDrift compares the database with the expected schema, responding with
+// an error describing any mismatch.
+func checkDrift() (msgs.Response, error) {
+	drift, err := initdb.CheckSchemaDrift()
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	} else if len(drift) > 0 {
+		return msgs.SendServerError(fmt.Errorf("schema drift detected: %s", strings.Join(drift, "; ")))
+	}
+
+	return msgs.SendSuccessMessage()
+}
+
+// initDBHandler handles the request to set up the database, after which the schema
+// is checked for drift. The `status` action reports on the state of each migration,
+// the `drift` action only checks the schema, and the `down` action reverts the most
+// recently applied migration.
+func initDBHandler(ctx context.Context, event InitEvent) (msgs.Response, error) {
+	logs.Start(ctx, event)
 
-	// If the migrations table does not exist run the initial setup
-	if !migExists {
-		err := initdb.InitializeDatabase()
+	switch event.Action {
+	case "status":
+		statuses, err := initdb.GetMigrationStatus()
 
 		if err != nil {
 			return msgs.SendServerError(err)
 		}
-	}
 
-	err := initdb.ApplyMigrations()
+		body, err := msgs.MarshalBody(statuses)
 
-	if err != nil {
-		return msgs.SendServerError(err)
-	}
+		if err != nil {
+			return msgs.SendServerError(err)
+		}
 
-	return msgs.SendSuccessMessage()
+		return msgs.PrepareResponse(body)
+	case "drift":
+		return checkDrift()
+	case "down":
+		title, err := initdb.RevertLastMigration()
+
+		if err != nil {
+			return msgs.SendServerError(err)
+		}
+
+		body, err := msgs.MarshalBody(map[string]string{"reverted": title})
+
+		if err != nil {
+			return msgs.SendServerError(err)
+		}
+
+		return msgs.PrepareResponse(body)
+	case "", "up":
+		migExists := initdb.CheckForTable("migrations")
+
+		// If the migrations table does not exist run the initial setup
+		if !migExists {
+			err := initdb.InitializeDatabase()
+
+			if err != nil {
+				return msgs.SendServerError(err)
+			}
+		}
+
+		err := initdb.ApplyMigrations()
+
+		if err != nil {
+			return msgs.SendServerError(err)
+		}
+
+		return checkDrift()
+	default:
+		return msgs.SendCustomError(fmt.Errorf("unknown action %s", event.Action), 400)
+	}
 }
 
 func main() {
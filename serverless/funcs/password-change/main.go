@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rs/xid"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/creds"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
@@ -121,45 +123,43 @@ func checkPasswordReused(email string, hashedPriorSalts []string) (bool, error)
 	return reused, err
 }
 
-// updatePassword stores a hash of the newly created password as needed in the database.
-func updatePassword(email string, salt string, newPasswordHash string, newSalt string) error {
-	var err error
-
-	pool := data.ConnectToDB()
-	defer pool.Close()
-
-	// Save new credentials to invites table.
-	query := "UPDATE invites SET pass_hash = $1, salt = $2, first_login = FALSE " +
-		" WHERE invitee = $3 AND salt = $4 " +
-		" AND pending = FALSE AND expiration > NOW() " +
-		" AND date_invited = ( SELECT max(date_invited) FROM invites WHERE invitee = $3 AND pending = FALSE )"
-	_, err = pool.Exec(query, newPasswordHash, newSalt, email, salt)
-
-	if err != nil {
-		return err
-	}
+// updatePassword stores a hash of the newly created password as needed in the database,
+// recording the provided audit event.
+func updatePassword(email string, salt string, newPasswordHash string, newSalt string, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "recent_invites", Column: "invitee", Key: email}}
+
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		// Save new credentials to invites table.
+		query := "UPDATE invites SET pass_hash = $1, salt = $2, first_login = FALSE " +
+			" WHERE invitee = $3 AND salt = $4 " +
+			" AND pending = FALSE AND expiration > NOW() " +
+			" AND date_invited = ( SELECT max(date_invited) FROM invites WHERE invitee = $3 AND pending = FALSE )"
+		_, err := tx.Exec(query, newPasswordHash, newSalt, email, salt)
+
+		if err != nil {
+			return err
+		}
 
-	// Save new credentials to password history table.
-	id := xid.New()
-	query = "INSERT INTO password_history ( id, user_id, creation_date, salt, pass_hash ) VALUES ( $1, $2, NOW(), $3, $4)"
-	_, err = pool.Exec(query, id, email, newSalt, newPasswordHash)
+		// Save new credentials to password history table.
+		id := xid.New()
+		query = "INSERT INTO password_history ( id, user_id, creation_date, salt, pass_hash ) VALUES ( $1, $2, NOW(), $3, $4)"
+		_, err = tx.Exec(query, id, email, newSalt, newPasswordHash)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	// Limits the number of history entries stored per user.
-	query = "DELETE FROM password_history WHERE user_id = $1 AND id NOT IN ( SELECT id FROM password_history WHERE user_id = $1 ORDER BY creation_date DESC LIMIT 24 )"
-	_, err = pool.Exec(query, email)
+		// Limits the number of history entries stored per user.
+		query = "DELETE FROM password_history WHERE user_id = $1 AND id NOT IN ( SELECT id FROM password_history WHERE user_id = $1 ORDER BY creation_date DESC LIMIT 24 )"
+		_, err = tx.Exec(query, email)
 
-	if err != nil {
 		return err
-	}
-
-	return err
+	})
 }
 
 func passwordChangeHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := extractBody(event.Body)
 
 	if err != nil {
@@ -180,7 +180,7 @@ func passwordChangeHandler(ctx context.Context, event events.APIGatewayProxyRequ
 		return msgs.SendCustomError(errors.New("password was reused"), 409)
 	}
 
-	err = updatePassword(parsed.Email, credentials.Salt, parsed.NewPasswordHash, parsed.NewSalt)
+	err = updatePassword(parsed.Email, credentials.Salt, parsed.NewPasswordHash, parsed.NewSalt, audit.FromRequest(event, audit.GuestPasswordChange, parsed.Email))
 
 	if err != nil {
 		return msgs.SendServerError(err)
@@ -8,7 +8,9 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/creds"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/email/provision"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
@@ -17,6 +19,8 @@ import (
 
 // passwordResetHandler handles the request to retrieve a single admin user based on email address.
 func passwordResetHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	id := event.QueryStringParameters["id"]
 
 	if id == "" {
@@ -36,19 +40,14 @@ func passwordResetHandler(ctx context.Context, event events.APIGatewayProxyReque
 		return msgs.SendCustomError(errors.New("user does not exist"), 404)
 	}
 
-	pass, err := creds.ResetPassword(id)
+	_, err = creds.ResetPassword(id, provision.Queue(user, provision.Reset), audit.FromRequest(event, audit.GuestPasswordReset, id))
 
 	if err != nil {
 		logs.LogError(err, "Reset Password Error")
 		return msgs.SendServerError(err)
 	}
 
-	_, err = provision.MailProvisionedCreds(user, pass, 2)
-
-	if err != nil {
-		logs.LogError(err, "Mail Credentials Error")
-		return msgs.SendServerError(err)
-	}
+	outbox.Flush(ctx, id)
 
 	return msgs.SendSuccessMessage()
 }
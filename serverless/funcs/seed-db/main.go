@@ -3,35 +3,65 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/csv"
-	"fmt"
-	"io"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
+	"github.com/IIP-Design/commons-gateway/utils/clients"
 	seed "github.com/IIP-Design/commons-gateway/utils/data/init"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 )
 
-// seedDatabaseHandler reads a newly uploaded file from S3
+// reportSuffix is appended to the key of a seed file to name its result report.
+const reportSuffix = ".report.json"
+
+// seedFile parses and loads a single seed file, returning a report of the outcome.
+func seedFile(key string, content []byte) seed.SeedReport {
+	records, err := seed.ParseSeedFile(key, content)
+
+	if err != nil {
+		logs.LogError(err, "Error Reading Seed File")
+		return seed.SeedReport{Source: key, Date: time.Now(), Error: err.Error()}
+	}
+
+	report, err := seed.SeedDatabase(key, records)
+
+	if err != nil {
+		logs.LogError(err, "Error Seeding DB")
+		report.Error = err.Error()
+	}
+
+	return report
+}
+
+// seedDatabaseHandler reads a newly uploaded seed file from S3, loads it into the
+// database, and writes a report of the outcome for each row alongside the seed file.
 func seedDatabaseHandler(ctx context.Context, event events.S3Event) error {
+	logs.Start(ctx, event)
+
 	var err error
 
-	sdkConfig, err := config.LoadDefaultConfig(ctx)
+	s3Client, err := clients.GetS3()
 
 	if err != nil {
-		logs.LogError(err, "Error Loading AWS Config")
 		return err
 	}
 
-	s3Client := s3.NewFromConfig(sdkConfig)
-
 	for _, record := range event.Records {
+		bucket := record.S3.Bucket.Name
+		key := record.S3.Object.URLDecodedKey
+
+		// Saving a report triggers this function, so reports themselves are ignored.
+		if strings.HasSuffix(key, reportSuffix) {
+			continue
+		}
+
 		var partMiBs int64 = 10
 
 		downloader := manager.NewDownloader(s3Client, func(d *manager.Downloader) {
@@ -40,9 +70,6 @@ func seedDatabaseHandler(ctx context.Context, event events.S3Event) error {
 
 		buffer := manager.NewWriteAtBuffer([]byte{})
 
-		bucket := record.S3.Bucket.Name
-		key := record.S3.Object.URLDecodedKey
-
 		_, err := downloader.Download(context.TODO(), buffer, &s3.GetObjectInput{
 			Bucket: aws.String(bucket),
 			Key:    aws.String(key),
@@ -53,30 +80,35 @@ func seedDatabaseHandler(ctx context.Context, event events.S3Event) error {
 			return err
 		}
 
-		file := bytes.NewReader(buffer.Bytes())
+		report := seedFile(key, buffer.Bytes())
+
+		body, err := json.MarshalIndent(report, "", "  ")
 
-		csvReader := csv.NewReader(file)
-		for {
-			rec, err := csvReader.Read()
+		if err != nil {
+			logs.LogError(err, "Error Marshalling Seed Report")
+			return err
+		}
 
-			if err == io.EOF {
-				break
-			}
+		_, err = s3Client.PutObject(context.TODO(), &s3.PutObjectInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key + reportSuffix),
+			Body:        bytes.NewReader(body),
+			ContentType: aws.String("application/json"),
+		})
 
-			if err != nil {
-				logs.LogError(err, "Error Reading CSV")
-				return err
-			}
+		if err != nil {
+			logs.LogError(err, "Error Saving Seed Report")
+			return err
+		}
 
-			err = seed.SeedDbRecord(rec)
-			if err != nil {
-				logs.LogError(err, "Error Seeding DB")
-				return err
-			}
+		// The outcome is recorded in the report, so a rejected seed file is not retried.
+		if report.Applied {
+			logs.Info("Seeded the database", "key", key)
+		} else {
+			logs.Warn("Unable to seed the database", "key", key, "error", report.Error)
 		}
 	}
 
-	fmt.Println("Successfully seeded the database")
 	return err
 }
 
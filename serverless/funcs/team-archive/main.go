@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/teams"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// teamArchiveHandler handles the request to archive (soft delete) an existing team.
+// Archived teams are hidden from the team listing.
+func teamArchiveHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Archive Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	superAdmin, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	exists, err := teams.CheckForExistingTeamById(request.Id)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	} else if !exists {
+		return msgs.SendCustomError(errors.New("no team with this id exists"), 404)
+	}
+
+	archived, err := teams.ArchiveTeam(request.Id, superAdmin, request.Reason, audit.FromRequest(event, audit.TeamArchive, request.Id))
+
+	if err != nil {
+		logs.LogError(err, "Archive Team Error")
+		return msgs.SendServerError(err)
+	} else if !archived {
+		return msgs.SendCustomError(errors.New("team has already been archived"), 409)
+	}
+
+	// Return the full list of teams in the response.
+	teams, err := teams.RetrieveTeams()
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(teams)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(teamArchiveHandler)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/teams"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestArchiveTeam(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s", "reason": "No longer needed"}`, testHelpers.ExampleTeam["id"]),
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := teamArchiveHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("teamArchiveHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	teamList, err := teams.RetrieveTeams()
+	if err != nil {
+		t.Fatalf("RetrieveTeams error %v, want nil", err)
+	}
+
+	for _, team := range teamList {
+		if team.Id == testHelpers.ExampleTeam["id"] {
+			t.Fatal("Archived team is still listed")
+		}
+	}
+}
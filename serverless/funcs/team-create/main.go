@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/teams"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
@@ -14,7 +15,7 @@ import (
 )
 
 // handleTeamCreation coordinates all the actions associated with creating a new team.
-func handleTeamCreation(teamName string, aprimoName string) (bool, error) {
+func handleTeamCreation(teamName string, aprimoName string, event audit.Event) (bool, error) {
 	var err error
 	var exists bool
 
@@ -24,7 +25,7 @@ func handleTeamCreation(teamName string, aprimoName string) (bool, error) {
 		return exists, err
 	}
 
-	err = teams.CreateTeam(teamName, aprimoName)
+	err = teams.CreateTeam(teamName, aprimoName, event)
 
 	return exists, err
 }
@@ -33,6 +34,8 @@ func handleTeamCreation(teamName string, aprimoName string) (bool, error) {
 // ensures that the required data is present before continuing on to recording
 // the team name and setting it to active.
 func newTeamHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := data.ParseBodyData(event.Body)
 
 	team := parsed.TeamName
@@ -46,7 +49,7 @@ func newTeamHandler(ctx context.Context, event events.APIGatewayProxyRequest) (m
 		return msgs.SendCustomError(err, 400)
 	}
 
-	exists, err := handleTeamCreation(team, aprimo_name)
+	exists, err := handleTeamCreation(team, aprimo_name, audit.FromRequest(event, audit.TeamCreate, ""))
 
 	if exists {
 		return msgs.SendCustomError(errors.New("a team with this name already exists"), 409)
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/teams"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+)
+
+// teamRestoreHandler handles the request to restore a previously archived team.
+func teamRestoreHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	request, err := data.ExtractArchiveRequest(event.Body)
+
+	if err != nil {
+		logs.LogError(err, "Extract Archive Request Error")
+		return msgs.SendCustomError(err, 400)
+	}
+
+	exists, err := teams.CheckForExistingTeamById(request.Id)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	} else if !exists {
+		return msgs.SendCustomError(errors.New("no team with this id exists"), 404)
+	}
+
+	restored, err := teams.RestoreTeam(request.Id, audit.FromRequest(event, audit.TeamRestore, request.Id))
+
+	if err != nil {
+		logs.LogError(err, "Restore Team Error")
+		return msgs.SendServerError(err)
+	} else if !restored {
+		return msgs.SendCustomError(errors.New("team is not archived"), 409)
+	}
+
+	// Return the full list of teams in the response.
+	teams, err := teams.RetrieveTeams()
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(teams)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
+
+func main() {
+	lambda.Start(teamRestoreHandler)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/teams"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+	testConfig.ConfigureEmail()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+func TestRestoreTeam(t *testing.T) {
+	archived, err := teams.ArchiveTeam(testHelpers.ExampleTeam["id"], "super@test.test", "Testing", audit.Event{Actor: "super@test.test", Action: audit.TeamArchive})
+	if !archived || err != nil {
+		t.Fatalf("archive result %t/%v, want true/nil", archived, err)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body:    fmt.Sprintf(`{"id": "%s"}`, testHelpers.ExampleTeam["id"]),
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := teamRestoreHandler(context.TODO(), event)
+	if resp.StatusCode != 200 || err != nil {
+		t.Fatalf("teamRestoreHandler result %d/%v, want 200/nil", resp.StatusCode, err)
+	}
+
+	result, err := testHelpers.DeserializeBodyArray(resp.Body)
+	if err != nil {
+		t.Fatalf("DeserializeBodyArray result %v, want nil", err)
+	}
+
+	for _, team := range result {
+		if team.(map[string]any)["id"] == testHelpers.ExampleTeam["id"] {
+			return
+		}
+	}
+
+	t.Fatal("Restored team is not listed")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/teams"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
@@ -17,6 +18,8 @@ import (
 // ensures that the required data is present before continuing on to
 // update the team data.
 func teamUpdateHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := data.ParseBodyData(event.Body)
 
 	active := parsed.Active
@@ -40,12 +43,14 @@ func teamUpdateHandler(ctx context.Context, event events.APIGatewayProxyRequest)
 		return msgs.SendCustomError(errors.New("no team with this id exists"), 404)
 	}
 
+	auditEvent := audit.FromRequest(event, audit.TeamUpdate, team)
+
 	if name != "" {
 		// If both active status and team name provided update full team info.
-		err = teams.UpdateTeam(team, name, aprimo_name, active)
+		err = teams.UpdateTeam(team, name, aprimo_name, active, auditEvent)
 	} else {
 		// If only status provided, update status.
-		err = teams.UpdateTeamStatus(team, active)
+		err = teams.UpdateTeamStatus(team, active, auditEvent)
 	}
 
 	if err != nil {
@@ -9,9 +9,12 @@ import (
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/metrics"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RequestBody struct {
@@ -20,6 +23,7 @@ type RequestBody struct {
 	TeamId      string `json:"team"`
 	FileType    string `json:"fileType"`
 	Description string `json:"description"`
+	TraceParent string `json:"traceparent"`
 }
 
 func parseRequest(body string) (RequestBody, error) {
@@ -35,8 +39,10 @@ func parseRequest(body string) (RequestBody, error) {
 	return parsed, err
 }
 
-// createUploadRecord opens a connection to the database and add a new upload record.
-func createUploadRecord(s3Id string, user string, teamId string, fileType string, description string) error {
+// createUploadRecord opens a connection to the database and add a new upload record. The
+// trace context is saved with the record so the trace can be continued once the file
+// has been scanned and is sent on to Aprimo.
+func createUploadRecord(s3Id string, user string, teamId string, fileType string, description string, traceParent string) error {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
@@ -50,8 +56,11 @@ func createUploadRecord(s3Id string, user string, teamId string, fileType string
 		return err
 	}
 
-	query := "INSERT INTO uploads ( s3_id, user_id, team_id, file_type, description, date_uploaded ) VALUES ( $1, $2, $3, $4, $5, $6 )"
-	_, err = pool.Exec(query, s3Id, userRecord.UserId, teamId, fileType, description, currentTime)
+	end := tracing.StartDBSpan("INSERT", "uploads")
+
+	query := "INSERT INTO uploads ( s3_id, user_id, team_id, file_type, description, date_uploaded, trace_parent ) VALUES ( $1, $2, $3, $4, $5, $6, NULLIF( $7, '' ) )"
+	_, err = pool.Exec(query, s3Id, userRecord.UserId, teamId, fileType, description, currentTime, traceParent)
+	end(err)
 
 	if err != nil {
 		logs.LogError(err, "Create Upload Record Query Error")
@@ -64,6 +73,11 @@ func createUploadRecord(s3Id string, user string, teamId string, fileType string
 // ensures that the required data is present before continuing on to recording
 // the team name and setting it to active.
 func newUploadHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	end := tracing.StartRequest(ctx, "upload-metadata", event)
+	defer end()
+
 	parsed, err := parseRequest(event.Body)
 
 	s3Id := parsed.S3Id
@@ -76,7 +90,12 @@ func newUploadHandler(ctx context.Context, event events.APIGatewayProxyRequest)
 		return msgs.SendServerError(err)
 	}
 
-	err = createUploadRecord(s3Id, user, teamId, fileType, description)
+	// Continue the trace begun when the upload URL was issued, if the client passed it on.
+	done := tracing.StartFrom(parsed.TraceParent, "upload.record", attribute.String("upload.key", s3Id))
+	err = createUploadRecord(s3Id, user, teamId, fileType, description, tracing.TraceParent())
+	done(err)
+
+	metrics.Emit(metrics.Dimensions{Team: teamId, Outcome: metrics.Outcome(err)}, metrics.Counter("Uploads"))
 
 	if err != nil {
 		return msgs.SendServerError(err)
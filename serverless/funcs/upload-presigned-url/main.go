@@ -4,19 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/url"
 	"os"
 	"time"
 
+	"github.com/IIP-Design/commons-gateway/utils/clients"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
 	"github.com/IIP-Design/commons-gateway/utils/security/sanitize"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
@@ -25,11 +25,16 @@ const (
 )
 
 func presignedUrlHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	end := tracing.StartRequest(ctx, "upload-presigned-url", event)
+	defer end()
+
 	rawContentType := event.QueryStringParameters["contentType"]
 	var contentType string
 
 	if rawContentType == "" {
-		fmt.Println("Unknown content type provided, assuming application/octet-stream")
+		logs.Debug("Unknown content type provided, assuming application/octet-stream")
 		contentType = "application/octet-stream"
 	} else {
 		ct, err := url.PathUnescape(rawContentType)
@@ -56,20 +61,15 @@ func presignedUrlHandler(ctx context.Context, event events.APIGatewayProxyReques
 	}
 
 	key := sanitize.TimestampObjectKey(sanitize.DefaultKeySanitizer(unsafeFilename))
-	// fmt.Printf("Key: %s\n", key)
+	logs.Debug("Presigning upload", "key", key)
 
-	var awsRegion = os.Getenv("AWS_REGION")
 	var s3Bucket = os.Getenv("S3_UPLOAD_BUCKET")
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(awsRegion),
-	)
+	svc, err := clients.GetS3()
 	if err != nil {
 		logs.LogError(err, "session creation error")
 		return msgs.SendServerError(err)
 	}
-
-	svc := s3.NewFromConfig(cfg)
 	presigner := s3.NewPresignClient(svc)
 
 	req, err := presigner.PresignPutObject(context.TODO(), &s3.PutObjectInput{
@@ -85,8 +85,9 @@ func presignedUrlHandler(ctx context.Context, event events.APIGatewayProxyReques
 	}
 
 	data := map[string]any{
-		"uploadURL": req.URL,
-		"key":       key,
+		"uploadURL":   req.URL,
+		"key":         key,
+		"traceparent": tracing.TraceParent(),
 	}
 
 	body, err := json.Marshal(data)
@@ -14,6 +14,8 @@ import (
 
 // getUploaderHandler handles the request to retrieve a list of uploader guests on the guest admin's team.
 func getUploaderHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
 	parsed, err := data.ParseBodyData(event.Body)
 
 	team := parsed.TeamId
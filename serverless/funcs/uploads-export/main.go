@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/uploads"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/spreadsheet"
+)
+
+var header = []string{
+	"File",
+	"Uploader",
+	"Team",
+	"File Type",
+	"Description",
+	"Date Uploaded",
+	"Date Scanned",
+	"Aprimo Record",
+	"Date Sent to Aprimo",
+}
+
+// formatTime renders an optional timestamp, leaving the cell empty if it is not set.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// formatRows converts the list of uploads into spreadsheet rows.
+func formatRows(list []uploads.Upload) [][]string {
+	rows := make([][]string, len(list))
+
+	for i, upload := range list {
+		rows[i] = []string{
+			upload.S3Id,
+			upload.Uploader,
+			upload.Team,
+			upload.FileType,
+			upload.Description,
+			upload.DateUploaded.Format(time.RFC3339),
+			formatTime(upload.DateCleaned),
+			upload.AprimoRecordId,
+			formatTime(upload.AprimoUploaded),
+		}
+	}
+
+	return rows
+}
+
+// exportUploadsHandler handles the request to export the list of uploaded files as a
+// CSV or XLSX spreadsheet, optionally filtered by team. Only super admins may export
+// uploads outside of their own team.
+func exportUploadsHandler(ctx context.Context, event events.APIGatewayProxyRequest) (msgs.Response, error) {
+	logs.Start(ctx, event)
+
+	return spreadsheet.HandleExport(event, spreadsheet.Export{
+		Name:   "uploads",
+		Header: header,
+		Rows: func(team string) ([][]string, error) {
+			list, err := uploads.RetrieveUploads(team)
+
+			if err != nil {
+				logs.LogError(err, "Retrieve Uploads Error")
+			}
+
+			return formatRows(list), err
+		},
+	})
+}
+
+func main() {
+	lambda.Start(exportUploadsHandler)
+}
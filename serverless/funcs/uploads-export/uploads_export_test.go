@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/uploads"
+)
+
+func TestFormatRows(t *testing.T) {
+	list := []uploads.Upload{
+		{S3Id: "file.png", Uploader: "guest@test.test", DateUploaded: time.Now()},
+	}
+
+	rows := formatRows(list)
+	if len(rows) != 1 || rows[0][6] != "" {
+		t.Fatalf("formatRows result %v, want one row with an empty scan date", rows)
+	}
+}
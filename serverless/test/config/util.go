@@ -24,12 +24,19 @@ func ConfigureAprimo() {
 	AddToEnv(AprimoEnv)
 }
 
+func ConfigureAudit() {
+	AddToEnv(AuditEnv)
+}
+
 func ConfigureAws() {
 	AddToEnv(AwsEnv)
 }
 
+// ConfigureDb also configures the outbox key, since most changes to the database
+// enqueue an email.
 func ConfigureDb() {
 	AddToEnv(DbEnv)
+	AddToEnv(OutboxEnv)
 }
 
 func ConfigureEmail() {
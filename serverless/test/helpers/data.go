@@ -97,6 +97,7 @@ func TearDownTestDb() error {
 	var err error
 
 	teamQuery := "DELETE FROM teams WHERE id = $1;"
+	inviteJobQuery := "DELETE FROM invite_jobs WHERE team = $1;"
 
 	adminTableQuery := "DELETE FROM admins WHERE email = $1;"
 	adminAllQuestsQuery := "DELETE FROM all_users WHERE admin_id = $1;"
@@ -105,6 +106,10 @@ func TearDownTestDb() error {
 	guestAllQuestsQuery := "DELETE FROM all_users WHERE guest_id = $1;"
 
 	inviteQuery := "DELETE FROM invites WHERE invitee = $1;"
+	loginAttemptsQuery := "DELETE FROM login_attempts WHERE email = $1;"
+	outboxQuery := "DELETE FROM email_outbox WHERE recipient = $1;"
+	suppressionQuery := "DELETE FROM email_suppressions WHERE email = $1;"
+	reminderQuery := "DELETE FROM expiration_reminders WHERE invitee = $1;"
 
 	pool := data.ConnectToDB()
 	defer pool.Close()
@@ -114,6 +119,26 @@ func TearDownTestDb() error {
 		return err
 	}
 
+	_, err = pool.Exec(loginAttemptsQuery, ExampleGuest["email"])
+	if err != nil {
+		return err
+	}
+
+	_, err = pool.Exec(outboxQuery, ExampleGuest["email"])
+	if err != nil {
+		return err
+	}
+
+	_, err = pool.Exec(suppressionQuery, ExampleGuest["email"])
+	if err != nil {
+		return err
+	}
+
+	_, err = pool.Exec(reminderQuery, ExampleGuest["email"])
+	if err != nil {
+		return err
+	}
+
 	_, err = pool.Exec(guestAllQuestsQuery, ExampleGuest["email"])
 	if err != nil {
 		return err
@@ -124,6 +149,11 @@ func TearDownTestDb() error {
 		return err
 	}
 
+	_, err = pool.Exec(outboxQuery, ExampleAdmin["email"])
+	if err != nil {
+		return err
+	}
+
 	_, err = pool.Exec(adminAllQuestsQuery, ExampleAdmin["email"])
 	if err != nil {
 		return err
@@ -134,6 +164,11 @@ func TearDownTestDb() error {
 		return err
 	}
 
+	_, err = pool.Exec(inviteJobQuery, ExampleTeam["id"])
+	if err != nil {
+		return err
+	}
+
 	_, err = pool.Exec(teamQuery, ExampleTeam["id"])
 	if err != nil {
 		return err
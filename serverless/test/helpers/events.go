@@ -0,0 +1,17 @@
+package testHelpers
+
+import (
+	"fmt"
+
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// AuthHeaders returns the headers of a request made by a user with the given role, for
+// handlers which identify the requesting user from their token.
+func AuthHeaders(user string, role string) map[string]string {
+	token, _ := jwt.GenerateJWT(user, role, false)
+
+	return map[string]string{
+		"Authorization": fmt.Sprintf(`Bearer %s`, token),
+	}
+}
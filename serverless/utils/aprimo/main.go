@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
@@ -14,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 )
 
 type TokenResponse struct {
@@ -62,6 +62,17 @@ func GetEndpointURL(endpoint string, dam bool) string {
 	return url
 }
 
+// send performs a request to Aprimo within a span of the current trace, passing the
+// trace context on in the request headers.
+func send(client *http.Client, request *http.Request) (*http.Response, error) {
+	end := tracing.StartHTTP(request)
+
+	resp, err := client.Do(request)
+	end(resp, err)
+
+	return resp, err
+}
+
 // GetAuthToken requests a bearer token from the Aprimo Marketing Operations API.
 // The resultant token can be used to authenticate to the Aprimo DAM API for record
 // creation and file uploading.
@@ -78,7 +89,17 @@ func GetAuthToken() (string, error) {
 	body.Set("client_secret", os.Getenv("APRIMO_CLIENT_SECRET"))
 	encodedBody := body.Encode()
 
-	resp, err := http.Post(endpoint, "application/x-www-form-urlencoded", strings.NewReader(encodedBody))
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(encodedBody))
+
+	if err != nil {
+		logs.LogError(err, "Error Preparing Aprimo Request")
+
+		return token, err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := send(http.DefaultClient, request)
 
 	if err != nil {
 		logs.LogError(err, "Retrieve Aprimo Auth Token Error")
@@ -138,7 +159,7 @@ func PostJsonDataWithFqUrl(url string, token string, reqBody string) ([]byte, in
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	request.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(request)
+	resp, err := send(client, request)
 
 	if err != nil {
 		logs.LogError(err, "Aprimo Response Error")
@@ -230,7 +251,7 @@ func UploadSegment(filename string, uri string, seg *FileSegment, token string)
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
 	// Make the request
-	resp, err := client.Do(request)
+	resp, err := send(client, request)
 
 	if err != nil {
 		logs.LogError(err, "Aprimo File Segment Upload Error")
@@ -279,7 +300,7 @@ func UploadFile(filename string, fileType string, data *bytes.Buffer, token stri
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
 	// Make the request
-	resp, err := client.Do(request)
+	resp, err := send(client, request)
 
 	if err != nil || resp.StatusCode >= 400 {
 		logs.LogError(err, "Aprimo File Upload Error")
@@ -349,7 +370,7 @@ func SubmitRecord(description string, event FileRecordInitEvent, team string, ac
 	if err != nil {
 		return id, err
 	} else if statusCode >= 400 {
-		log.Printf("Return status: %d\n", statusCode)
+		logs.Warn("Aprimo record creation failed", "status", statusCode)
 	}
 
 	var res RecordCreationResponse
@@ -382,7 +403,7 @@ func DeleteRecord(recordId string, token string) error {
 	request.Header.Set("API-VERSION", "1")
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-	resp, err := client.Do(request)
+	resp, err := send(client, request)
 
 	if err != nil {
 		logs.LogError(err, "Error Deleting Aprimo Record")
@@ -0,0 +1,191 @@
+// Package clients provides the AWS service clients used by the gateway. Each client is
+// built on first use and then reused for as long as the Lambda container lives, rather
+// than loading the AWS configuration again for every message or email.
+//
+// The endpoint of each service may be overridden to point at a local stand-in, such as
+// ElasticMQ for SQS, by setting AWS_ENDPOINT_URL for all services or AWS_ENDPOINT_URL_SQS,
+// AWS_ENDPOINT_URL_SESV2, or AWS_ENDPOINT_URL_S3 for a single service.
+package clients
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// SQS lists the SQS operations used by the gateway, so that a fake may stand in for the
+// client in tests.
+type SQS interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// SES lists the SES operations used by the gateway, so that a fake may stand in for the
+// client in tests.
+type SES interface {
+	SendEmail(ctx context.Context, params *ses.SendEmailInput, optFns ...func(*ses.Options)) (*ses.SendEmailOutput, error)
+}
+
+var (
+	mu        sync.Mutex
+	sqsClient SQS
+	sesClient SES
+	s3Client  *s3.Client
+)
+
+// loadConfig reads the AWS configuration from the environment, logging the requests made
+// by clients built from it when DEBUG is enabled. An empty region keeps the default. The
+// bodies of requests and responses are only logged if logBodies is set, since those sent
+// to SES and S3 hold email content, including temporary passwords, and exported data.
+func loadConfig(region string, logBodies bool) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{}
+
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	if os.Getenv("DEBUG") == "true" {
+		mode := aws.LogRetries | aws.LogRequestEventMessage
+
+		if logBodies {
+			mode |= aws.LogRequestWithBody | aws.LogResponseWithBody
+		} else {
+			mode |= aws.LogRequest | aws.LogResponse
+		}
+
+		opts = append(opts, config.WithClientLogMode(mode))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
+
+	if err != nil {
+		logs.LogError(err, "Error Loading AWS Config")
+	}
+
+	return cfg, err
+}
+
+// endpoint returns the override for a single service's endpoint, if one is set. Overrides
+// for all services are applied by the SDK itself.
+func endpoint(service string) *string {
+	if url := os.Getenv("AWS_ENDPOINT_URL_" + service); url != "" {
+		return aws.String(url)
+	}
+
+	return nil
+}
+
+// GetSQS returns the SQS client, building it on first use.
+func GetSQS() (SQS, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if sqsClient != nil {
+		return sqsClient, nil
+	}
+
+	cfg, err := loadConfig("", true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sqsClient = sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if url := endpoint("SQS"); url != nil {
+			o.BaseEndpoint = url
+		}
+	})
+
+	return sqsClient, nil
+}
+
+// GetSES returns the SES client for the region in AWS_SES_REGION, building it on first use.
+func GetSES() (SES, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if sesClient != nil {
+		return sesClient, nil
+	}
+
+	cfg, err := loadConfig(os.Getenv("AWS_SES_REGION"), false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sesClient = ses.NewFromConfig(cfg, func(o *ses.Options) {
+		if url := endpoint("SESV2"); url != nil {
+			o.BaseEndpoint = url
+		}
+	})
+
+	return sesClient, nil
+}
+
+// GetS3 returns the S3 client, building it on first use. Unlike the other clients, the
+// concrete client is returned, since it is needed to presign requests and to create the
+// transfer managers. Those accept interfaces, which fakes can implement instead.
+func GetS3() (*s3.Client, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if s3Client != nil {
+		return s3Client, nil
+	}
+
+	cfg, err := loadConfig("", false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s3Client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if url := endpoint("S3"); url != nil {
+			o.BaseEndpoint = url
+		}
+
+		// Local stand-ins for S3 rarely support virtual-hosted bucket addresses.
+		if o.BaseEndpoint != nil {
+			o.UsePathStyle = true
+		}
+	})
+
+	return s3Client, nil
+}
+
+// SetSQS replaces the SQS client, typically with a fake in tests.
+func SetSQS(client SQS) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sqsClient = client
+}
+
+// SetSES replaces the SES client, typically with a fake in tests.
+func SetSES(client SES) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sesClient = client
+}
+
+// Reset discards all the clients, so that they are built afresh on next use.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sqsClient = nil
+	sesClient = nil
+	s3Client = nil
+}
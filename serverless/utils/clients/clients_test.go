@@ -0,0 +1,101 @@
+package clients
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+type fakeSES struct{}
+
+func (fakeSES) SendEmail(ctx context.Context, params *ses.SendEmailInput, optFns ...func(*ses.Options)) (*ses.SendEmailOutput, error) {
+	return &ses.SendEmailOutput{}, nil
+}
+
+// useTestEnv provides static credentials so that clients can be built without an AWS account.
+func useTestEnv(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	Reset()
+	t.Cleanup(Reset)
+}
+
+func TestReuseClient(t *testing.T) {
+	useTestEnv(t)
+
+	first, err := GetSQS()
+	if err != nil {
+		t.Fatalf("GetSQS error: %v", err)
+	}
+
+	second, _ := GetSQS()
+	if first != second {
+		t.Fatal("GetSQS built a second client, want the first reused")
+	}
+
+	Reset()
+
+	if third, _ := GetSQS(); third == first {
+		t.Fatal("GetSQS reused the client after Reset, want a new client")
+	}
+}
+
+func TestEndpointOverride(t *testing.T) {
+	useTestEnv(t)
+	t.Setenv("AWS_ENDPOINT_URL_SQS", "http://localhost:9324")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "http://localhost:4566")
+
+	client, _ := GetSQS()
+	if endpoint := aws.ToString(client.(*sqs.Client).Options().BaseEndpoint); endpoint != "http://localhost:9324" {
+		t.Fatalf("SQS endpoint %s, want http://localhost:9324", endpoint)
+	}
+
+	s3Client, _ := GetS3()
+	options := s3Client.Options()
+
+	if aws.ToString(options.BaseEndpoint) != "http://localhost:4566" || !options.UsePathStyle {
+		t.Fatalf("S3 endpoint %s/%t, want http://localhost:4566/true", aws.ToString(options.BaseEndpoint), options.UsePathStyle)
+	}
+}
+
+func TestDefaultEndpoint(t *testing.T) {
+	useTestEnv(t)
+
+	s3Client, _ := GetS3()
+	options := s3Client.Options()
+
+	if options.BaseEndpoint != nil || options.UsePathStyle {
+		t.Fatalf("S3 endpoint %v/%t, want nil/false", options.BaseEndpoint, options.UsePathStyle)
+	}
+}
+
+func TestSetClient(t *testing.T) {
+	useTestEnv(t)
+
+	SetSES(fakeSES{})
+
+	client, err := GetSES()
+	if _, ok := client.(fakeSES); !ok || err != nil {
+		t.Fatalf("GetSES result %T/%v, want fakeSES/nil", client, err)
+	}
+}
+
+func TestDebugLogBodies(t *testing.T) {
+	useTestEnv(t)
+	t.Setenv("DEBUG", "true")
+
+	cfg, err := loadConfig("", false)
+	if err != nil || cfg.ClientLogMode.IsRequestWithBody() || cfg.ClientLogMode.IsResponseWithBody() || !cfg.ClientLogMode.IsRequest() {
+		t.Fatalf("loadConfig log mode %v/%v, want requests logged without bodies", cfg.ClientLogMode, err)
+	}
+
+	cfg, err = loadConfig("", true)
+	if err != nil || !cfg.ClientLogMode.IsRequestWithBody() {
+		t.Fatalf("loadConfig log mode %v/%v, want request bodies logged", cfg.ClientLogMode, err)
+	}
+}
@@ -1,8 +1,10 @@
 package admins
 
 import (
+	"database/sql"
 	"time"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
@@ -19,7 +21,9 @@ func CheckForActiveAdmin(adminEmail string) (data.User, bool, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	query := `SELECT email, first_name, last_name, role, team, active FROM admins WHERE email = $1;`
+	query :=
+		`SELECT email, first_name, last_name, role, team, active AND archived_at IS NULL AS active
+		 FROM admins WHERE email = $1;`
 	err = pool.QueryRow(query, adminEmail).Scan(
 		&inviter.Email, &inviter.NameFirst, &inviter.NameLast, &inviter.Role, &inviter.Team, &active)
 
@@ -39,7 +43,9 @@ func CheckForGuestAdmin(email string) (data.User, bool, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	query := `SELECT email, first_name, last_name, role, team, expiration > NOW() AS active FROM guest_auth_data WHERE email = $1 AND role='guest admin';`
+	query :=
+		`SELECT email, first_name, last_name, role, team, expiration > NOW() AND archived_at IS NULL AS active
+		 FROM guest_auth_data WHERE email = $1 AND role='guest admin';`
 	err = pool.QueryRow(query, email).Scan(
 		&proposer.Email, &proposer.NameFirst, &proposer.NameLast, &proposer.Role, &proposer.Team, &active)
 
@@ -51,35 +57,52 @@ func CheckForGuestAdmin(email string) (data.User, bool, error) {
 	return proposer, active, err
 }
 
-// CreateAdmin opens a database connection and saves a new administrative user record.
-func CreateAdmin(adminData data.User) error {
-	var err error
+// CreateAdmin opens a database connection and saves a new administrative user record,
+// recording the provided audit event.
+func CreateAdmin(adminData data.User, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: adminData.Email}}
 
-	pool := data.ConnectToDB()
-	defer pool.Close()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	currentTime := time.Now()
+		insertAdmin :=
+			`INSERT INTO admins( email, first_name, last_name, role, team, active, date_created, date_modified )
+			 VALUES ( $1, $2, $3, $4, $5, $6, $7, $8 );`
+		_, err := tx.Exec(insertAdmin, adminData.Email, adminData.NameFirst, adminData.NameLast, adminData.Role, adminData.Team, true, currentTime, currentTime)
 
-	insertAdmin :=
-		`INSERT INTO admins( email, first_name, last_name, role, team, active, date_created, date_modified )
-		 VALUES ( $1, $2, $3, $4, $5, $6, $7, $8 );`
-	_, err = pool.Exec(insertAdmin, adminData.Email, adminData.NameFirst, adminData.NameLast, adminData.Role, adminData.Team, true, currentTime, currentTime)
+		if err != nil {
+			logs.LogError(err, "Create Admin Query Error")
+			return err
+		}
 
-	if err != nil {
-		logs.LogError(err, "Create Admin Query Error")
-	}
+		// Add the admin to the list of all users
+		guid := xid.New()
 
-	// Add the admin to the list of all users
-	guid := xid.New()
+		insertAllUsers := `INSERT INTO all_users( user_id, admin_id ) VALUES ( $1, $2 );`
+		_, err = tx.Exec(insertAllUsers, guid, adminData.Email)
 
-	insertAllUsers := `INSERT INTO all_users( user_id, admin_id ) VALUES ( $1, $2 );`
-	_, err = pool.Exec(insertAllUsers, guid, adminData.Email)
+		if err != nil {
+			logs.LogError(err, "Add Admin to All Users Query Error")
+		}
 
-	if err != nil {
-		logs.LogError(err, "Add Admin to All Users Query Error")
-	}
+		return err
+	})
+}
+
+// ReinstateAdmin opens a database connection and restores a previously archived admin
+// user, updating their personal data with the values provided when they are re-invited
+// and recording the provided audit event.
+func ReinstateAdmin(adminData data.User, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: adminData.Email}}
 
-	return err
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		query :=
+			`UPDATE admins SET first_name = $1, last_name = $2, role = $3, team = $4, active = true,
+			 archived_at = NULL, archived_by = NULL, archive_reason = NULL, date_modified = $5
+			 WHERE email = $6 AND archived_at IS NOT NULL`
+
+		return audit.ExecChange(tx, "Reinstate Admin Query Error", query, adminData.NameFirst, adminData.NameLast, adminData.Role, adminData.Team, time.Now(), adminData.Email)
+	})
 }
 
 // RetrieveAdmin opens a database connection and retrieves the data for an individual admin user.
@@ -96,9 +119,10 @@ func RetrieveAdmin(username string) (map[string]any, error) {
 	var role string
 	var team string
 	var active string
+	var digest bool
 
-	query := `SELECT email, first_name, last_name, role, team, active FROM admins WHERE email = $1;`
-	err = pool.QueryRow(query, username).Scan(&email, &first_name, &last_name, &role, &team, &active)
+	query := `SELECT email, first_name, last_name, role, team, active, digest FROM admins WHERE email = $1 AND archived_at IS NULL;`
+	err = pool.QueryRow(query, username).Scan(&email, &first_name, &last_name, &role, &team, &active, &digest)
 
 	if err != nil {
 		logs.LogError(err, "Get Admin Query Error")
@@ -119,6 +143,7 @@ func RetrieveAdmin(username string) (map[string]any, error) {
 		"role":       role,
 		"team":       team,
 		"active":     active,
+		"digest":     digest,
 		"token":      jwt,
 	}
 
@@ -133,7 +158,10 @@ func RetrieveAdmins() ([]data.AdminUser, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	rows, err := pool.Query(`SELECT email, first_name, last_name, role, team, active FROM admins ORDER BY first_name`)
+	rows, err := pool.Query(
+		`SELECT email, first_name, last_name, role, team, active, digest FROM admins
+		 WHERE archived_at IS NULL ORDER BY first_name`,
+	)
 
 	if err != nil {
 		logs.LogError(err, "Get Admins Query Error")
@@ -144,7 +172,7 @@ func RetrieveAdmins() ([]data.AdminUser, error) {
 
 	for rows.Next() {
 		var admin data.AdminUser
-		if err := rows.Scan(&admin.Email, &admin.NameFirst, &admin.NameLast, &admin.Role, &admin.Team, &admin.Active); err != nil {
+		if err := rows.Scan(&admin.Email, &admin.NameFirst, &admin.NameLast, &admin.Role, &admin.Team, &admin.Active, &admin.Digest); err != nil {
 			logs.LogError(err, "Get Admins Query Error")
 			return admins, err
 		}
@@ -161,23 +189,78 @@ func RetrieveAdmins() ([]data.AdminUser, error) {
 }
 
 // UpdateAdmin opens a database connection and updates a given
-// admin user with the provided information.
+// admin user with the provided information, recording the provided audit event.
 // TODO? - Allow for changes to user email? If so we may need
 // to add an id field and set that as the primary key on an admin.
-func UpdateAdmin(admin data.AdminUser) error {
-	pool := data.ConnectToDB()
-	defer pool.Close()
+func UpdateAdmin(admin data.AdminUser, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: admin.Email}}
 
-	currentTime := time.Now()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	query :=
-		`UPDATE admins SET first_name = $1, last_name = $2, role = $3, team = $4,
-		 active = $5, date_modified = $6 WHERE email = $7`
-	_, err := pool.Exec(query, admin.NameFirst, admin.NameLast, admin.Role, admin.Team, admin.Active, currentTime, admin.Email)
+		query :=
+			`UPDATE admins SET first_name = $1, last_name = $2, role = $3, team = $4,
+			 active = $5, date_modified = $6 WHERE email = $7`
+		_, err := tx.Exec(query, admin.NameFirst, admin.NameLast, admin.Role, admin.Team, admin.Active, currentTime, admin.Email)
 
-	if err != nil {
-		logs.LogError(err, "Update Admin Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Update Admin Query Error")
+		}
+
+		return err
+	})
+}
+
+// SetDigest opens a database connection and sets whether an admin receives the daily
+// digest of their team's activity, recording the provided audit event. It returns false
+// if the preference was unchanged.
+func SetDigest(email string, digest bool, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		query :=
+			`UPDATE admins SET digest = $1, date_modified = $2
+			 WHERE email = $3 AND archived_at IS NULL AND digest <> $1`
+
+		return audit.ExecChange(tx, "Set Admin Digest Query Error", query, digest, time.Now(), email)
+	})
+
+	return audit.Changed(err)
+}
+
+// ArchiveAdmin opens a database connection and soft deletes the given admin user,
+// recording who archived them and why along with the provided audit event. It returns
+// false if the admin had already been archived.
+func ArchiveAdmin(email string, archivedBy string, reason string, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`UPDATE admins SET archived_at = $1, archived_by = $2, archive_reason = $3, date_modified = $1
+			 WHERE email = $4 AND archived_at IS NULL`
+
+		return audit.ExecChange(tx, "Archive Admin Query Error", query, currentTime, archivedBy, reason, email)
+	})
+
+	return audit.Changed(err)
+}
+
+// RestoreAdmin opens a database connection and clears the archival data from the given
+// admin user, recording the provided audit event. It returns false if the admin was not archived.
+func RestoreAdmin(email string, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "admins", Column: "email", Key: email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`UPDATE admins SET archived_at = NULL, archived_by = NULL, archive_reason = NULL, date_modified = $1
+			 WHERE email = $2 AND archived_at IS NOT NULL`
+
+		return audit.ExecChange(tx, "Restore Admin Query Error", query, currentTime, email)
+	})
 
-	return err
+	return audit.Changed(err)
 }
@@ -0,0 +1,348 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+)
+
+// The actions recorded in the audit log.
+const (
+	AdminArchive        = "admin.archive"
+	AdminCreate         = "admin.create"
+	AdminDeactivate     = "admin.deactivate"
+	AdminDigest         = "admin.digest"
+	AdminRestore        = "admin.restore"
+	AdminUpdate         = "admin.update"
+	EmailSuppress       = "email.suppress"
+	GuestApprove        = "guest.approve"
+	GuestArchive        = "guest.archive"
+	GuestDeactivate     = "guest.deactivate"
+	GuestEmailResend    = "guest.email_resend"
+	GuestErase          = "guest.erase"
+	GuestInvite         = "guest.invite"
+	GuestPasswordChange = "guest.password_change"
+	GuestPasswordReset  = "guest.password_reset"
+	GuestPropose        = "guest.propose"
+	GuestReauthorize    = "guest.reauthorize"
+	GuestReject         = "guest.reject"
+	GuestRestore        = "guest.restore"
+	GuestUnlock         = "guest.unlock"
+	GuestUpdate         = "guest.update"
+	TeamArchive         = "team.archive"
+	TeamCreate          = "team.create"
+	TeamRestore         = "team.restore"
+	TeamUpdate          = "team.update"
+	UploadAbandon       = "upload.abandon"
+	UploadRedrive       = "upload.redrive"
+)
+
+// ErrNoChange may be returned by a change function to indicate that there was nothing to
+// change. The transaction is rolled back without recording an event.
+var ErrNoChange = errors.New("no change made")
+
+// SystemActor is recorded as the actor of actions which are not initiated by a user.
+const SystemActor = "system"
+
+// Columns whose values must never be written to the audit log. Changes to these
+// columns are recorded, but their values are redacted.
+var redactedColumns = map[string]bool{
+	"body_html": true,
+	"body_text": true,
+	"code":      true,
+	"pass_hash": true,
+	"salt":      true,
+}
+
+const redacted = "[redacted]"
+
+// Change records the value of a single column before and after an action.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Event describes a single administrative or security relevant action.
+type Event struct {
+	Actor     string
+	ActorRole string
+	Action    string
+	Target    string
+	Changes   map[string]Change
+	SourceIP  string
+	UserAgent string
+	RequestId string
+}
+
+// Subject identifies the database row affected by an action, whose state before and
+// after the action is compared to produce the recorded changes. The table and column
+// must be constants, never user input.
+type Subject struct {
+	Table  string
+	Column string
+	Key    string
+}
+
+// FromRequest prepares an event for the given action on the given target, attributing
+// it to the user identified by the request's authorization token.
+func FromRequest(request events.APIGatewayProxyRequest, action string, target string) Event {
+	event := Event{
+		Action:    action,
+		Target:    target,
+		SourceIP:  request.RequestContext.Identity.SourceIP,
+		UserAgent: request.RequestContext.Identity.UserAgent,
+		RequestId: request.RequestContext.RequestID,
+	}
+
+	token := request.Headers["Authorization"]
+
+	// The user and role are recorded as unknown rather than failing the action, since
+	// the authorizer has already verified the token by the time a handler runs.
+	event.Actor, _ = jwt.ExtractClientUser(token)
+	event.ActorRole, _ = jwt.ExtractClientRole(token)
+
+	if event.Actor == "" {
+		event.Actor = "unknown"
+	}
+
+	return event
+}
+
+// FromSystem prepares an event for an action which is performed automatically rather
+// than at the request of a user.
+func FromSystem(action string, target string, requestId string) Event {
+	return Event{
+		Actor:     SystemActor,
+		Action:    action,
+		Target:    target,
+		RequestId: requestId,
+	}
+}
+
+// For returns a copy of the event directed at a different target. It is used when
+// a single request acts upon several targets.
+func (e Event) For(target string) Event {
+	e.Target = target
+	e.Changes = nil
+
+	return e
+}
+
+// snapshot captures the current state of a subject row as a map of column values.
+// It returns an empty map if the row does not exist.
+func snapshot(tx *sql.Tx, subject Subject) (map[string]any, error) {
+	state := map[string]any{}
+
+	var raw []byte
+
+	query := fmt.Sprintf(`SELECT to_jsonb(s) FROM %s s WHERE %s = $1 LIMIT 1;`, subject.Table, subject.Column)
+	err := tx.QueryRow(query, subject.Key).Scan(&raw)
+
+	if err == sql.ErrNoRows {
+		return state, nil
+	} else if err != nil {
+		logs.LogError(err, "Audit Snapshot Query Error")
+		return state, err
+	}
+
+	err = json.Unmarshal(raw, &state)
+
+	return state, err
+}
+
+// diff lists the columns whose values differ between two snapshots of the same table.
+func diff(table string, before map[string]any, after map[string]any, changes map[string]Change) {
+	columns := map[string]bool{}
+
+	for column := range before {
+		columns[column] = true
+	}
+
+	for column := range after {
+		columns[column] = true
+	}
+
+	for column := range columns {
+		prev, next := before[column], after[column]
+
+		if reflect.DeepEqual(prev, next) {
+			continue
+		}
+
+		if redactedColumns[column] {
+			prev, next = redacted, redacted
+		}
+
+		changes[table+"."+column] = Change{Before: prev, After: next}
+	}
+}
+
+// Record writes an event to the audit log as part of the provided transaction, so
+// that the event is only recorded if the action it describes is committed. Each event
+// is chained to the one before it by including the previous event's hash in its own.
+// The people the event identifies are recorded under their subject tokens.
+func Record(tx *sql.Tx, event Event) error {
+	var changes []byte
+
+	event, err := protect(tx, event)
+
+	if err != nil {
+		return err
+	}
+
+	if len(event.Changes) > 0 {
+		changes, err = json.Marshal(event.Changes)
+
+		if err != nil {
+			logs.LogError(err, "Audit Changes Marshal Error")
+			return err
+		}
+	}
+
+	prevHash, err := chainHead(tx)
+
+	if err != nil {
+		return err
+	}
+
+	entry := chainEntry{
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+		Actor:      event.Actor,
+		ActorRole:  event.ActorRole,
+		Action:     event.Action,
+		Target:     event.Target,
+		Changes:    changes,
+		SourceIP:   event.SourceIP,
+		UserAgent:  event.UserAgent,
+		RequestId:  event.RequestId,
+	}
+
+	hash, err := chainHash(prevHash, entry)
+
+	if err != nil {
+		logs.LogError(err, "Audit Chain Hash Error")
+		return err
+	}
+
+	query :=
+		`INSERT INTO audit_events( occurred_at, actor, actor_role, action, target, changes, source_ip, user_agent, request_id, prev_hash, hash )
+		 VALUES ( $1, $2, NULLIF( $3, '' ), $4, NULLIF( $5, '' ), $6, NULLIF( $7, '' ), NULLIF( $8, '' ), NULLIF( $9, '' ), $10, $11 );`
+	_, err = tx.Exec(
+		query,
+		entry.OccurredAt,
+		entry.Actor,
+		entry.ActorRole,
+		entry.Action,
+		entry.Target,
+		entry.Changes,
+		entry.SourceIP,
+		entry.UserAgent,
+		entry.RequestId,
+		prevHash,
+		hash,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Record Audit Event Query Error")
+	}
+
+	return err
+}
+
+// RecordChange performs the provided change within a transaction and records the event
+// in the audit log as part of that same transaction. The state of each subject is
+// captured before and after the change and any differences are recorded with the event.
+// Should the change return an error, the transaction is rolled back and no event is recorded.
+func RecordChange(tx *sql.Tx, event Event, subjects []Subject, change func(tx *sql.Tx) error) error {
+	before := make([]map[string]any, len(subjects))
+
+	for i, subject := range subjects {
+		state, err := snapshot(tx, subject)
+
+		if err != nil {
+			return err
+		}
+
+		before[i] = state
+	}
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	if event.Changes == nil {
+		event.Changes = map[string]Change{}
+	}
+
+	for i, subject := range subjects {
+		after, err := snapshot(tx, subject)
+
+		if err != nil {
+			return err
+		}
+
+		diff(subject.Table, before[i], after, event.Changes)
+	}
+
+	return Record(tx, event)
+}
+
+// ExecChange executes a query which is expected to modify a row, returning
+// ErrNoChange if no rows were affected.
+func ExecChange(tx *sql.Tx, errorTitle string, query string, args ...any) error {
+	result, err := tx.Exec(query, args...)
+
+	if err != nil {
+		logs.LogError(err, errorTitle)
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNoChange
+	}
+
+	return nil
+}
+
+// Changed converts the error returned by Transact into whether a change was made,
+// treating ErrNoChange as a successful outcome in which nothing was changed.
+func Changed(err error) (bool, error) {
+	if errors.Is(err, ErrNoChange) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Transact opens a database connection and performs the provided change within a
+// transaction, recording the event in the audit log before it is committed.
+func Transact(event Event, subjects []Subject, change func(tx *sql.Tx) error) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Audited Transaction Error")
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = RecordChange(tx, event, subjects, change); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
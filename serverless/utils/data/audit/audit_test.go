@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]any{"email": "guest@example.com", "first_name": "Ann", "salt": "abc", "locked": true}
+	after := map[string]any{"email": "guest@example.com", "first_name": "Anne", "salt": "def", "locked": true, "archived_at": "2023-12-26"}
+
+	changes := map[string]Change{}
+	diff("guests", before, after, changes)
+
+	expected := map[string]Change{
+		"guests.first_name":  {Before: "Ann", After: "Anne"},
+		"guests.salt":        {Before: redacted, After: redacted},
+		"guests.archived_at": {Before: nil, After: "2023-12-26"},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("diff result %v, want %v", changes, expected)
+	}
+}
+
+func TestForResetsChanges(t *testing.T) {
+	event := Event{Actor: "admin@example.com", Target: "a@example.com", Changes: map[string]Change{"x": {}}}
+	other := event.For("b@example.com")
+
+	if other.Target != "b@example.com" || other.Changes != nil || other.Actor != event.Actor {
+		t.Fatalf("For result %+v, want a copy with a new target and no changes", other)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if changed, err := Changed(nil); !changed || err != nil {
+		t.Fatalf("Changed(nil) result %t/%v, want true/nil", changed, err)
+	}
+
+	if changed, err := Changed(ErrNoChange); changed || err != nil {
+		t.Fatalf("Changed(ErrNoChange) result %t/%v, want false/nil", changed, err)
+	}
+
+	failure := errors.New("failure")
+
+	if changed, err := Changed(failure); changed || err != failure {
+		t.Fatalf("Changed(failure) result %t/%v, want false/failure", changed, err)
+	}
+}
+
+func TestBuildConditions(t *testing.T) {
+	from := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := buildConditions(Filter{Actor: "admin@example.com", From: from, Before: 42})
+
+	if where != "WHERE actor = $1 AND occurred_at >= $2 AND id < $3" {
+		t.Fatalf("buildConditions clause %q is incorrect", where)
+	} else if !reflect.DeepEqual(args, []any{"admin@example.com", from, int64(42)}) {
+		t.Fatalf("buildConditions arguments %v are incorrect", args)
+	}
+
+	where, args = buildConditions(Filter{})
+
+	if where != "" || len(args) != 0 {
+		t.Fatalf("buildConditions for an empty filter returned %q/%v, want no conditions", where, args)
+	}
+}
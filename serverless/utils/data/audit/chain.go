@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// GenesisHash is the previous hash recorded against the first event in the chain.
+var GenesisHash = strings.Repeat("0", 64)
+
+// chainLockKey identifies the advisory lock held while an event is appended to the
+// chain, so that concurrent transactions cannot link their events to the same predecessor.
+const chainLockKey = 7220231226
+
+// timestampLayout formats the time of an event to the microsecond precision with
+// which it is stored in the database.
+const timestampLayout = "2006-01-02T15:04:05.000000"
+
+// chainEntry holds the fields of an event which are covered by its hash.
+type chainEntry struct {
+	OccurredAt time.Time
+	Actor      string
+	ActorRole  string
+	Action     string
+	Target     string
+	Changes    []byte
+	SourceIP   string
+	UserAgent  string
+	RequestId  string
+}
+
+// canonicalJSON re-encodes a JSON document so that semantically equal documents produce
+// identical bytes, regardless of the key order or spacing with which they were stored.
+func canonicalJSON(raw []byte) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var value any
+
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// chainHash computes the SHA-256 hash linking an entry to the hash of the entry before it.
+func chainHash(prevHash string, entry chainEntry) (string, error) {
+	changes, err := canonicalJSON(entry.Changes)
+
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal([]any{
+		prevHash,
+		entry.OccurredAt.UTC().Format(timestampLayout),
+		entry.Actor,
+		entry.ActorRole,
+		entry.Action,
+		entry.Target,
+		changes,
+		entry.SourceIP,
+		entry.UserAgent,
+		entry.RequestId,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// chainHead locks the chain for the remainder of the transaction and returns the hash
+// of the most recent event, or the genesis hash if no events have been recorded.
+func chainHead(tx *sql.Tx) (string, error) {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock( $1 );`, chainLockKey); err != nil {
+		logs.LogError(err, "Audit Chain Lock Error")
+		return "", err
+	}
+
+	var head string
+
+	err := tx.QueryRow(`SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1;`).Scan(&head)
+
+	if err == sql.ErrNoRows {
+		return GenesisHash, nil
+	} else if err != nil {
+		logs.LogError(err, "Audit Chain Head Query Error")
+	}
+
+	return head, err
+}
+
+// ChainExisting links events recorded before the hash chain was introduced, in the
+// order in which they were recorded. It is used when migrating an existing audit log
+// and expects the caller to have suspended the table's append-only trigger.
+func ChainExisting(tx *sql.Tx) error {
+	query :=
+		`SELECT id, occurred_at, actor, COALESCE( actor_role, '' ), action, COALESCE( target, '' ),
+		 changes, COALESCE( source_ip, '' ), COALESCE( user_agent, '' ), COALESCE( request_id, '' )
+		 FROM audit_events ORDER BY id;`
+	rows, err := tx.Query(query)
+
+	if err != nil {
+		logs.LogError(err, "Chain Existing Events Query Error")
+		return err
+	}
+
+	ids := []int64{}
+	entries := []chainEntry{}
+
+	for rows.Next() {
+		var id int64
+		var entry chainEntry
+
+		err = rows.Scan(
+			&id,
+			&entry.OccurredAt,
+			&entry.Actor,
+			&entry.ActorRole,
+			&entry.Action,
+			&entry.Target,
+			&entry.Changes,
+			&entry.SourceIP,
+			&entry.UserAgent,
+			&entry.RequestId,
+		)
+
+		if err != nil {
+			rows.Close()
+			logs.LogError(err, "Chain Existing Events Scan Error")
+			return err
+		}
+
+		ids = append(ids, id)
+		entries = append(entries, entry)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	prev := GenesisHash
+
+	for i, entry := range entries {
+		hash, err := chainHash(prev, entry)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE audit_events SET prev_hash = $1, hash = $2 WHERE id = $3;`, prev, hash, ids[i])
+
+		if err != nil {
+			logs.LogError(err, "Chain Existing Events Query Error")
+			return err
+		}
+
+		prev = hash
+	}
+
+	return nil
+}
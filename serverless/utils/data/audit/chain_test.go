@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func exampleEntry(t *testing.T) chainEntry {
+	changes, err := json.Marshal(map[string]Change{
+		"guests.first_name": {Before: "Ann", After: "Anne"},
+		"guests.locked":     {Before: true, After: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chainEntry{
+		OccurredAt: time.Date(2024, 1, 2, 15, 4, 5, 123456000, time.UTC),
+		Actor:      "admin@example.com",
+		ActorRole:  "admin",
+		Action:     GuestUpdate,
+		Target:     "guest@example.com",
+		Changes:    changes,
+		RequestId:  "request",
+	}
+}
+
+func TestChainHashStoredChanges(t *testing.T) {
+	entry := exampleEntry(t)
+
+	written, err := chainHash(GenesisHash, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The database returns JSONB with its own key order and spacing.
+	entry.Changes = []byte(`{"guests.locked": {"after": false, "before": true}, "guests.first_name": {"after": "Anne", "before": "Ann"}}`)
+
+	read, err := chainHash(GenesisHash, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if written != read {
+		t.Fatalf("chainHash of stored changes %s, want %s", read, written)
+	}
+}
+
+func TestChainHashCoversFields(t *testing.T) {
+	entry := exampleEntry(t)
+
+	original, _ := chainHash(GenesisHash, entry)
+
+	altered := entry
+	altered.Target = "other@example.com"
+
+	if hash, _ := chainHash(GenesisHash, altered); hash == original {
+		t.Fatal("chainHash did not change when the target was altered")
+	}
+
+	altered = entry
+	altered.OccurredAt = entry.OccurredAt.Add(time.Microsecond)
+
+	if hash, _ := chainHash(GenesisHash, altered); hash == original {
+		t.Fatal("chainHash did not change when the time was altered")
+	}
+
+	if hash, _ := chainHash(original, entry); hash == original {
+		t.Fatal("chainHash did not change when the previous hash was altered")
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	entry := exampleEntry(t)
+	hash, _ := chainHash(GenesisHash, entry)
+
+	if reason, err := checkLink(GenesisHash, GenesisHash, hash, entry); reason != "" || err != nil {
+		t.Fatalf("checkLink of a valid link returned %q/%v", reason, err)
+	}
+
+	if reason, _ := checkLink(hash, GenesisHash, hash, entry); reason == "" {
+		t.Fatal("checkLink did not report a broken previous hash")
+	}
+
+	entry.Actor = "intruder@example.com"
+
+	if reason, _ := checkLink(GenesisHash, GenesisHash, hash, entry); reason == "" {
+		t.Fatal("checkLink did not report altered contents")
+	}
+}
+
+func TestCheckCheckpoint(t *testing.T) {
+	key := []byte("test-key")
+	checkpoint := Checkpoint{Day: "2024-01-02", LastEventId: 12, EventCount: 12, Digest: GenesisHash}
+	checkpoint.Signature = signCheckpoint(key, checkpoint)
+
+	if reason := checkCheckpoint(key, checkpoint, GenesisHash, 12); reason != "" {
+		t.Fatalf("checkCheckpoint of a valid checkpoint returned %q", reason)
+	}
+
+	if reason := checkCheckpoint([]byte("other-key"), checkpoint, GenesisHash, 12); reason == "" {
+		t.Fatal("checkCheckpoint accepted a signature made with a different key")
+	}
+
+	if reason := checkCheckpoint(key, checkpoint, "altered", 12); reason == "" {
+		t.Fatal("checkCheckpoint did not report a changed digest")
+	}
+
+	if reason := checkCheckpoint(key, checkpoint, GenesisHash, 11); reason == "" {
+		t.Fatal("checkCheckpoint did not report a removed event")
+	}
+}
@@ -0,0 +1,246 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// DayLayout is the format of the day to which a checkpoint applies.
+const DayLayout = "2006-01-02"
+
+// ErrIncompleteDay is returned when a checkpoint is requested for a day which has not yet ended.
+var ErrIncompleteDay = errors.New("checkpoints can only be created for days which have ended")
+
+// Checkpoint records the head of the hash chain at the end of a day, signed with the
+// service key so that it can later be used to prove the log has not been rewritten.
+type Checkpoint struct {
+	Day         string    `json:"day"`
+	LastEventId int64     `json:"lastEventId"`
+	EventCount  int64     `json:"eventCount"`
+	Digest      string    `json:"digest"`
+	Signature   string    `json:"signature"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// CheckpointFailure identifies a stored checkpoint which does not match the audit log.
+type CheckpointFailure struct {
+	Day    string `json:"day"`
+	Reason string `json:"reason"`
+}
+
+// signingKey retrieves the key used to sign checkpoints from the environment.
+func signingKey() ([]byte, error) {
+	key := os.Getenv("AUDIT_SIGNING_KEY")
+
+	if key == "" {
+		return nil, errors.New("no audit signing key configured")
+	}
+
+	return []byte(key), nil
+}
+
+// signCheckpoint computes the HMAC-SHA256 signature of a checkpoint's contents.
+func signCheckpoint(key []byte, checkpoint Checkpoint) string {
+	mac := hmac.New(sha256.New, key)
+
+	fmt.Fprintf(mac, "%s\n%d\n%d\n%s", checkpoint.Day, checkpoint.LastEventId, checkpoint.EventCount, checkpoint.Digest)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retrieveCheckpoint reads the stored checkpoint for a given day, if there is one.
+func retrieveCheckpoint(tx *sql.Tx, day string) (Checkpoint, bool, error) {
+	var checkpoint Checkpoint
+	var date time.Time
+
+	query :=
+		`SELECT day, last_event_id, event_count, digest, signature, date_created
+		 FROM audit_checkpoints WHERE day = $1;`
+	err := tx.QueryRow(query, day).Scan(
+		&date,
+		&checkpoint.LastEventId,
+		&checkpoint.EventCount,
+		&checkpoint.Digest,
+		&checkpoint.Signature,
+		&checkpoint.DateCreated,
+	)
+
+	if err == sql.ErrNoRows {
+		return checkpoint, false, nil
+	} else if err != nil {
+		logs.LogError(err, "Retrieve Audit Checkpoint Query Error")
+		return checkpoint, false, err
+	}
+
+	checkpoint.Day = date.Format(DayLayout)
+
+	return checkpoint, true, nil
+}
+
+// CreateCheckpoint opens a database connection and signs the head of the hash chain
+// as it stood at the end of the given day. Checkpoints are stored once and never
+// replaced, so a request for a day which already has a checkpoint returns the original.
+func CreateCheckpoint(day time.Time) (Checkpoint, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	checkpoint := Checkpoint{Day: start.Format(DayLayout), Digest: GenesisHash}
+
+	if end.After(time.Now().UTC()) {
+		return checkpoint, ErrIncompleteDay
+	}
+
+	key, err := signingKey()
+
+	if err != nil {
+		logs.LogError(err, "Audit Signing Key Error")
+		return checkpoint, err
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Audit Checkpoint Transaction Error")
+		return checkpoint, err
+	}
+
+	defer tx.Rollback()
+
+	if existing, found, err := retrieveCheckpoint(tx, checkpoint.Day); err != nil || found {
+		return existing, err
+	}
+
+	query := `SELECT id, hash FROM audit_events WHERE occurred_at < $1 ORDER BY id DESC LIMIT 1;`
+	err = tx.QueryRow(query, end).Scan(&checkpoint.LastEventId, &checkpoint.Digest)
+
+	if err != nil && err != sql.ErrNoRows {
+		logs.LogError(err, "Audit Checkpoint Head Query Error")
+		return checkpoint, err
+	}
+
+	query = `SELECT COUNT(*) FROM audit_events WHERE id <= $1;`
+	err = tx.QueryRow(query, checkpoint.LastEventId).Scan(&checkpoint.EventCount)
+
+	if err != nil {
+		logs.LogError(err, "Audit Checkpoint Count Query Error")
+		return checkpoint, err
+	}
+
+	checkpoint.Signature = signCheckpoint(key, checkpoint)
+	checkpoint.DateCreated = time.Now()
+
+	query =
+		`INSERT INTO audit_checkpoints( day, last_event_id, event_count, digest, signature, date_created )
+		 VALUES ( $1, $2, $3, $4, $5, $6 ) ON CONFLICT ( day ) DO NOTHING;`
+	_, err = tx.Exec(
+		query,
+		checkpoint.Day,
+		checkpoint.LastEventId,
+		checkpoint.EventCount,
+		checkpoint.Digest,
+		checkpoint.Signature,
+		checkpoint.DateCreated,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Save Audit Checkpoint Query Error")
+		return checkpoint, err
+	}
+
+	// Should a concurrent request have stored the checkpoint first, return that one.
+	stored, _, err := retrieveCheckpoint(tx, checkpoint.Day)
+
+	if err != nil {
+		return checkpoint, err
+	}
+
+	return stored, tx.Commit()
+}
+
+// VerifyCheckpoints opens a database connection and confirms that every stored
+// checkpoint carries a valid signature and still matches the event it was taken at.
+// It returns the first checkpoint which fails either check, or nil if all are valid.
+func VerifyCheckpoints() (*CheckpointFailure, error) {
+	key, err := signingKey()
+
+	if err != nil {
+		logs.LogError(err, "Audit Signing Key Error")
+		return nil, err
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT c.day, c.last_event_id, c.event_count, c.digest, c.signature,
+		 COALESCE( e.hash, $1 ), ( SELECT COUNT(*) FROM audit_events WHERE id <= c.last_event_id )
+		 FROM audit_checkpoints c LEFT JOIN audit_events e ON e.id = c.last_event_id
+		 ORDER BY c.day;`
+	rows, err := pool.Query(query, GenesisHash)
+
+	if err != nil {
+		logs.LogError(err, "Verify Audit Checkpoints Query Error")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var checkpoint Checkpoint
+		var date time.Time
+		var eventHash string
+		var eventCount int64
+
+		err = rows.Scan(
+			&date,
+			&checkpoint.LastEventId,
+			&checkpoint.EventCount,
+			&checkpoint.Digest,
+			&checkpoint.Signature,
+			&eventHash,
+			&eventCount,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Verify Audit Checkpoints Scan Error")
+			return nil, err
+		}
+
+		checkpoint.Day = date.Format(DayLayout)
+
+		if reason := checkCheckpoint(key, checkpoint, eventHash, eventCount); reason != "" {
+			return &CheckpointFailure{Day: checkpoint.Day, Reason: reason}, nil
+		}
+	}
+
+	return nil, rows.Err()
+}
+
+// checkCheckpoint compares a checkpoint against its signature and the current state of
+// the audit log, returning a description of the first discrepancy found.
+func checkCheckpoint(key []byte, checkpoint Checkpoint, eventHash string, eventCount int64) string {
+	expected := signCheckpoint(key, checkpoint)
+
+	switch {
+	case !hmac.Equal([]byte(expected), []byte(checkpoint.Signature)):
+		return "signature does not match the checkpoint contents"
+	case eventHash != checkpoint.Digest:
+		return fmt.Sprintf("digest does not match the hash of event %d", checkpoint.LastEventId)
+	case eventCount != checkpoint.EventCount:
+		return fmt.Sprintf("%d events were recorded up to event %d but %d are now present", checkpoint.EventCount, checkpoint.LastEventId, eventCount)
+	default:
+		return ""
+	}
+}
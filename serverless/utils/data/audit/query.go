@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Filter narrows down the audit events returned by a query. Empty fields are ignored.
+// Events are returned newest first, and Before may be set to the id of the last event
+// in a previous page of results to continue from that point.
+type Filter struct {
+	Actor  string
+	Target string
+	Action string
+	From   time.Time
+	To     time.Time
+	Before int64
+	Limit  int
+}
+
+// StoredEvent is an event as it has been recorded in the audit log.
+type StoredEvent struct {
+	Id         int64             `json:"id"`
+	OccurredAt time.Time         `json:"occurredAt"`
+	Actor      string            `json:"actor"`
+	ActorRole  string            `json:"actorRole"`
+	Action     string            `json:"action"`
+	Target     string            `json:"target"`
+	Changes    map[string]Change `json:"changes"`
+	SourceIP   string            `json:"sourceIp"`
+	UserAgent  string            `json:"userAgent"`
+	RequestId  string            `json:"requestId"`
+	PrevHash   string            `json:"prevHash"`
+	Hash       string            `json:"hash"`
+}
+
+// buildConditions converts a filter into a SQL where clause and its arguments.
+func buildConditions(filter Filter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+
+	if filter.Target != "" {
+		add("target = $%d", filter.Target)
+	}
+
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+
+	if !filter.From.IsZero() {
+		add("occurred_at >= $%d", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		add("occurred_at < $%d", filter.To)
+	}
+
+	if filter.Before > 0 {
+		add("id < $%d", filter.Before)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// RetrieveEvents opens a database connection and retrieves the audit events matching
+// the provided filter, newest first.
+func RetrieveEvents(filter Filter) ([]StoredEvent, error) {
+	events := []StoredEvent{}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	} else if filter.Limit > MaxLimit {
+		filter.Limit = MaxLimit
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	// People are recorded under their subject tokens, so filters naming a person are
+	// converted to the person's token. Erased people no longer have a token, and so
+	// their address matches no events.
+	for _, person := range []*string{&filter.Actor, &filter.Target} {
+		if !isPersonal(*person) {
+			continue
+		}
+
+		token, ok, err := lookupToken(pool, *person)
+
+		if err != nil {
+			return events, err
+		} else if ok {
+			*person = token
+		}
+	}
+
+	where, args := buildConditions(filter)
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(
+		`SELECT id, occurred_at, actor, COALESCE( actor_role, '' ), action, COALESCE( target, '' ),
+		 changes, COALESCE( source_ip, '' ), COALESCE( user_agent, '' ), COALESCE( request_id, '' ),
+		 prev_hash, hash
+		 FROM audit_events %s ORDER BY id DESC LIMIT $%d;`,
+		where,
+		len(args),
+	)
+	rows, err := pool.Query(query, args...)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Audit Events Query Error")
+		return events, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var event StoredEvent
+		var changes []byte
+
+		err = rows.Scan(
+			&event.Id,
+			&event.OccurredAt,
+			&event.Actor,
+			&event.ActorRole,
+			&event.Action,
+			&event.Target,
+			&changes,
+			&event.SourceIP,
+			&event.UserAgent,
+			&event.RequestId,
+			&event.PrevHash,
+			&event.Hash,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Audit Events Scan Error")
+			return events, err
+		}
+
+		if len(changes) > 0 {
+			if err = json.Unmarshal(changes, &event.Changes); err != nil {
+				logs.LogError(err, "Audit Changes Unmarshal Error")
+				return events, err
+			}
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return events, err
+	}
+
+	return events, reveal(pool, events)
+}
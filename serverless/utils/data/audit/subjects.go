@@ -0,0 +1,367 @@
+package audit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// The audit log is append-only, so the email addresses of the people it mentions are
+// never written to it. Each person is instead assigned a random key, stored in the
+// `audit_subjects` table, and is recorded under a token derived from that key. Changes
+// recorded against a person are encrypted with the same key. Destroying the key when a
+// guest is erased leaves their events in the hash chain, but they can no longer be read
+// or linked to the guest, even by someone who guesses their address.
+
+// tokenPrefix marks an actor or target recorded as a subject token.
+const tokenPrefix = "subject:"
+
+// sealedPrefix marks a change value encrypted with a subject's key.
+const sealedPrefix = "sealed:"
+
+// erased replaces the values of changes whose subject's key has been destroyed.
+const erased = "[erased]"
+
+// subjectKey is the key assigned to a person, along with the token derived from it.
+type subjectKey struct {
+	Subject string
+	Token   string
+	Key     []byte
+}
+
+// isPersonal reports whether an actor or target identifies a person, rather than a
+// team, upload, or the system.
+func isPersonal(value string) bool {
+	return strings.Contains(value, "@")
+}
+
+// normalizeSubject reduces an email address to the form in which it is keyed.
+func normalizeSubject(subject string) string {
+	return strings.ToLower(strings.TrimSpace(subject))
+}
+
+// subjectToken derives the token under which a person is recorded from their key.
+func subjectToken(key []byte, subject string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(subject))
+
+	return tokenPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// assignKey retrieves the key of a person as part of the provided transaction, first
+// creating one if they have not been recorded before.
+func assignKey(tx *sql.Tx, subject string) (subjectKey, error) {
+	key := subjectKey{Subject: normalizeSubject(subject), Key: make([]byte, 32)}
+
+	if _, err := rand.Read(key.Key); err != nil {
+		return key, err
+	}
+
+	query :=
+		`INSERT INTO audit_subjects( subject, token, key, date_created ) VALUES ( $1, $2, $3, $4 )
+		 ON CONFLICT ( subject ) DO NOTHING;`
+	_, err := tx.Exec(query, key.Subject, subjectToken(key.Key, key.Subject), key.Key, time.Now())
+
+	if err != nil {
+		logs.LogError(err, "Assign Audit Subject Key Query Error")
+		return key, err
+	}
+
+	query = `SELECT token, key FROM audit_subjects WHERE subject = $1;`
+	err = tx.QueryRow(query, key.Subject).Scan(&key.Token, &key.Key)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Audit Subject Key Query Error")
+	}
+
+	return key, err
+}
+
+// lookupToken retrieves the token under which a person is recorded. People who have
+// never been recorded, or who have been erased, have no token.
+func lookupToken(pool *sql.DB, subject string) (string, bool, error) {
+	var token string
+
+	query := `SELECT token FROM audit_subjects WHERE subject = $1;`
+	err := pool.QueryRow(query, normalizeSubject(subject)).Scan(&token)
+
+	if err == sql.ErrNoRows {
+		return token, false, nil
+	} else if err != nil {
+		logs.LogError(err, "Lookup Audit Subject Query Error")
+		return token, false, err
+	}
+
+	return token, true, nil
+}
+
+// lookupKeys retrieves the keys which remain for the given tokens, indexed by token.
+func lookupKeys(pool *sql.DB, tokens []string) (map[string]subjectKey, error) {
+	keys := map[string]subjectKey{}
+
+	if len(tokens) == 0 {
+		return keys, nil
+	}
+
+	query := `SELECT subject, token, key FROM audit_subjects WHERE token = ANY( $1 );`
+	rows, err := pool.Query(query, pq.Array(tokens))
+
+	if err != nil {
+		logs.LogError(err, "Lookup Audit Subject Keys Query Error")
+		return keys, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var key subjectKey
+
+		if err = rows.Scan(&key.Subject, &key.Token, &key.Key); err != nil {
+			logs.LogError(err, "Lookup Audit Subject Keys Scan Error")
+			return keys, err
+		}
+
+		keys[key.Token] = key
+	}
+
+	return keys, rows.Err()
+}
+
+// ForgetSubject destroys the key of a person as part of the provided transaction, so
+// that the events recorded under it can no longer be linked to them or read. It returns
+// false if the person had no key.
+func ForgetSubject(tx *sql.Tx, subject string) (bool, error) {
+	result, err := tx.Exec(`DELETE FROM audit_subjects WHERE subject = $1;`, normalizeSubject(subject))
+
+	if err != nil {
+		logs.LogError(err, "Forget Audit Subject Query Error")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	return affected > 0, err
+}
+
+// SubjectHash computes a keyed hash of a person's email address with the audit signing
+// key. It allows the service to confirm that a given person was erased, without allowing
+// anyone who lacks the key to test candidate addresses against it.
+func SubjectHash(subject string) (string, error) {
+	key, err := signingKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("erasure\n" + normalizeSubject(subject)))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// newSubjectCipher prepares the AES-GCM cipher with which a person's changes are encrypted.
+func newSubjectCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealChanges encrypts the values of each change with a person's key. Values which are
+// empty or redacted reveal nothing and are left as they are.
+func sealChanges(key []byte, changes map[string]Change) error {
+	aead, err := newSubjectCipher(key)
+
+	if err != nil {
+		return err
+	}
+
+	seal := func(value any) (any, error) {
+		if value == nil || value == redacted {
+			return value, nil
+		}
+
+		plain, err := json.Marshal(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+
+		if _, err = rand.Read(nonce); err != nil {
+			return nil, err
+		}
+
+		return sealedPrefix + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil)), nil
+	}
+
+	for column, change := range changes {
+		if change.Before, err = seal(change.Before); err != nil {
+			return err
+		}
+
+		if change.After, err = seal(change.After); err != nil {
+			return err
+		}
+
+		changes[column] = change
+	}
+
+	return nil
+}
+
+// openChanges decrypts the values of each change with a person's key. If the key has
+// been destroyed, indicated by a nil key, the values are replaced with a placeholder.
+func openChanges(key []byte, changes map[string]Change) error {
+	var aead cipher.AEAD
+	var err error
+
+	if key != nil {
+		if aead, err = newSubjectCipher(key); err != nil {
+			return err
+		}
+	}
+
+	open := func(value any) (any, error) {
+		stored, ok := value.(string)
+
+		if !ok || !strings.HasPrefix(stored, sealedPrefix) {
+			return value, nil
+		} else if aead == nil {
+			return erased, nil
+		}
+
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+
+		if err != nil {
+			return nil, err
+		} else if len(sealed) < aead.NonceSize() {
+			return nil, errors.New("sealed audit change is truncated")
+		}
+
+		plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var opened any
+		err = json.Unmarshal(plain, &opened)
+
+		return opened, err
+	}
+
+	for column, change := range changes {
+		if change.Before, err = open(change.Before); err != nil {
+			return err
+		}
+
+		if change.After, err = open(change.After); err != nil {
+			return err
+		}
+
+		changes[column] = change
+	}
+
+	return nil
+}
+
+// protect replaces the people identified by an event with their tokens, and encrypts its
+// changes with the key of its target, before the event is recorded.
+func protect(tx *sql.Tx, event Event) (Event, error) {
+	if isPersonal(event.Actor) {
+		key, err := assignKey(tx, event.Actor)
+
+		if err != nil {
+			return event, err
+		}
+
+		event.Actor = key.Token
+	}
+
+	if isPersonal(event.Target) {
+		key, err := assignKey(tx, event.Target)
+
+		if err != nil {
+			return event, err
+		}
+
+		event.Target = key.Token
+
+		// The changes are copied so that the caller's event is left unencrypted.
+		changes := make(map[string]Change, len(event.Changes))
+
+		for column, change := range event.Changes {
+			changes[column] = change
+		}
+
+		event.Changes = changes
+
+		if err = sealChanges(key.Key, event.Changes); err != nil {
+			logs.LogError(err, "Seal Audit Changes Error")
+			return event, err
+		}
+	}
+
+	return event, nil
+}
+
+// reveal restores the people identified by stored events from their tokens, and
+// decrypts the events' changes. People who have been erased remain as their tokens.
+func reveal(pool *sql.DB, events []StoredEvent) error {
+	var tokens []string
+
+	for _, event := range events {
+		for _, value := range []string{event.Actor, event.Target} {
+			if strings.HasPrefix(value, tokenPrefix) {
+				tokens = append(tokens, value)
+			}
+		}
+	}
+
+	keys, err := lookupKeys(pool, tokens)
+
+	if err != nil {
+		return err
+	}
+
+	for i, event := range events {
+		if key, ok := keys[event.Actor]; ok {
+			events[i].Actor = key.Subject
+		}
+
+		if !strings.HasPrefix(event.Target, tokenPrefix) {
+			continue
+		}
+
+		key, ok := keys[event.Target]
+
+		if ok {
+			events[i].Target = key.Subject
+		}
+
+		if err = openChanges(key.Key, event.Changes); err != nil {
+			logs.LogError(err, "Open Audit Changes Error")
+			return err
+		}
+	}
+
+	return nil
+}
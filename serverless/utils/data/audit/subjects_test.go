@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubjectHash(t *testing.T) {
+	t.Setenv("AUDIT_SIGNING_KEY", "first-key")
+
+	hash, err := SubjectHash(" Guest@Test.Test ")
+	if err != nil {
+		t.Fatalf("SubjectHash error: %v", err)
+	}
+
+	if want, _ := SubjectHash("guest@test.test"); hash != want || len(hash) != 64 {
+		t.Fatalf("SubjectHash returned %s, want %s", hash, want)
+	}
+
+	t.Setenv("AUDIT_SIGNING_KEY", "second-key")
+
+	if other, _ := SubjectHash("guest@test.test"); other == hash {
+		t.Fatal("SubjectHash returned the same hash under a different key")
+	}
+}
+
+func TestSubjectToken(t *testing.T) {
+	token := subjectToken([]byte("first-key"), "guest@test.test")
+
+	if !strings.HasPrefix(token, tokenPrefix) || strings.Contains(token, "guest") {
+		t.Fatalf("subjectToken returned %s, want a token which does not reveal the subject", token)
+	}
+
+	if other := subjectToken([]byte("second-key"), "guest@test.test"); other == token {
+		t.Fatal("subjectToken returned the same token under a different key")
+	}
+}
+
+func TestSealChanges(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	changes := map[string]Change{
+		"guests.first_name": {Before: "Ann", After: "Anne"},
+		"guests.salt":       {Before: redacted, After: redacted},
+		"guests.locked":     {Before: nil, After: true},
+	}
+
+	if err := sealChanges(key, changes); err != nil {
+		t.Fatalf("sealChanges error: %v", err)
+	}
+
+	if sealed, _ := changes["guests.first_name"].After.(string); !strings.HasPrefix(sealed, sealedPrefix) {
+		t.Fatalf("sealChanges left %v unencrypted", changes["guests.first_name"].After)
+	}
+
+	opened := map[string]Change{}
+
+	for column, change := range changes {
+		opened[column] = change
+	}
+
+	if err := openChanges(key, opened); err != nil {
+		t.Fatalf("openChanges error: %v", err)
+	}
+
+	expected := map[string]Change{
+		"guests.first_name": {Before: "Ann", After: "Anne"},
+		"guests.salt":       {Before: redacted, After: redacted},
+		"guests.locked":     {Before: nil, After: true},
+	}
+
+	if !reflect.DeepEqual(opened, expected) {
+		t.Fatalf("openChanges result %v, want %v", opened, expected)
+	}
+
+	// Once the key is destroyed, the values can no longer be read.
+	if err := openChanges(nil, changes); err != nil {
+		t.Fatalf("openChanges error: %v", err)
+	}
+
+	if changes["guests.first_name"].Before != erased || changes["guests.locked"].Before != nil {
+		t.Fatalf("openChanges without a key returned %v, want erased values", changes)
+	}
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// Verification reports the outcome of walking the audit log's hash chain.
+type Verification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	Head     string `json:"head"`
+	BrokenAt int64  `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// checkLink compares a stored event against the hash chain, returning a description
+// of the problem if the event does not follow on from the previous hash.
+func checkLink(prevHash string, storedPrev string, storedHash string, entry chainEntry) (string, error) {
+	if storedPrev != prevHash {
+		return fmt.Sprintf("previous hash %s does not match the hash of the preceding event %s", storedPrev, prevHash), nil
+	}
+
+	hash, err := chainHash(prevHash, entry)
+
+	if err != nil {
+		return "", err
+	}
+
+	if hash != storedHash {
+		return fmt.Sprintf("stored hash %s does not match the recomputed hash %s", storedHash, hash), nil
+	}
+
+	return "", nil
+}
+
+// VerifyChain opens a database connection and walks the audit log from the first event
+// to the most recent, recomputing each hash. It stops at and reports the first event
+// whose hash does not match its contents or whose link to the preceding event is broken.
+func VerifyChain() (Verification, error) {
+	result := Verification{Head: GenesisHash}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT id, occurred_at, actor, COALESCE( actor_role, '' ), action, COALESCE( target, '' ),
+		 changes, COALESCE( source_ip, '' ), COALESCE( user_agent, '' ), COALESCE( request_id, '' ),
+		 prev_hash, hash
+		 FROM audit_events ORDER BY id;`
+	rows, err := pool.Query(query)
+
+	if err != nil {
+		logs.LogError(err, "Verify Audit Chain Query Error")
+		return result, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var entry chainEntry
+		var storedPrev, storedHash string
+
+		err = rows.Scan(
+			&id,
+			&entry.OccurredAt,
+			&entry.Actor,
+			&entry.ActorRole,
+			&entry.Action,
+			&entry.Target,
+			&entry.Changes,
+			&entry.SourceIP,
+			&entry.UserAgent,
+			&entry.RequestId,
+			&storedPrev,
+			&storedHash,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Verify Audit Chain Scan Error")
+			return result, err
+		}
+
+		reason, err := checkLink(result.Head, storedPrev, storedHash, entry)
+
+		if err != nil {
+			return result, err
+		} else if reason != "" {
+			result.BrokenAt = id
+			result.Reason = reason
+
+			return result, nil
+		}
+
+		result.Checked++
+		result.Head = storedHash
+	}
+
+	if err = rows.Err(); err != nil {
+		return result, err
+	}
+
+	result.Valid = true
+
+	return result, nil
+}
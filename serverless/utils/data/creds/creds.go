@@ -1,11 +1,14 @@
 package creds
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/data/users"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	"github.com/IIP-Design/commons-gateway/utils/security/hashing"
@@ -18,6 +21,7 @@ type CredentialsData struct {
 	Expired    bool     `json:"expired"`
 	Approved   bool     `json:"approved"`
 	Locked     bool     `json:"locked"`
+	Archived   bool     `json:"archived"`
 	FirstLogin bool     `json:"firstLogin"`
 	Role       string   `json:"role"`
 }
@@ -50,14 +54,16 @@ func RetrieveCredentials(email string) (CredentialsData, error) {
 	var expired bool
 	var approved bool
 	var locked bool
+	var archived bool
 	var firstLogin bool
 	var role string
 
 	query :=
-		`SELECT pass_hash, salt, expiration < NOW() AS expired, pending=FALSE AS approved, locked, first_login, role
+		`SELECT pass_hash, salt, expiration < NOW() AS expired, pending=FALSE AS approved, locked,
+		 archived_at IS NOT NULL AS archived, first_login, role
 		 FROM guest_auth_data WHERE email = $1;`
 
-	err = pool.QueryRow(query, email).Scan(&passHash, &salt, &expired, &approved, &locked, &firstLogin, &role)
+	err = pool.QueryRow(query, email).Scan(&passHash, &salt, &expired, &approved, &locked, &archived, &firstLogin, &role)
 
 	if err != nil {
 		logs.LogError(err, "Retrieve Credentials Query Error")
@@ -88,6 +94,7 @@ func RetrieveCredentials(email string) (CredentialsData, error) {
 		Expired:    expired,
 		Approved:   approved,
 		Locked:     locked,
+		Archived:   archived,
 		FirstLogin: firstLogin,
 		Role:       role,
 	}
@@ -95,16 +102,22 @@ func RetrieveCredentials(email string) (CredentialsData, error) {
 	return creds, err
 }
 
-func SaveInitialInvite(invite data.Invite, setPending bool) (string, error) {
+// SaveInitialInvite registers a guest user and records their invitation, returning their
+// temporary password. The guest and invitation are saved in a single transaction along
+// with the provided audit event. Archived guests are restored rather than recreated. If
+// notify is not nil, it is called with the password before the transaction is committed.
+func SaveInitialInvite(invite data.Invite, setPending bool, notify outbox.Notify, event audit.Event) (string, error) {
 	var pass string
 
-	// Ensure invitee doesn't already have access.
+	// Ensure invitee doesn't already have access. Archived guests, and guests whose
+	// proposed invitation was rejected, may be invited again. Archived admins may not be
+	// invited as guests, since a user cannot change type; they are re-invited as admins.
 	exists, user, err := users.CheckForExistingUser(invite.Invitee.Email)
 
 	if err != nil {
 		logs.LogError(err, "Check For Existing User Error")
 		return pass, err
-	} else if exists {
+	} else if exists && !((user.Archived || user.Rejected) && user.Type == "guest") {
 		err = fmt.Errorf(
 			"the user %s has already been registered as a user of type %s",
 			invite.Invitee.Email,
@@ -115,55 +128,84 @@ func SaveInitialInvite(invite data.Invite, setPending bool) (string, error) {
 		return pass, fmt.Errorf("user already exists")
 	}
 
-	// Save credentials
-	err = invites.SaveCredentials(invite.Invitee)
-
-	if err != nil {
-		logs.LogError(err, "Save Credentials Error")
-		return pass, errors.New("something went wrong - credential generation failed")
+	subjects := []audit.Subject{
+		{Table: "guests", Column: "email", Key: invite.Invitee.Email},
+		{Table: "recent_invites", Column: "invitee", Key: invite.Invitee.Email},
 	}
 
-	// PASSWORD IS UNRECOVERABLE
-	pass, salt := hashing.GenerateCredentials()
-	hash := hashing.GenerateHash(pass, salt)
+	err = audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		var err error
 
-	// Record the invitation - has to follow cred generation due to foreign key constraint
-	var email string
+		// Save credentials, archived and rejected guests are restored rather than recreated.
+		if exists {
+			err = invites.ReinstateCredentials(tx, invite.Invitee)
+		} else {
+			err = invites.SaveCredentials(tx, invite.Invitee)
+		}
 
-	if setPending {
-		email = invite.Proposer
-	} else {
-		email = invite.Inviter
-	}
+		if err != nil {
+			logs.LogError(err, "Save Credentials Error")
+			return errors.New("something went wrong - credential generation failed")
+		}
 
-	err = invites.SaveInvite(email, invite.Invitee.Email, invite.Expires, hash, salt, setPending, true, true)
+		// PASSWORD IS UNRECOVERABLE
+		var salt string
+		pass, salt = hashing.GenerateCredentials()
+		hash := hashing.GenerateHash(pass, salt)
 
-	if err != nil {
-		logs.LogError(err, "Save Invite Error")
-		return pass, errors.New("something went wrong - saving invite failed")
-	}
+		// Record the invitation - has to follow cred generation due to foreign key constraint
+		var email string
+
+		if setPending {
+			email = invite.Proposer
+		} else {
+			email = invite.Inviter
+		}
+
+		err = invites.SaveInvite(tx, email, invite.Invitee.Email, invite.Expires, hash, salt, setPending, true, true)
+
+		if err != nil {
+			logs.LogError(err, "Save Invite Error")
+			return errors.New("something went wrong - saving invite failed")
+		}
 
-	return pass, nil
+		if notify != nil {
+			return notify(tx, pass)
+		}
+
+		return nil
+	})
+
+	return pass, err
 }
 
 // ResetPassword assigns a given user a new random temporary password. It allows
 // records this as the user's first login so that they are required to update their
-// password upon their next login.
-func ResetPassword(email string) (string, error) {
-	pool := data.ConnectToDB()
-	defer pool.Close()
-
+// password upon their next login. The reset is recorded with the provided audit event.
+// If notify is not nil, it is called with the password before the reset is committed.
+func ResetPassword(email string, notify outbox.Notify, event audit.Event) (string, error) {
 	pass, salt := hashing.GenerateCredentials()
 	hash := hashing.GenerateHash(pass, salt)
 
-	query :=
-		`UPDATE invites SET salt = $1, pass_hash = $2, first_login = TRUE WHERE invitee = $3
-		 AND date_invited = ( SELECT MAX(date_invited) FROM invites WHERE invitee = $3 AND pending = FALSE );`
-	_, err := pool.Exec(query, salt, hash, email)
+	subjects := []audit.Subject{{Table: "recent_invites", Column: "invitee", Key: email}}
 
-	if err != nil {
-		logs.LogError(err, "Reset Password Error")
-	}
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		query :=
+			`UPDATE invites SET salt = $1, pass_hash = $2, first_login = TRUE WHERE invitee = $3
+			 AND date_invited = ( SELECT MAX(date_invited) FROM invites WHERE invitee = $3 AND pending = FALSE );`
+		_, err := tx.Exec(query, salt, hash, email)
+
+		if err != nil {
+			logs.LogError(err, "Reset Password Error")
+			return err
+		}
+
+		if notify != nil {
+			return notify(tx, pass)
+		}
+
+		return nil
+	})
 
 	return pass, err
 }
@@ -0,0 +1,32 @@
+package data
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultLocale is the language used for users who have not chosen one.
+const DefaultLocale = "en"
+
+// Locales lists the languages in which emails can be sent to users.
+var Locales = []string{"en", "fr", "es", "ar", "pt"}
+
+var ErrUnsupportedLocale = errors.New("locale must be one of en, fr, es, ar, or pt")
+
+// NormalizeLocale reduces a language tag such as `fr-CA` to the supported language it
+// belongs to. An empty tag is returned unchanged, so that an existing preference is kept.
+func NormalizeLocale(tag string) (string, error) {
+	if tag == "" {
+		return "", nil
+	}
+
+	language := strings.ToLower(strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0])
+
+	for _, locale := range Locales {
+		if language == locale {
+			return locale, nil
+		}
+	}
+
+	return "", ErrUnsupportedLocale
+}
@@ -21,6 +21,7 @@ type UserBodyOptions struct {
 	TeamId     string `json:"team"`
 	TeamName   string `json:"teamName"`
 	AprimoName string `json:"teamAprimo"`
+	Locale     string `json:"locale"`
 }
 
 type MFARequest struct {
@@ -32,15 +33,17 @@ type MFARequest struct {
 // JSON object sent to the serverless functions by the API Gateway.
 type RequestBodyOptions struct {
 	UserBodyOptions
-	Active   bool            `json:"active"`
-	Hash     string          `json:"hash"`
-	Invitee  UserBodyOptions `json:"invitee"`
-	Inviter  string          `json:"inviter"`
-	Admin    string          `json:"admin"`
-	MFA      MFARequest      `json:"mfa"`
-	Proposer string          `json:"proposer"`
-	Username string          `json:"username"`
-	Token    string          `json:"token"`
+	Active    bool            `json:"active"`
+	Digest    bool            `json:"digest"`
+	Hash      string          `json:"hash"`
+	Invitee   UserBodyOptions `json:"invitee"`
+	Inviter   string          `json:"inviter"`
+	Admin     string          `json:"admin"`
+	MFA       MFARequest      `json:"mfa"`
+	NeverUsed bool            `json:"neverUsed"`
+	Proposer  string          `json:"proposer"`
+	Username  string          `json:"username"`
+	Token     string          `json:"token"`
 }
 
 // User represents the properties required to record a user.
@@ -50,18 +53,22 @@ type User struct {
 	NameLast  string `json:"familyName"`
 	Role      string `json:"role"`
 	Team      string `json:"team"`
+	Locale    string `json:"locale,omitempty"`
 }
 
 // AdminUser extends the base User struct with unique admin properties.
 type AdminUser struct {
 	Active bool `json:"active"`
+	Digest bool `json:"digest"`
 	User
 }
 
 // GuestUser extends the base User struct with unique guest properties.
 type GuestUser struct {
-	Expires string `json:"expires"`
-	Pending bool   `json:"pending"`
+	Expires   string     `json:"expires"`
+	Pending   bool       `json:"pending"`
+	Rejected  bool       `json:"rejected,omitempty"`
+	LastLogin *time.Time `json:"lastLogin,omitempty"`
 	User
 }
 
@@ -101,8 +108,10 @@ type AcceptInvite struct {
 	Inviter string `json:"inviterEmail"`
 }
 
-type GuestUnlockInitEvent struct {
-	Username string `json:"username"`
+// ArchiveRequest represents the properties required to archive or restore a record.
+type ArchiveRequest struct {
+	Id     string `json:"id"`
+	Reason string `json:"reason"`
 }
 
 type GuestReauth struct {
@@ -145,11 +154,18 @@ func ExtractUser(body string) (User, error) {
 		return user, errors.New("data missing from request")
 	}
 
+	locale, err := NormalizeLocale(parsed.Locale)
+
+	if err != nil {
+		return user, err
+	}
+
 	user.Email = email
 	user.NameFirst = firstName
 	user.NameLast = lastName
 	user.Role = role
 	user.Team = team
+	user.Locale = locale
 
 	return user, err
 }
@@ -200,6 +216,7 @@ func ExtractGuestUser(body string) (GuestUser, error) {
 	guest.NameLast = userData.NameLast
 	guest.Role = userData.Role
 	guest.Team = userData.Team
+	guest.Locale = userData.Locale
 
 	return guest, err
 }
@@ -239,6 +256,12 @@ func ExtractInvite(body string) (Invite, error) {
 		return invite, err
 	}
 
+	locale, err := NormalizeLocale(parsed.Invitee.Locale)
+
+	if err != nil {
+		return invite, err
+	}
+
 	invite.Inviter = admin
 	invite.Proposer = proposer
 	invite.Invitee.Email = guest
@@ -246,6 +269,7 @@ func ExtractInvite(body string) (Invite, error) {
 	invite.Invitee.NameLast = lastName
 	invite.Invitee.Role = role
 	invite.Invitee.Team = team
+	invite.Invitee.Locale = locale
 	invite.Expires = parsedTime
 
 	return invite, err
@@ -288,3 +312,21 @@ func ExtractReauth(body string) (GuestReauth, error) {
 
 	return ret, err
 }
+
+// ExtractArchiveRequest parses an API Gateway request body returning the
+// id of the record to be archived (or restored) and the reason for doing so.
+func ExtractArchiveRequest(body string) (ArchiveRequest, error) {
+	var request ArchiveRequest
+
+	b := []byte(body)
+	err := json.Unmarshal(b, &request)
+
+	if err != nil {
+		logs.LogError(err, "Failed to Unmarshal Archive Request")
+		return request, err
+	} else if request.Id == "" {
+		return request, errors.New("data missing from request")
+	}
+
+	return request, err
+}
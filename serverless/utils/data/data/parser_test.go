@@ -1,6 +1,7 @@
 package data
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -75,6 +76,31 @@ func TestExtractInvite(t *testing.T) {
 	}
 }
 
+func TestExtractInviteLocale(t *testing.T) {
+	body := strings.Replace(makeJsonBody(), `"givenName": "Carmen",`, `"givenName": "Carmen", "locale": "fr-CA",`, 1)
+	invite, err := ExtractInvite(body)
+
+	if invite.Invitee.Locale != "fr" || err != nil {
+		t.Fatalf(`ExtractInvite error, have %s %v, want %s nil`, invite.Invitee.Locale, err, "fr")
+	}
+
+	body = strings.Replace(makeJsonBody(), `"givenName": "Carmen",`, `"givenName": "Carmen", "locale": "de",`, 1)
+
+	if _, err = ExtractInvite(body); err != ErrUnsupportedLocale {
+		t.Fatalf(`ExtractInvite error, have %v, want %v`, err, ErrUnsupportedLocale)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	cases := map[string]string{"": "", "en": "en", "pt_BR": "pt", "AR-EG": "ar", "es-419": "es"}
+
+	for tag, want := range cases {
+		if locale, err := NormalizeLocale(tag); locale != want || err != nil {
+			t.Fatalf(`NormalizeLocale(%q) error, have %s %v, want %s nil`, tag, locale, err, want)
+		}
+	}
+}
+
 func TestExtractAcceptInvite(t *testing.T) {
 	body := `{"inviteeEmail": "invitee@example.com", "inviterEmail": "inviter@example.com"}`
 	user, err := ExtractAcceptInvite(body)
@@ -92,3 +118,21 @@ func TestExtractReauth(t *testing.T) {
 		t.Fatalf(`ExtractReauth error, have %s %v, want %s nil`, user.Email, err, "email@example.com")
 	}
 }
+
+func TestExtractArchiveRequest(t *testing.T) {
+	body := `{"id": "invitee@example.com", "reason": "invited by mistake"}`
+	request, err := ExtractArchiveRequest(body)
+
+	if request.Id != "invitee@example.com" || request.Reason != "invited by mistake" || err != nil {
+		t.Fatalf(`ExtractArchiveRequest error, have %s %s %v, want %s %s nil`, request.Id, request.Reason, err, "invitee@example.com", "invited by mistake")
+	}
+}
+
+func TestExtractArchiveRequestMissingId(t *testing.T) {
+	body := `{"reason": "invited by mistake"}`
+	_, err := ExtractArchiveRequest(body)
+
+	if err == nil {
+		t.Fatal(`ExtractArchiveRequest error, have nil, want error`)
+	}
+}
@@ -0,0 +1,290 @@
+// Package digest gathers the activity reported to admins in the daily digest of their
+// team: pending proposals, guests whose access has just expired, and failed uploads.
+package digest
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// Period is the span of activity covered by each digest.
+const Period = 24 * time.Hour
+
+// ExpiringWithin is how soon a pending proposal's requested access must end for the
+// digest to report it as about to expire, since it is no longer listed once it has.
+const ExpiringWithin = 3 * 24 * time.Hour
+
+// minInterval is the shortest time between two digests sent to the same admin. It is
+// shorter than the period so that a run which starts a little early still sends.
+const minInterval = 20 * time.Hour
+
+// Proposal describes a proposed invitation awaiting approval.
+type Proposal struct {
+	Guest    data.User
+	Proposer string
+	Expires  time.Time
+}
+
+// ExpiredGuest describes a guest whose access ended during the digest's period.
+type ExpiredGuest struct {
+	Guest   data.User
+	Expired time.Time
+}
+
+// FailedUpload describes an upload whose transfer to Aprimo failed during the digest's
+// period and has not since succeeded.
+type FailedUpload struct {
+	S3Id        string
+	Description string
+	Uploader    data.User
+	IsGuest     bool
+	Failed      time.Time
+}
+
+// SplitProposals divides the pending invites of a team, as returned by
+// `guests.RetrievePendingInvites`, into those awaiting approval and those whose requested
+// access ends within ExpiringWithin of now.
+func SplitProposals(invites []map[string]string, now time.Time) ([]Proposal, []Proposal, error) {
+	awaiting := []Proposal{}
+	expiring := []Proposal{}
+
+	for _, invite := range invites {
+		expires, err := time.Parse(time.RFC3339, invite["expiration"])
+
+		if err != nil {
+			return awaiting, expiring, err
+		}
+
+		proposal := Proposal{
+			Guest: data.User{
+				Email:     invite["email"],
+				NameFirst: invite["givenName"],
+				NameLast:  invite["familyName"],
+				Role:      invite["role"],
+				Team:      invite["team"],
+			},
+			Proposer: invite["proposer"],
+			Expires:  expires,
+		}
+
+		if expires.Sub(now) < ExpiringWithin {
+			expiring = append(expiring, proposal)
+		} else {
+			awaiting = append(awaiting, proposal)
+		}
+	}
+
+	return awaiting, expiring, nil
+}
+
+// Subscribers opens a database connection and retrieves the active admins who have opted
+// in to the digest and have not been sent one recently. Admins whose address has been
+// suppressed are omitted.
+func Subscribers(now time.Time) ([]data.User, error) {
+	admins := []data.User{}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT email, first_name, last_name, role, team FROM admins
+		 WHERE digest AND active AND archived_at IS NULL AND email_suppressed_at IS NULL
+		 AND ( digest_sent_at IS NULL OR digest_sent_at < $1 ) ORDER BY email;`
+	rows, err := pool.Query(query, now.Add(-minInterval))
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Digest Subscribers Query Error")
+		return admins, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var admin data.User
+
+		if err := rows.Scan(&admin.Email, &admin.NameFirst, &admin.NameLast, &admin.Role, &admin.Team); err != nil {
+			logs.LogError(err, "Retrieve Digest Subscribers Scan Error")
+			return admins, err
+		}
+
+		admins = append(admins, admin)
+	}
+
+	if err = rows.Err(); err != nil {
+		logs.LogError(err, "Retrieve Digest Subscribers Row Error")
+	}
+
+	return admins, err
+}
+
+// RetrieveExpiredGuests opens a database connection and retrieves the guests on a team
+// whose access ended between since and now, according to their latest approved invite.
+func RetrieveExpiredGuests(team string, since time.Time, now time.Time) ([]ExpiredGuest, error) {
+	expired := []ExpiredGuest{}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT g.email, g.first_name, g.last_name, g.role, g.team, i.expiration
+		 FROM guests g
+		 JOIN ( SELECT DISTINCT ON ( invitee ) invitee, expiration, pending FROM invites ORDER BY invitee, date_invited DESC ) i
+		 ON i.invitee = g.email
+		 WHERE g.team = $1 AND NOT i.pending AND g.archived_at IS NULL AND i.expiration >= $2 AND i.expiration < $3
+		 ORDER BY i.expiration;`
+	rows, err := pool.Query(query, team, since, now)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Expired Guests Query Error")
+		return expired, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var guest ExpiredGuest
+
+		err := rows.Scan(
+			&guest.Guest.Email,
+			&guest.Guest.NameFirst,
+			&guest.Guest.NameLast,
+			&guest.Guest.Role,
+			&guest.Guest.Team,
+			&guest.Expired,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Expired Guests Scan Error")
+			return expired, err
+		}
+
+		expired = append(expired, guest)
+	}
+
+	if err = rows.Err(); err != nil {
+		logs.LogError(err, "Retrieve Expired Guests Row Error")
+	}
+
+	return expired, err
+}
+
+// RetrieveFailedUploads opens a database connection and retrieves the uploads by a team
+// whose transfer to Aprimo failed or was abandoned since the given time, and which have
+// not since been recorded in Aprimo.
+func RetrieveFailedUploads(team string, since time.Time) ([]FailedUpload, error) {
+	failed := []FailedUpload{}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT u.s3_id, u.description, COALESCE( g.email, a.email, u.user_id ),
+		 COALESCE( g.first_name, a.first_name, '' ), COALESCE( g.last_name, a.last_name, '' ), g.email IS NOT NULL,
+		 COALESCE( u.aprimo_abandoned_dt, u.aprimo_error_dt )
+		 FROM uploads u
+		 LEFT JOIN all_users au ON u.user_id = au.user_id
+		 LEFT JOIN guests g ON au.guest_id = g.email
+		 LEFT JOIN admins a ON au.admin_id = a.email
+		 WHERE u.team_id = $1 AND u.aprimo_record_dt IS NULL
+		 AND ( u.aprimo_abandoned_dt >= $2 OR u.aprimo_error_dt >= $2 )
+		 ORDER BY u.date_uploaded;`
+	rows, err := pool.Query(query, team, since)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Failed Uploads Query Error")
+		return failed, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload FailedUpload
+
+		err := rows.Scan(
+			&upload.S3Id,
+			&upload.Description,
+			&upload.Uploader.Email,
+			&upload.Uploader.NameFirst,
+			&upload.Uploader.NameLast,
+			&upload.IsGuest,
+			&upload.Failed,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Failed Uploads Scan Error")
+			return failed, err
+		}
+
+		failed = append(failed, upload)
+	}
+
+	if err = rows.Err(); err != nil {
+		logs.LogError(err, "Retrieve Failed Uploads Row Error")
+	}
+
+	return failed, err
+}
+
+// Send opens a database connection and adds an admin's digest to the outbox, recording
+// in the same transaction when it was sent so that it is not sent again within the period.
+func Send(admin data.User, message email.Message) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Digest Transaction Error")
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = outbox.Enqueue(tx, email.TemplateDigest, message); err != nil {
+		return err
+	}
+
+	if err = markSent(tx, admin.Email); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Skip opens a database connection and records that an admin had nothing to report, so
+// that they are not checked again within the period.
+func Skip(admin data.User) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Digest Transaction Error")
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = markSent(tx, admin.Email); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// markSent records when an admin's digest was sent, as part of the provided transaction.
+func markSent(tx *sql.Tx, address string) error {
+	_, err := tx.Exec(`UPDATE admins SET digest_sent_at = $1 WHERE email = $2;`, time.Now(), address)
+
+	if err != nil {
+		logs.LogError(err, "Record Digest Sent Query Error")
+	}
+
+	return err
+}
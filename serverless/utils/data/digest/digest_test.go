@@ -0,0 +1,36 @@
+package digest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitProposals(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 13, 0, 0, 0, time.UTC)
+
+	invites := []map[string]string{
+		{"email": "soon@example.com", "expiration": "2024-03-03T12:00:00Z", "proposer": "guest@example.com"},
+		{"email": "later@example.com", "expiration": "2024-04-01T12:00:00Z", "proposer": "guest@example.com"},
+	}
+
+	awaiting, expiring, err := SplitProposals(invites, now)
+	if err != nil {
+		t.Fatalf("SplitProposals error: %v", err)
+	}
+
+	if len(awaiting) != 1 || awaiting[0].Guest.Email != "later@example.com" {
+		t.Fatalf("SplitProposals awaiting %+v, want later@example.com", awaiting)
+	}
+
+	if len(expiring) != 1 || expiring[0].Guest.Email != "soon@example.com" || expiring[0].Proposer != "guest@example.com" {
+		t.Fatalf("SplitProposals expiring %+v, want soon@example.com", expiring)
+	}
+}
+
+func TestSplitProposalsInvalid(t *testing.T) {
+	invites := []map[string]string{{"email": "guest@example.com", "expiration": "tomorrow"}}
+
+	if _, _, err := SplitProposals(invites, time.Now()); err == nil {
+		t.Fatal("SplitProposals error nil, want a parse error")
+	}
+}
@@ -0,0 +1,453 @@
+package guests
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+type ExportProfile struct {
+	Email        string     `json:"email"`
+	FirstName    string     `json:"givenName"`
+	LastName     string     `json:"familyName"`
+	Role         string     `json:"role"`
+	Team         string     `json:"team"`
+	DateCreated  time.Time  `json:"dateCreated"`
+	DateModified time.Time  `json:"dateModified"`
+	Locale       string     `json:"locale"`
+	ArchivedAt   *time.Time `json:"archivedAt"`
+}
+
+type ExportLoginState struct {
+	FirstLogin    bool       `json:"firstLogin"`
+	Locked        bool       `json:"locked"`
+	LoginAttempts int        `json:"loginAttempts"`
+	LastAttempt   *time.Time `json:"lastAttempt"`
+}
+
+type ExportLoginAttempt struct {
+	AttemptedAt time.Time `json:"attemptedAt"`
+	Success     bool      `json:"success"`
+	Reason      string    `json:"reason"`
+	SourceIp    string    `json:"sourceIp"`
+	UserAgent   string    `json:"userAgent"`
+}
+
+type ExportInvite struct {
+	Inviter       string     `json:"inviter"`
+	Proposer      string     `json:"proposer"`
+	Pending       bool       `json:"pending"`
+	DateInvited   time.Time  `json:"dateInvited"`
+	Expiration    time.Time  `json:"expiration"`
+	FirstLogin    bool       `json:"firstLogin"`
+	PasswordReset bool       `json:"passwordReset"`
+	RejectedAt    *time.Time `json:"rejectedAt"`
+	RejectedBy    string     `json:"rejectedBy"`
+	RejectReason  string     `json:"rejectReason"`
+}
+
+type ExportEmail struct {
+	Template    string     `json:"template"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	DateCreated time.Time  `json:"dateCreated"`
+	DateSent    *time.Time `json:"dateSent"`
+}
+
+type ExportSuppression struct {
+	Reason         string    `json:"reason"`
+	Detail         string    `json:"detail"`
+	DateSuppressed time.Time `json:"dateSuppressed"`
+}
+
+type ExportReminder struct {
+	Expiration time.Time `json:"expiration"`
+	Days       int       `json:"days"`
+	DateSent   time.Time `json:"dateSent"`
+}
+
+type ExportUpload struct {
+	S3Id           string     `json:"s3Id"`
+	Team           string     `json:"team"`
+	FileType       string     `json:"fileType"`
+	Description    string     `json:"description"`
+	DateUploaded   time.Time  `json:"dateUploaded"`
+	DateCleaned    *time.Time `json:"dateCleaned"`
+	AprimoRecordId string     `json:"aprimoRecordId"`
+	AprimoRecorded *time.Time `json:"aprimoRecordDate"`
+	AprimoUploaded *time.Time `json:"aprimoUploadDate"`
+}
+
+// GuestExport is the full set of data held about a single guest user.
+// Password hashes and salts are deliberately excluded, as are the contents
+// of the emails sent to the guest, which may include temporary passwords.
+type GuestExport struct {
+	GeneratedAt     time.Time            `json:"generatedAt"`
+	Profile         ExportProfile        `json:"profile"`
+	LoginState      ExportLoginState     `json:"loginState"`
+	LoginHistory    []ExportLoginAttempt `json:"loginHistory"`
+	Invites         []ExportInvite       `json:"invites"`
+	PasswordChanges []time.Time          `json:"passwordChanges"`
+	Uploads         []ExportUpload       `json:"uploads"`
+	Emails          []ExportEmail        `json:"emails"`
+	Suppression     *ExportSuppression   `json:"suppression"`
+	Reminders       []ExportReminder     `json:"reminders"`
+}
+
+// nullTimePointer converts a nullable timestamp into a pointer that marshals to null.
+func nullTimePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// retrieveExportInvites collects the full invite history for a guest, most recent first.
+func retrieveExportInvites(pool *sql.DB, email string) ([]ExportInvite, error) {
+	invites := []ExportInvite{}
+
+	query :=
+		`SELECT COALESCE( inviter, '' ), COALESCE( proposer, '' ), pending, date_invited,
+		 expiration, first_login, password_reset, rejected_at, COALESCE( rejected_by, '' ),
+		 COALESCE( reject_reason, '' )
+		 FROM invites WHERE invitee = $1 ORDER BY date_invited DESC`
+	rows, err := pool.Query(query, email)
+
+	if err != nil {
+		logs.LogError(err, "Export Invites Query Error")
+		return invites, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var invite ExportInvite
+		var rejected sql.NullTime
+
+		err = rows.Scan(
+			&invite.Inviter,
+			&invite.Proposer,
+			&invite.Pending,
+			&invite.DateInvited,
+			&invite.Expiration,
+			&invite.FirstLogin,
+			&invite.PasswordReset,
+			&rejected,
+			&invite.RejectedBy,
+			&invite.RejectReason,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Export Invites Scan Error")
+			return invites, err
+		}
+
+		invite.RejectedAt = nullTimePointer(rejected)
+
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// retrievePasswordChanges collects the dates on which a guest changed their password.
+func retrievePasswordChanges(pool *sql.DB, email string) ([]time.Time, error) {
+	changes := []time.Time{}
+
+	query := `SELECT creation_date FROM password_history WHERE user_id = $1 ORDER BY creation_date DESC`
+	rows, err := pool.Query(query, email)
+
+	if err != nil {
+		logs.LogError(err, "Export Password History Query Error")
+		return changes, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var changed time.Time
+
+		if err = rows.Scan(&changed); err != nil {
+			logs.LogError(err, "Export Password History Scan Error")
+			return changes, err
+		}
+
+		changes = append(changes, changed)
+	}
+
+	return changes, rows.Err()
+}
+
+// retrieveExportUploads collects every upload made by a guest along with its Aprimo data.
+func retrieveExportUploads(pool *sql.DB, email string) ([]ExportUpload, error) {
+	uploads := []ExportUpload{}
+
+	query :=
+		`SELECT s3_id, team_id, file_type, description, date_uploaded, clean_dt,
+		 COALESCE( aprimo_record_id, '' ), aprimo_record_dt, aprimo_upload_dt
+		 FROM uploads
+		 WHERE user_id IN ( SELECT user_id FROM all_users WHERE guest_id = $1 )
+		 ORDER BY date_uploaded DESC`
+	rows, err := pool.Query(query, email)
+
+	if err != nil {
+		logs.LogError(err, "Export Uploads Query Error")
+		return uploads, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload ExportUpload
+		var cleaned, recorded, uploaded sql.NullTime
+
+		err = rows.Scan(
+			&upload.S3Id,
+			&upload.Team,
+			&upload.FileType,
+			&upload.Description,
+			&upload.DateUploaded,
+			&cleaned,
+			&upload.AprimoRecordId,
+			&recorded,
+			&uploaded,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Export Uploads Scan Error")
+			return uploads, err
+		}
+
+		upload.DateCleaned = nullTimePointer(cleaned)
+		upload.AprimoRecorded = nullTimePointer(recorded)
+		upload.AprimoUploaded = nullTimePointer(uploaded)
+
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
+
+// retrieveLoginHistory collects every recorded login attempt for a guest, most recent first.
+func retrieveLoginHistory(pool *sql.DB, email string) ([]ExportLoginAttempt, error) {
+	history := []ExportLoginAttempt{}
+
+	query :=
+		`SELECT attempted_at, success, COALESCE( reason, '' ), COALESCE( source_ip, '' ),
+		 COALESCE( user_agent, '' )
+		 FROM login_attempts WHERE email = $1 ORDER BY attempted_at DESC`
+	rows, err := pool.Query(query, email)
+
+	if err != nil {
+		logs.LogError(err, "Export Login History Query Error")
+		return history, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt ExportLoginAttempt
+
+		err = rows.Scan(
+			&attempt.AttemptedAt,
+			&attempt.Success,
+			&attempt.Reason,
+			&attempt.SourceIp,
+			&attempt.UserAgent,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Export Login History Scan Error")
+			return history, err
+		}
+
+		history = append(history, attempt)
+	}
+
+	return history, rows.Err()
+}
+
+// retrieveExportEmails collects the emails queued for a guest, most recent first.
+// Only the delivery details are included, since the content may hold credentials.
+func retrieveExportEmails(pool *sql.DB, email string) ([]ExportEmail, error) {
+	emails := []ExportEmail{}
+
+	query :=
+		`SELECT template, status, attempts, date_created, date_sent
+		 FROM email_outbox WHERE recipient = $1 ORDER BY date_created DESC`
+	rows, err := pool.Query(query, email)
+
+	if err != nil {
+		logs.LogError(err, "Export Emails Query Error")
+		return emails, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var sent ExportEmail
+		var dateSent sql.NullTime
+
+		err = rows.Scan(
+			&sent.Template,
+			&sent.Status,
+			&sent.Attempts,
+			&sent.DateCreated,
+			&dateSent,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Export Emails Scan Error")
+			return emails, err
+		}
+
+		sent.DateSent = nullTimePointer(dateSent)
+
+		emails = append(emails, sent)
+	}
+
+	return emails, rows.Err()
+}
+
+// retrieveExportSuppression retrieves the reason that emails to a guest are suppressed,
+// if they are. A nil suppression indicates that emails are delivered as normal.
+func retrieveExportSuppression(pool *sql.DB, email string) (*ExportSuppression, error) {
+	var suppression ExportSuppression
+
+	query :=
+		`SELECT reason, COALESCE( detail, '' ), date_suppressed
+		 FROM email_suppressions WHERE email = $1`
+	err := pool.QueryRow(query, email).Scan(
+		&suppression.Reason,
+		&suppression.Detail,
+		&suppression.DateSuppressed,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		logs.LogError(err, "Export Suppression Query Error")
+		return nil, err
+	}
+
+	return &suppression, nil
+}
+
+// retrieveExportReminders collects the expiration reminders sent to a guest, most recent first.
+func retrieveExportReminders(pool *sql.DB, email string) ([]ExportReminder, error) {
+	reminders := []ExportReminder{}
+
+	query :=
+		`SELECT expiration, days, date_sent
+		 FROM expiration_reminders WHERE invitee = $1 ORDER BY date_sent DESC`
+	rows, err := pool.Query(query, email)
+
+	if err != nil {
+		logs.LogError(err, "Export Reminders Query Error")
+		return reminders, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var reminder ExportReminder
+
+		if err = rows.Scan(&reminder.Expiration, &reminder.Days, &reminder.DateSent); err != nil {
+			logs.LogError(err, "Export Reminders Scan Error")
+			return reminders, err
+		}
+
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// RetrieveGuestExport opens a database connection and gathers everything held about a
+// single guest user in order to respond to a subject access request. Archived guests
+// are included, since their data is still held.
+func RetrieveGuestExport(email string) (GuestExport, error) {
+	export := GuestExport{GeneratedAt: time.Now()}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	var archived, lastAttempt sql.NullTime
+	var attempts sql.NullInt32
+
+	query :=
+		`SELECT email, first_name, last_name, role, team, date_created, date_modified,
+		 locale, archived_at, locked, login_attempt, login_date
+		 FROM guests WHERE email = $1`
+	err := pool.QueryRow(query, email).Scan(
+		&export.Profile.Email,
+		&export.Profile.FirstName,
+		&export.Profile.LastName,
+		&export.Profile.Role,
+		&export.Profile.Team,
+		&export.Profile.DateCreated,
+		&export.Profile.DateModified,
+		&export.Profile.Locale,
+		&archived,
+		&export.LoginState.Locked,
+		&attempts,
+		&lastAttempt,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Export Guest Profile Query Error")
+		return export, err
+	}
+
+	export.Profile.ArchivedAt = nullTimePointer(archived)
+	export.LoginState.LoginAttempts = int(attempts.Int32)
+	export.LoginState.LastAttempt = nullTimePointer(lastAttempt)
+
+	export.LoginHistory, err = retrieveLoginHistory(pool, email)
+
+	if err != nil {
+		return export, err
+	}
+
+	export.Invites, err = retrieveExportInvites(pool, email)
+
+	if err != nil {
+		return export, err
+	}
+
+	// The first login flag is tracked against the most recent invite.
+	if len(export.Invites) > 0 {
+		export.LoginState.FirstLogin = export.Invites[0].FirstLogin
+	}
+
+	export.PasswordChanges, err = retrievePasswordChanges(pool, email)
+
+	if err != nil {
+		return export, err
+	}
+
+	export.Uploads, err = retrieveExportUploads(pool, email)
+
+	if err != nil {
+		return export, err
+	}
+
+	export.Emails, err = retrieveExportEmails(pool, email)
+
+	if err != nil {
+		return export, err
+	}
+
+	export.Suppression, err = retrieveExportSuppression(pool, email)
+
+	if err != nil {
+		return export, err
+	}
+
+	export.Reminders, err = retrieveExportReminders(pool, email)
+
+	return export, err
+}
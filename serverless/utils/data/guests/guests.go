@@ -2,11 +2,17 @@ package guests
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/rs/xid"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/invites"
+	"github.com/IIP-Design/commons-gateway/utils/data/logins"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 	"github.com/IIP-Design/commons-gateway/utils/security/hashing"
 )
@@ -19,6 +25,11 @@ type InviteRecord struct {
 	Expired       bool   `json:"expired"`
 	Inviter       string `json:"inviter"`
 	PasswordReset bool   `json:"passwordReset"`
+	// RejectedAt is set if the invitation was proposed and an admin declined it, in
+	// which case it is no longer pending.
+	RejectedAt   *time.Time `json:"rejectedAt,omitempty"`
+	RejectedBy   string     `json:"rejectedBy,omitempty"`
+	RejectReason string     `json:"rejectReason,omitempty"`
 }
 
 type GuestData struct {
@@ -27,11 +38,35 @@ type GuestData struct {
 	LastName  string `json:"familyName"`
 	Role      string `json:"role"`
 	Team      string `json:"team"`
+	Locale    string `json:"locale"`
 }
 
 type GuestDetails struct {
 	GuestData
-	Invites []InviteRecord `json:"invites"`
+	Invites      []InviteRecord   `json:"invites"`
+	LastLogin    *time.Time       `json:"lastLogin,omitempty"`
+	LoginHistory []logins.Attempt `json:"loginHistory,omitempty"`
+	Emails       []outbox.Email   `json:"emails,omitempty"`
+	// EmailSuppressedAt is set once an email to the guest bounces permanently or
+	// the guest marks one as spam, after which no further emails are sent to them.
+	EmailSuppressedAt *time.Time `json:"emailSuppressedAt,omitempty"`
+}
+
+type ErasureReceipt struct {
+	Id                     string    `json:"id"`
+	Pseudonym              string    `json:"pseudonym"`
+	SubjectHash            string    `json:"subjectHash"`
+	ErasedBy               string    `json:"erasedBy"`
+	Reason                 string    `json:"reason"`
+	DateErased             time.Time `json:"dateErased"`
+	InvitesPseudonymized   int64     `json:"invitesPseudonymized"`
+	PasswordHistoryDeleted int64     `json:"passwordHistoryDeleted"`
+	MfaDeleted             int64     `json:"mfaDeleted"`
+	LoginAttemptsDeleted   int64     `json:"loginAttemptsDeleted"`
+	EmailsDeleted          int64     `json:"emailsDeleted"`
+	JobRowsPseudonymized   int64     `json:"jobRowsPseudonymized"`
+	UploadsRetained        int64     `json:"uploadsRetained"`
+	AuditKeyDestroyed      bool      `json:"auditKeyDestroyed"`
 }
 
 // RetrieveGuest opens a database connection and retrieves the information for a single user.
@@ -41,17 +76,18 @@ func RetrieveGuest(email string) (GuestDetails, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	query := `SELECT email, first_name, last_name, role, team FROM guests WHERE email = $1`
-	err := pool.QueryRow(query, email).Scan(&guest.Email, &guest.FirstName, &guest.LastName, &guest.Role, &guest.Team)
+	query := `SELECT email, first_name, last_name, role, team, locale, email_suppressed_at FROM guests WHERE email = $1 AND archived_at IS NULL`
+	err := pool.QueryRow(query, email).Scan(&guest.Email, &guest.FirstName, &guest.LastName, &guest.Role, &guest.Team, &guest.Locale, &guest.EmailSuppressedAt)
 
 	if err != nil {
 		logs.LogError(err, "Retrieve Guest Query Error")
 		return guest, err
 	}
 
-	query = `SELECT pending, date_invited, expiration,
+	query = `SELECT pending AND rejected_at IS NULL, date_invited, expiration,
 	  expiration < NOW() AS expired, password_reset,
-	  COALESCE( inviter, '' ), COALESCE( proposer, '' )
+	  COALESCE( inviter, '' ), COALESCE( proposer, '' ),
+	  rejected_at, COALESCE( rejected_by, '' ), COALESCE( reject_reason, '' )
 		FROM invites
 		WHERE invitee = $1 ORDER BY date_invited DESC`
 	rows, err := pool.Query(query, email)
@@ -71,6 +107,9 @@ func RetrieveGuest(email string) (GuestDetails, error) {
 		var expiration time.Time
 		var expired bool
 		var passwordReset bool
+		var rejectedAt *time.Time
+		var rejectedBy string
+		var rejectReason string
 
 		if err := rows.Scan(
 			&pending,
@@ -80,6 +119,9 @@ func RetrieveGuest(email string) (GuestDetails, error) {
 			&passwordReset,
 			&inviter,
 			&proposer,
+			&rejectedAt,
+			&rejectedBy,
+			&rejectReason,
 		); err != nil {
 			logs.LogError(err, "Scan Guests Query Error")
 			return guest, err
@@ -127,6 +169,9 @@ func RetrieveGuest(email string) (GuestDetails, error) {
 			PasswordReset: passwordReset,
 			Pending:       pending,
 			Proposer:      proposerName,
+			RejectedAt:    rejectedAt,
+			RejectedBy:    rejectedBy,
+			RejectReason:  rejectReason,
 		}
 
 		guest.Invites = append(guest.Invites, invite)
@@ -152,25 +197,72 @@ func RetrieveGuestExpiration(email string) (time.Time, error) {
 	return expires, err
 }
 
-// RetrieveGuests opens a database connection and retrieves the full list of guest users.
-func RetrieveGuests(team string, role string) ([]data.GuestUser, error) {
+// RetrieveInviter looks up a guest and the user who most recently invited or proposed
+// them, as part of the provided transaction. It returns false if either is unknown, or if
+// emails to the inviter are suppressed.
+func RetrieveInviter(tx *sql.Tx, address string) (data.User, data.User, bool, error) {
+	var guest data.User
+	var inviter data.User
+
+	query :=
+		`SELECT g.email, g.first_name, g.last_name, g.role, g.team, g.locale, COALESCE( i.inviter, i.proposer, '' )
+		 FROM guests g JOIN invites i ON i.invitee = g.email
+		 WHERE LOWER( g.email ) = LOWER( $1 ) ORDER BY i.date_invited DESC LIMIT 1;`
+	err := tx.QueryRow(query, address).Scan(
+		&guest.Email,
+		&guest.NameFirst,
+		&guest.NameLast,
+		&guest.Role,
+		&guest.Team,
+		&guest.Locale,
+		&inviter.Email,
+	)
+
+	if err == sql.ErrNoRows || (err == nil && inviter.Email == "") {
+		return guest, inviter, false, nil
+	} else if err != nil {
+		logs.LogError(err, "Retrieve Invited Guest Query Error")
+		return guest, inviter, false, err
+	}
+
+	query =
+		`SELECT first_name, last_name, role::TEXT, team, '' FROM admins WHERE email = $1 AND email_suppressed_at IS NULL
+		 UNION ALL
+		 SELECT first_name, last_name, role::TEXT, team, locale FROM guests WHERE email = $1 AND email_suppressed_at IS NULL
+		 LIMIT 1;`
+	err = tx.QueryRow(query, inviter.Email).Scan(&inviter.NameFirst, &inviter.NameLast, &inviter.Role, &inviter.Team, &inviter.Locale)
+
+	if err == sql.ErrNoRows {
+		return guest, inviter, false, nil
+	} else if err != nil {
+		logs.LogError(err, "Retrieve Inviter Query Error")
+		return guest, inviter, false, err
+	}
+
+	return guest, inviter, true, nil
+}
+
+// RetrieveGuests opens a database connection and retrieves the full list of guest users,
+// along with the time of each guest's last successful login. The list may be narrowed to
+// a single team or role, or to the guests who have never successfully logged in.
+func RetrieveGuests(team string, role string, neverUsed bool) ([]data.GuestUser, error) {
 	var guests []data.GuestUser
-	var err error
-	var query string
-	var rows *sql.Rows
 
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	if team == "" {
-		query = `SELECT email, first_name, last_name, role, team, pending, expiration FROM guest_auth_data ORDER BY first_name;`
-		rows, err = pool.Query(query)
-	} else {
-		query =
-			`SELECT email, first_name, last_name, role, team, pending, expiration
-			 FROM guest_auth_data WHERE team = $1 ORDER BY first_name;`
-		rows, err = pool.Query(query, team)
-	}
+	query :=
+		`SELECT email, first_name, last_name, role, team, pending AND rejected_at IS NULL,
+		 rejected_at IS NOT NULL, expiration, logins.last_login
+		 FROM guest_auth_data
+		 LEFT JOIN LATERAL (
+		   SELECT MAX( attempted_at ) AS last_login FROM login_attempts
+		   WHERE login_attempts.email = guest_auth_data.email AND success
+		 ) logins ON TRUE
+		 WHERE archived_at IS NULL AND ( $1 = '' OR team = $1 )
+		 AND ( NOT $2 OR logins.last_login IS NULL )
+		 ORDER BY first_name;`
+	rows, err := pool.Query(query, team, neverUsed)
 
 	if err != nil {
 		logs.LogError(err, "Get Guests Query Error")
@@ -181,11 +273,17 @@ func RetrieveGuests(team string, role string) ([]data.GuestUser, error) {
 
 	for rows.Next() {
 		var guest data.GuestUser
-		if err := rows.Scan(&guest.Email, &guest.NameFirst, &guest.NameLast, &guest.Role, &guest.Team, &guest.Pending, &guest.Expires); err != nil {
+		var lastLogin sql.NullTime
+
+		if err := rows.Scan(&guest.Email, &guest.NameFirst, &guest.NameLast, &guest.Role, &guest.Team, &guest.Pending, &guest.Rejected, &guest.Expires, &lastLogin); err != nil {
 			logs.LogError(err, "Get Guests Query Error")
 			return guests, err
 		}
 
+		if lastLogin.Valid {
+			guest.LastLogin = &lastLogin.Time
+		}
+
 		if role == "" || role == guest.Role {
 			guests = append(guests, guest)
 		}
@@ -212,15 +310,15 @@ func RetrievePendingInvites(team string) ([]map[string]string, error) {
 	if team == "" {
 		query = `SELECT email, first_name, last_name, role, team, expiration, date_invited, proposer
 			 FROM guests LEFT JOIN invites ON guests.email=invites.invitee
-			 WHERE inviter IS NULL AND proposer IS NOT NULL AND pending=TRUE AND expiration >= NOW()
-			 ORDER BY first_name;`
+			 WHERE inviter IS NULL AND proposer IS NOT NULL AND pending=TRUE AND rejected_at IS NULL AND expiration >= NOW()
+			 AND archived_at IS NULL ORDER BY first_name;`
 		rows, err = pool.Query(query)
 	} else {
 		query =
 			`SELECT email, first_name, last_name, role, team, expiration, date_invited, proposer
 			 FROM guests LEFT JOIN invites ON guests.email=invites.invitee
-			 WHERE inviter IS NULL AND proposer IS NOT NULL AND pending=TRUE AND expiration >= NOW() AND team = $1
-			 ORDER BY first_name;`
+			 WHERE inviter IS NULL AND proposer IS NOT NULL AND pending=TRUE AND rejected_at IS NULL AND expiration >= NOW()
+			 AND team = $1 AND archived_at IS NULL ORDER BY first_name;`
 		rows, err = pool.Query(query, team)
 	}
 
@@ -269,8 +367,8 @@ func RetrieveUploaders(team string) ([]map[string]any, error) {
 
 	query :=
 		`SELECT email, first_name, last_name, role, team, expiration, date_invited,
-		 proposer, inviter, pending FROM guest_auth_data
-		 WHERE team = $1 AND role = 'guest' ORDER BY first_name;`
+		 proposer, inviter, pending AND rejected_at IS NULL, rejected_at IS NOT NULL FROM guest_auth_data
+		 WHERE team = $1 AND role = 'guest' AND archived_at IS NULL ORDER BY first_name;`
 	rows, err := pool.Query(query, team)
 
 	if err != nil {
@@ -293,6 +391,7 @@ func RetrieveUploaders(team string) ([]map[string]any, error) {
 			&guest.Proposer,
 			&guest.Inviter,
 			&guest.Pending,
+			&guest.Rejected,
 		)
 
 		if err != nil {
@@ -311,6 +410,7 @@ func RetrieveUploaders(team string) ([]map[string]any, error) {
 			"proposer":    guest.Proposer.String,
 			"inviter":     guest.Inviter.String,
 			"pending":     guest.Pending,
+			"rejected":    guest.Rejected,
 		}
 
 		uploaders = append(uploaders, guestData)
@@ -325,25 +425,26 @@ func RetrieveUploaders(team string) ([]map[string]any, error) {
 }
 
 // UpdateGuest opens a database connection and updates a given
-// guest user with the provided information.
+// guest user with the provided information, recording the provided audit event.
 // TODO? - Allow for changes to user email? If so we may need
 // to add an id field and set that as the primary key on a guest.
-func UpdateGuest(guest data.GuestUser) error {
-	pool := data.ConnectToDB()
-	defer pool.Close()
+func UpdateGuest(guest data.GuestUser, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "guests", Column: "email", Key: guest.Email}}
 
-	currentTime := time.Now()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	query :=
-		`UPDATE guests SET first_name = $1, last_name = $2, role = $3,
-		 team = $4, date_modified = $5 WHERE email = $6`
-	_, err := pool.Exec(query, guest.NameFirst, guest.NameLast, guest.Role, guest.Team, currentTime, guest.Email)
+		query :=
+			`UPDATE guests SET first_name = $1, last_name = $2, role = $3,
+			 team = $4, date_modified = $5, locale = COALESCE( NULLIF( $7, '' ), locale ) WHERE email = $6`
+		_, err := tx.Exec(query, guest.NameFirst, guest.NameLast, guest.Role, guest.Team, currentTime, guest.Email, guest.Locale)
 
-	if err != nil {
-		logs.LogError(err, "Update Guest Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Update Guest Query Error")
+		}
 
-	return err
+		return err
+	})
 }
 
 func shouldResetPassword(dateInvited string, nextExpiration time.Time, passwordWasReset bool) (bool, error) {
@@ -369,58 +470,392 @@ func shouldResetPassword(dateInvited string, nextExpiration time.Time, passwordW
 	return requireReset, err
 }
 
-func Reauthorize(guest data.GuestReauth, clientIsGuestAdmin bool) (string, int, error) {
+// Reauthorize opens a database connection and records a new invitation for a guest user
+// whose access has expired, along with the provided audit event. A new temporary password
+// is returned if the guest is required to reset their password, in which case notify is
+// called with the password before the transaction is committed, unless it is nil.
+func Reauthorize(guest data.GuestReauth, clientIsGuestAdmin bool, notify outbox.Notify, event audit.Event) (string, int, error) {
 	var pass string
+	status := 200
+
+	subjects := []audit.Subject{{Table: "recent_invites", Column: "invitee", Key: guest.Email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		var dateInvited string
+		var pending bool
+		var active bool
+		var salt string
+		var passHash string
+		var passwordWasReset bool
+		firstLogin := false
+
+		query :=
+			`SELECT date_invited, pending AND rejected_at IS NULL, expiration >= NOW() AND rejected_at IS NULL AS active,
+			 salt, pass_hash, password_reset
+			 FROM invites WHERE invitee = $1 ORDER BY date_invited DESC LIMIT 1;`
+		err := tx.QueryRow(query, guest.Email).Scan(&dateInvited, &pending, &active, &salt, &passHash, &passwordWasReset)
+
+		if err != nil {
+			status = 500
+			return err
+		} else if pending || active {
+			status = 409
+			return audit.ErrNoChange
+		}
+
+		resetPassword, err := shouldResetPassword(dateInvited, guest.Expires, passwordWasReset)
+
+		if err != nil {
+			status = 500
+			return err
+		}
+
+		if resetPassword {
+			pass, salt = hashing.GenerateCredentials()
+			passHash = hashing.GenerateHash(pass, salt)
+			firstLogin = true
+		}
+
+		err = invites.SaveInvite(tx, guest.Admin, guest.Email, guest.Expires, passHash, salt, clientIsGuestAdmin, resetPassword, firstLogin)
+
+		if err == nil && resetPassword && notify != nil {
+			err = notify(tx, pass)
+		}
+
+		if err != nil {
+			status = 500
+		}
+
+		return err
+	})
+
+	if errors.Is(err, audit.ErrNoChange) {
+		err = nil
+	}
+
+	return pass, status, err
+}
+
+// AcceptGuest opens a database connection and approves a proposed invitation, assigning
+// the guest fresh credentials and recording the provided audit event. If notify is not
+// nil, it is called with the guest's temporary password before the approval is committed.
+// It returns audit.ErrNoChange if the guest has no proposed invitation awaiting approval,
+// such as when it has been rejected.
+func AcceptGuest(guest data.AcceptInvite, notify outbox.Notify, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "recent_invites", Column: "invitee", Key: guest.Invitee}}
+
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		pass, salt := hashing.GenerateCredentials()
+		hash := hashing.GenerateHash(pass, salt)
+
+		query :=
+			`UPDATE invites SET inviter = $1, pass_hash = $2, salt = $3, pending = FALSE
+			 WHERE invitee = $4 AND pending AND rejected_at IS NULL`
+		err := audit.ExecChange(tx, "Update Invite Query Error", query, guest.Inviter, hash, salt, guest.Invitee)
+
+		if err != nil {
+			return err
+		}
+
+		if notify != nil {
+			return notify(tx, pass)
+		}
+
+		return nil
+	})
+}
+
+// RejectNotify is called within the transaction which rejects a proposed invitation, with
+// the guest and the guest admin who proposed them, so that the proposer learns of it.
+type RejectNotify func(tx *sql.Tx, guest data.User, proposer data.User) error
+
+// RejectGuest opens a database connection and declines a guest's proposed invitation,
+// recording the admin who rejected it, why, and when, along with the provided audit
+// event. The invite remains pending so that the guest cannot log in, but is no longer
+// awaiting approval, and the guest may be proposed again. If notify is not nil, it is
+// called with the guest and their proposer before the rejection is committed. It returns
+// false if the guest has no proposed invitation awaiting approval.
+func RejectGuest(email string, rejectedBy string, reason string, notify RejectNotify, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "recent_invites", Column: "invitee", Key: email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		var guest data.User
+		var proposer data.User
+
+		query :=
+			`SELECT g.email, g.first_name, g.last_name, g.role, g.team, g.locale,
+			 COALESCE( p.email, '' ), COALESCE( p.first_name, '' ), COALESCE( p.last_name, '' ),
+			 COALESCE( p.team, '' ), COALESCE( p.locale, '' )
+			 FROM invites i
+			 JOIN guests g ON i.invitee = g.email
+			 LEFT JOIN guests p ON i.proposer = p.email
+			 WHERE i.invitee = $1 AND i.pending AND i.inviter IS NULL AND i.rejected_at IS NULL
+			 ORDER BY i.date_invited DESC LIMIT 1 FOR UPDATE OF i;`
+		err := tx.QueryRow(query, email).Scan(
+			&guest.Email,
+			&guest.NameFirst,
+			&guest.NameLast,
+			&guest.Role,
+			&guest.Team,
+			&guest.Locale,
+			&proposer.Email,
+			&proposer.NameFirst,
+			&proposer.NameLast,
+			&proposer.Team,
+			&proposer.Locale,
+		)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return audit.ErrNoChange
+		} else if err != nil {
+			logs.LogError(err, "Retrieve Proposed Invite Query Error")
+			return err
+		}
+
+		query =
+			`UPDATE invites SET rejected_at = $1, rejected_by = $2, reject_reason = NULLIF( $3, '' )
+			 WHERE invitee = $4 AND pending AND inviter IS NULL AND rejected_at IS NULL`
+		err = audit.ExecChange(tx, "Reject Invite Query Error", query, time.Now(), rejectedBy, reason, email)
+
+		if err != nil || notify == nil || proposer.Email == "" {
+			return err
+		}
+
+		return notify(tx, guest, proposer)
+	})
+
+	return audit.Changed(err)
+}
+
+// ArchiveGuest opens a database connection and soft deletes the given guest user,
+// recording who archived them and why along with the provided audit event. It returns
+// false if the guest had already been archived.
+func ArchiveGuest(email string, archivedBy string, reason string, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "guests", Column: "email", Key: email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`UPDATE guests SET archived_at = $1, archived_by = $2, archive_reason = $3, date_modified = $1
+			 WHERE email = $4 AND archived_at IS NULL`
+
+		return audit.ExecChange(tx, "Archive Guest Query Error", query, currentTime, archivedBy, reason, email)
+	})
+
+	return audit.Changed(err)
+}
+
+// RestoreGuest opens a database connection and clears the archival data from the given
+// guest user, recording the provided audit event. It returns false if the guest was not archived.
+func RestoreGuest(email string, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "guests", Column: "email", Key: email}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`UPDATE guests SET archived_at = NULL, archived_by = NULL, archive_reason = NULL, date_modified = $1
+			 WHERE email = $2 AND archived_at IS NOT NULL`
+
+		return audit.ExecChange(tx, "Restore Guest Query Error", query, currentTime, email)
+	})
+
+	return audit.Changed(err)
+}
+
+// EraseGuest opens a database connection and irreversibly removes the personal data of
+// the given guest user. The guest's email and name are replaced with a pseudonym, which
+// cascades to the invites and all_users tables. Their password history, MFA codes, and
+// login history are deleted, whereas their uploads are retained for auditing under the
+// pseudonym. All changes are made within a single transaction and a receipt of the
+// erasure is saved and returned.
+//
+// The audit log is append-only, so the guest's earlier events are kept. They are recorded
+// under a token derived from the guest's audit key, with their changes encrypted by that
+// key, which is destroyed here so the events can no longer be read or linked to the guest.
+// The receipt identifies the guest by a hash keyed with the audit signing key, and the
+// erasure itself is recorded against the pseudonym.
+func EraseGuest(email string, erasedBy string, reason string, event audit.Event) (ErasureReceipt, error) {
+	receipt := ErasureReceipt{
+		Id:         xid.New().String(),
+		Pseudonym:  fmt.Sprintf("erased-%s@erased.invalid", xid.New().String()),
+		ErasedBy:   erasedBy,
+		Reason:     reason,
+		DateErased: time.Now(),
+	}
+
+	subjectHash, err := audit.SubjectHash(email)
+
+	if err != nil {
+		logs.LogError(err, "Hash Erasure Subject Error")
+		return receipt, err
+	}
+
+	receipt.SubjectHash = subjectHash
 
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	var dateInvited string
-	var pending bool
-	var active bool
-	var salt string
-	var passHash string
-	var passwordWasReset bool
-	firstLogin := false
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Erasure Transaction Error")
+		return receipt, err
+	}
+
+	// Rolling back is a no-op once the transaction has been committed.
+	defer tx.Rollback()
 
 	query :=
-		`SELECT date_invited, pending, expiration >= NOW() AS active, salt, pass_hash, password_reset
-		 FROM invites WHERE invitee = $1 ORDER BY date_invited DESC LIMIT 1;`
-	err := pool.QueryRow(query, guest.Email).Scan(&dateInvited, &pending, &active, &salt, &passHash, &passwordWasReset)
+		`SELECT COUNT(*) FROM uploads
+		 WHERE user_id IN ( SELECT user_id FROM all_users WHERE guest_id = $1 )`
+	err = tx.QueryRow(query, email).Scan(&receipt.UploadsRetained)
 
 	if err != nil {
-		return pass, 500, err
-	} else if pending || active {
-		return pass, 409, err
+		logs.LogError(err, "Count Erased Guest Uploads Query Error")
+		return receipt, err
 	}
 
-	resetPassword, err := shouldResetPassword(dateInvited, guest.Expires, passwordWasReset)
+	result, err := tx.Exec(`DELETE FROM password_history WHERE user_id = $1`, email)
 
 	if err != nil {
-		return pass, 500, err
+		logs.LogError(err, "Delete Password History Query Error")
+		return receipt, err
 	}
 
-	if resetPassword {
-		pass, salt = hashing.GenerateCredentials()
-		passHash = hashing.GenerateHash(pass, salt)
-		firstLogin = true
+	receipt.PasswordHistoryDeleted, _ = result.RowsAffected()
+
+	result, err = tx.Exec(`DELETE FROM mfa WHERE user_id = $1`, email)
+
+	if err != nil {
+		logs.LogError(err, "Delete MFA Requests Query Error")
+		return receipt, err
 	}
 
-	err = invites.SaveInvite(guest.Admin, guest.Email, guest.Expires, passHash, salt, clientIsGuestAdmin, resetPassword, firstLogin)
+	receipt.MfaDeleted, _ = result.RowsAffected()
 
-	return pass, 200, err
-}
+	result, err = tx.Exec(`DELETE FROM login_attempts WHERE email = $1`, email)
 
-func AcceptGuest(guest data.AcceptInvite, hash string, salt string) error {
-	pool := data.ConnectToDB()
-	defer pool.Close()
+	if err != nil {
+		logs.LogError(err, "Delete Login History Query Error")
+		return receipt, err
+	}
+
+	receipt.LoginAttemptsDeleted, _ = result.RowsAffected()
+
+	result, err = tx.Exec(`DELETE FROM email_outbox WHERE recipient = $1`, email)
+
+	if err != nil {
+		logs.LogError(err, "Delete Outbox Emails Query Error")
+		return receipt, err
+	}
+
+	receipt.EmailsDeleted, _ = result.RowsAffected()
+
+	_, err = tx.Exec(`DELETE FROM email_suppressions WHERE email = LOWER( $1 )`, email)
+
+	if err != nil {
+		logs.LogError(err, "Delete Email Suppression Query Error")
+		return receipt, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM expiration_reminders WHERE invitee = $1`, email)
+
+	if err != nil {
+		logs.LogError(err, "Delete Expiration Reminders Query Error")
+		return receipt, err
+	}
+
+	// Bulk invitation jobs keep their rows so that their totals still add up, but the
+	// guest's address is replaced with the pseudonym.
+	query = `UPDATE invite_job_rows SET email = $1 WHERE LOWER( email ) = LOWER( $2 )`
+	result, err = tx.Exec(query, receipt.Pseudonym, email)
+
+	if err != nil {
+		logs.LogError(err, "Pseudonymize Invite Job Rows Query Error")
+		return receipt, err
+	}
+
+	receipt.JobRowsPseudonymized, _ = result.RowsAffected()
+
+	receipt.AuditKeyDestroyed, err = audit.ForgetSubject(tx, email)
+
+	if err != nil {
+		return receipt, err
+	}
+
+	// Updating the primary key cascades the pseudonym to the invites and all_users tables.
+	// Erased guests are archived so that they no longer appear in any guest listings.
+	query =
+		`UPDATE guests SET email = $1, first_name = 'Erased', last_name = 'User',
+		 locked = false, login_attempt = 0, login_date = NULL, date_modified = $2,
+		 archived_at = COALESCE( archived_at, $2 ), archived_by = COALESCE( archived_by, $3 ),
+		 archive_reason = 'erased'
+		 WHERE email = $4`
+	result, err = tx.Exec(query, receipt.Pseudonym, receipt.DateErased, erasedBy, email)
+
+	if err != nil {
+		logs.LogError(err, "Pseudonymize Guest Query Error")
+		return receipt, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return receipt, sql.ErrNoRows
+	}
+
+	// Clear the stored credentials so that the pseudonymized account can never be logged into.
+	result, err = tx.Exec(`UPDATE invites SET pass_hash = '', salt = '' WHERE invitee = $1`, receipt.Pseudonym)
+
+	if err != nil {
+		logs.LogError(err, "Clear Erased Guest Credentials Query Error")
+		return receipt, err
+	}
+
+	receipt.InvitesPseudonymized, _ = result.RowsAffected()
+
+	query =
+		`INSERT INTO erasures( id, pseudonym, subject_hash, erased_by, reason, date_erased,
+		 invites_pseudonymized, password_history_deleted, mfa_deleted, uploads_retained, login_attempts_deleted,
+		 emails_deleted, job_rows_pseudonymized, audit_key_destroyed )
+		 VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14 )`
+	_, err = tx.Exec(
+		query,
+		receipt.Id,
+		receipt.Pseudonym,
+		receipt.SubjectHash,
+		receipt.ErasedBy,
+		receipt.Reason,
+		receipt.DateErased,
+		receipt.InvitesPseudonymized,
+		receipt.PasswordHistoryDeleted,
+		receipt.MfaDeleted,
+		receipt.UploadsRetained,
+		receipt.LoginAttemptsDeleted,
+		receipt.EmailsDeleted,
+		receipt.JobRowsPseudonymized,
+		receipt.AuditKeyDestroyed,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Save Erasure Receipt Query Error")
+		return receipt, err
+	}
+
+	event.Target = receipt.Pseudonym
+	event.Changes = map[string]audit.Change{
+		"erasures.id": {Before: nil, After: receipt.Id},
+	}
+
+	if err = audit.Record(tx, event); err != nil {
+		return receipt, err
+	}
 
-	query := `UPDATE invites SET inviter = $1, pass_hash = $2, salt = $3, pending = FALSE WHERE invitee = $4`
-	_, err := pool.Exec(query, guest.Inviter, hash, salt, guest.Invitee)
+	err = tx.Commit()
 
 	if err != nil {
-		logs.LogError(err, "Update Invite Query Error")
+		logs.LogError(err, "Commit Erasure Transaction Error")
 	}
 
-	return err
+	return receipt, err
 }
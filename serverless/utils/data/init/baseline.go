@@ -0,0 +1,243 @@
+package init
+
+// baselineMigration is the most recent migration reflected in the baseline schema.
+// New installs record it, and every migration before it, as applied. Migrations
+// added to the registry after it are applied as usual on top of the baseline.
+const baselineMigration = mig20240206
+
+// baselineQueries create the complete application schema as it stands after
+// the baseline migration. Any migration that is folded into the baseline must
+// have its changes reflected here (and in the expected schema in drift.go).
+var baselineQueries = []string{
+	`CREATE TYPE ADMIN_ROLE AS ENUM ( 'super admin', 'admin' );`,
+	`CREATE TYPE GUEST_ROLE AS ENUM ( 'guest admin', 'guest' );`,
+	`CREATE TABLE teams (
+		id VARCHAR(20) PRIMARY KEY,
+		team_name VARCHAR(255) NOT NULL,
+		aprimo_name VARCHAR(255) NOT NULL,
+		active BOOLEAN NOT NULL,
+		date_created TIMESTAMP NOT NULL,
+		date_modified TIMESTAMP NOT NULL,
+		archived_at TIMESTAMP DEFAULT NULL,
+		archived_by VARCHAR(255) DEFAULT NULL,
+		archive_reason TEXT DEFAULT NULL
+	);`,
+	`CREATE TABLE admins (
+		email VARCHAR(255) PRIMARY KEY,
+		first_name VARCHAR(255) NOT NULL,
+		last_name VARCHAR(255) NOT NULL,
+		team VARCHAR(20) NOT NULL,
+		role ADMIN_ROLE NOT NULL DEFAULT 'admin',
+		active BOOLEAN NOT NULL,
+		date_created TIMESTAMP NOT NULL,
+		date_modified TIMESTAMP NOT NULL,
+		archived_at TIMESTAMP DEFAULT NULL,
+		archived_by VARCHAR(255) DEFAULT NULL,
+		archive_reason TEXT DEFAULT NULL,
+		email_suppressed_at TIMESTAMP,
+		digest BOOLEAN NOT NULL DEFAULT FALSE,
+		digest_sent_at TIMESTAMP,
+		CONSTRAINT admins_team_fkey FOREIGN KEY(team) REFERENCES teams(id) ON UPDATE CASCADE ON DELETE RESTRICT
+	);`,
+	`CREATE TABLE guests (
+		email VARCHAR(255) PRIMARY KEY,
+		first_name VARCHAR(255) NOT NULL,
+		last_name VARCHAR(255) NOT NULL,
+		team VARCHAR(20) NOT NULL,
+		role GUEST_ROLE NOT NULL DEFAULT 'guest',
+		date_created TIMESTAMP NOT NULL,
+		date_modified TIMESTAMP NOT NULL,
+		locked BOOLEAN NOT NULL DEFAULT false,
+		login_attempt SMALLINT DEFAULT 0,
+		login_date TIMESTAMP,
+		archived_at TIMESTAMP DEFAULT NULL,
+		archived_by VARCHAR(255) DEFAULT NULL,
+		archive_reason TEXT DEFAULT NULL,
+		locale VARCHAR(8) NOT NULL DEFAULT 'en',
+		email_suppressed_at TIMESTAMP,
+		CONSTRAINT guests_team_fkey FOREIGN KEY(team) REFERENCES teams(id) ON UPDATE CASCADE ON DELETE RESTRICT
+	);`,
+	`CREATE TABLE invites (
+		invitee VARCHAR(255) NOT NULL,
+		inviter VARCHAR(255),
+		proposer VARCHAR(255),
+		pending BOOLEAN NOT NULL DEFAULT false,
+		date_invited TIMESTAMP NOT NULL,
+		expiration TIMESTAMP NOT NULL,
+		pass_hash VARCHAR(255) NOT NULL,
+		salt VARCHAR(10) NOT NULL,
+		first_login BOOLEAN NOT NULL DEFAULT true,
+		password_reset BOOLEAN NOT NULL DEFAULT true,
+		rejected_at TIMESTAMP DEFAULT NULL,
+		rejected_by VARCHAR(255) DEFAULT NULL,
+		reject_reason TEXT DEFAULT NULL,
+		CONSTRAINT invites_invitee_fkey FOREIGN KEY(invitee) REFERENCES guests(email) ON UPDATE CASCADE ON DELETE RESTRICT,
+		CONSTRAINT invites_inviter_fkey FOREIGN KEY(inviter) REFERENCES admins(email) ON UPDATE CASCADE ON DELETE RESTRICT,
+		CONSTRAINT invites_proposer_fkey FOREIGN KEY(proposer) REFERENCES guests(email) ON UPDATE CASCADE ON DELETE RESTRICT
+	);`,
+	`CREATE TABLE all_users (
+		user_id VARCHAR(20) PRIMARY KEY,
+		admin_id VARCHAR(255),
+		guest_id VARCHAR(255),
+		FOREIGN KEY(admin_id) REFERENCES admins(email) ON UPDATE CASCADE ON DELETE CASCADE,
+		FOREIGN KEY(guest_id) REFERENCES guests(email) ON UPDATE CASCADE ON DELETE CASCADE
+	);`,
+	`CREATE TABLE uploads (
+		s3_id VARCHAR(255) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		team_id VARCHAR(20) NOT NULL,
+		file_type VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL,
+		date_uploaded TIMESTAMP NOT NULL,
+		clean_dt TIMESTAMP DEFAULT NULL,
+		aprimo_record_id VARCHAR(255) DEFAULT NULL,
+		aprimo_record_dt TIMESTAMP DEFAULT NULL,
+		aprimo_upload_token VARCHAR(255) DEFAULT NULL,
+		aprimo_upload_dt TIMESTAMP DEFAULT NULL,
+		trace_parent VARCHAR(55) DEFAULT NULL,
+		aprimo_error TEXT DEFAULT NULL,
+		aprimo_error_dt TIMESTAMP DEFAULT NULL,
+		aprimo_abandoned_dt TIMESTAMP DEFAULT NULL,
+		CONSTRAINT uploads_team_id_fkey FOREIGN KEY(team_id) REFERENCES teams(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+		CONSTRAINT uploads_user_id_fkey FOREIGN KEY(user_id) REFERENCES all_users(user_id) ON UPDATE CASCADE ON DELETE RESTRICT
+	);`,
+	`CREATE TABLE mfa (
+		request_id VARCHAR(20) PRIMARY KEY,
+		code VARCHAR(20) NOT NULL,
+		date_created TIMESTAMP NOT NULL,
+		user_id VARCHAR(255) DEFAULT NULL
+	);`,
+	`CREATE TABLE password_history (
+		id VARCHAR(20) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		creation_date TIMESTAMP NOT NULL,
+		salt VARCHAR(10) NOT NULL,
+		pass_hash VARCHAR(255) NOT NULL,
+		FOREIGN KEY(user_id) REFERENCES guests(email) ON UPDATE CASCADE ON DELETE CASCADE
+	);`,
+	`CREATE TABLE erasures (
+		id VARCHAR(20) PRIMARY KEY,
+		pseudonym VARCHAR(255) NOT NULL,
+		subject_hash VARCHAR(64) NOT NULL,
+		erased_by VARCHAR(255) NOT NULL,
+		reason TEXT,
+		date_erased TIMESTAMP NOT NULL,
+		invites_pseudonymized INTEGER NOT NULL,
+		password_history_deleted INTEGER NOT NULL,
+		mfa_deleted INTEGER NOT NULL,
+		uploads_retained INTEGER NOT NULL,
+		login_attempts_deleted INTEGER NOT NULL DEFAULT 0,
+		emails_deleted INTEGER NOT NULL DEFAULT 0,
+		job_rows_pseudonymized INTEGER NOT NULL DEFAULT 0,
+		audit_key_destroyed BOOLEAN NOT NULL DEFAULT false
+	);`,
+	`CREATE TABLE invite_jobs (
+		id VARCHAR(20) PRIMARY KEY,
+		team VARCHAR(20) NOT NULL,
+		created_by VARCHAR(255) NOT NULL,
+		date_created TIMESTAMP NOT NULL,
+		total INTEGER NOT NULL,
+		CONSTRAINT invite_jobs_team_fkey FOREIGN KEY(team) REFERENCES teams(id) ON UPDATE CASCADE ON DELETE RESTRICT
+	);`,
+	`CREATE TABLE invite_job_rows (
+		job_id VARCHAR(20) NOT NULL,
+		row_number INTEGER NOT NULL,
+		email VARCHAR(255) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		reason TEXT,
+		date_modified TIMESTAMP NOT NULL,
+		email_id VARCHAR(20),
+		PRIMARY KEY(job_id, row_number),
+		CONSTRAINT invite_job_rows_job_id_fkey FOREIGN KEY(job_id) REFERENCES invite_jobs(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE audit_events (
+		id BIGSERIAL PRIMARY KEY,
+		occurred_at TIMESTAMP NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		actor_role VARCHAR(20),
+		action VARCHAR(64) NOT NULL,
+		target VARCHAR(255),
+		changes JSONB,
+		source_ip VARCHAR(64),
+		user_agent TEXT,
+		request_id VARCHAR(128),
+		prev_hash VARCHAR(64) NOT NULL,
+		hash VARCHAR(64) NOT NULL
+	);`,
+	`CREATE UNIQUE INDEX audit_events_hash_idx ON audit_events (hash);`,
+	`CREATE INDEX audit_events_actor_idx ON audit_events (actor, occurred_at);`,
+	`CREATE INDEX audit_events_target_idx ON audit_events (target, occurred_at);`,
+	`CREATE INDEX audit_events_action_idx ON audit_events (action, occurred_at);`,
+	`CREATE FUNCTION prevent_audit_mutation() RETURNS TRIGGER AS $$
+		BEGIN
+		  RAISE EXCEPTION 'audit events are append-only';
+		END;
+		$$ LANGUAGE plpgsql;`,
+	`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+		FOR EACH STATEMENT EXECUTE FUNCTION prevent_audit_mutation();`,
+	`CREATE TABLE audit_checkpoints (
+		day DATE PRIMARY KEY,
+		last_event_id BIGINT NOT NULL,
+		event_count BIGINT NOT NULL,
+		digest VARCHAR(64) NOT NULL,
+		signature VARCHAR(64) NOT NULL,
+		date_created TIMESTAMP NOT NULL
+	);`,
+	`CREATE TRIGGER audit_checkpoints_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_checkpoints
+		FOR EACH STATEMENT EXECUTE FUNCTION prevent_audit_mutation();`,
+	`CREATE TABLE audit_subjects (
+		subject VARCHAR(255) PRIMARY KEY,
+		token VARCHAR(72) NOT NULL UNIQUE,
+		key BYTEA NOT NULL,
+		date_created TIMESTAMP NOT NULL
+	);`,
+	`CREATE TABLE login_attempts (
+		id BIGSERIAL PRIMARY KEY,
+		email VARCHAR(255) NOT NULL,
+		attempted_at TIMESTAMP NOT NULL,
+		success BOOLEAN NOT NULL,
+		reason VARCHAR(32),
+		source_ip VARCHAR(64),
+		user_agent TEXT
+	);`,
+	`CREATE INDEX login_attempts_email_idx ON login_attempts (email, attempted_at);`,
+	`CREATE TABLE email_outbox (
+		id VARCHAR(20) PRIMARY KEY,
+		recipient VARCHAR(255) NOT NULL,
+		template VARCHAR(32) NOT NULL,
+		subject TEXT NOT NULL,
+		body_html TEXT,
+		body_text TEXT,
+		status VARCHAR(10) NOT NULL DEFAULT 'pending',
+		attempts SMALLINT NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMP NOT NULL,
+		message_id VARCHAR(255),
+		date_created TIMESTAMP NOT NULL,
+		date_sent TIMESTAMP
+	);`,
+	`CREATE INDEX email_outbox_due_idx ON email_outbox (status, next_attempt_at);`,
+	`CREATE INDEX email_outbox_recipient_idx ON email_outbox (recipient, date_created);`,
+	`CREATE TABLE email_suppressions (
+		email VARCHAR(255) PRIMARY KEY,
+		reason VARCHAR(16) NOT NULL,
+		detail TEXT,
+		feedback_id VARCHAR(255),
+		date_suppressed TIMESTAMP NOT NULL
+	);`,
+	`CREATE TABLE expiration_reminders (
+		invitee VARCHAR(255) NOT NULL,
+		expiration TIMESTAMP NOT NULL,
+		days INTEGER NOT NULL,
+		date_sent TIMESTAMP NOT NULL,
+		PRIMARY KEY (invitee, expiration, days)
+	);`,
+	`CREATE TABLE migrations (
+		id VARCHAR(20) PRIMARY KEY,
+		title VARCHAR(255) NOT NULL,
+		date_applied TIMESTAMP NOT NULL,
+		checksum VARCHAR(64)
+	);`,
+	`CREATE VIEW recent_invites AS ( SELECT DISTINCT ON(invitee) * FROM invites ORDER BY invitee, date_invited DESC );`,
+	`CREATE VIEW guest_auth_data AS ( SELECT * FROM guests LEFT JOIN recent_invites ON guests.email = recent_invites.invitee );`,
+}
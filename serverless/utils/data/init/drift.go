@@ -0,0 +1,350 @@
+package init
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// expectedSchema describes each table once every registered migration has been applied,
+// as a map of column names to their type as reported by `information_schema`. Columns
+// which do not accept null values are suffixed with `not null`. It must be updated
+// alongside any migration that alters the schema.
+var expectedSchema = map[string]map[string]string{
+	"teams": {
+		"id":             "character varying(20) not null",
+		"team_name":      "character varying(255) not null",
+		"aprimo_name":    "character varying(255) not null",
+		"active":         "boolean not null",
+		"date_created":   "timestamp without time zone not null",
+		"date_modified":  "timestamp without time zone not null",
+		"archived_at":    "timestamp without time zone",
+		"archived_by":    "character varying(255)",
+		"archive_reason": "text",
+	},
+	"admins": {
+		"email":               "character varying(255) not null",
+		"first_name":          "character varying(255) not null",
+		"last_name":           "character varying(255) not null",
+		"team":                "character varying(20) not null",
+		"role":                "admin_role not null",
+		"active":              "boolean not null",
+		"date_created":        "timestamp without time zone not null",
+		"date_modified":       "timestamp without time zone not null",
+		"archived_at":         "timestamp without time zone",
+		"archived_by":         "character varying(255)",
+		"archive_reason":      "text",
+		"email_suppressed_at": "timestamp without time zone",
+		"digest":              "boolean not null",
+		"digest_sent_at":      "timestamp without time zone",
+	},
+	"guests": {
+		"email":               "character varying(255) not null",
+		"first_name":          "character varying(255) not null",
+		"last_name":           "character varying(255) not null",
+		"team":                "character varying(20) not null",
+		"role":                "guest_role not null",
+		"date_created":        "timestamp without time zone not null",
+		"date_modified":       "timestamp without time zone not null",
+		"locked":              "boolean not null",
+		"login_attempt":       "smallint",
+		"login_date":          "timestamp without time zone",
+		"archived_at":         "timestamp without time zone",
+		"archived_by":         "character varying(255)",
+		"archive_reason":      "text",
+		"locale":              "character varying(8) not null",
+		"email_suppressed_at": "timestamp without time zone",
+	},
+	"invites": {
+		"invitee":        "character varying(255) not null",
+		"inviter":        "character varying(255)",
+		"proposer":       "character varying(255)",
+		"pending":        "boolean not null",
+		"date_invited":   "timestamp without time zone not null",
+		"expiration":     "timestamp without time zone not null",
+		"pass_hash":      "character varying(255) not null",
+		"salt":           "character varying(10) not null",
+		"first_login":    "boolean not null",
+		"password_reset": "boolean not null",
+		"rejected_at":    "timestamp without time zone",
+		"rejected_by":    "character varying(255)",
+		"reject_reason":  "text",
+	},
+	"all_users": {
+		"user_id":  "character varying(20) not null",
+		"admin_id": "character varying(255)",
+		"guest_id": "character varying(255)",
+	},
+	"uploads": {
+		"s3_id":               "character varying(255) not null",
+		"user_id":             "character varying(255) not null",
+		"team_id":             "character varying(20) not null",
+		"file_type":           "character varying(255) not null",
+		"description":         "text not null",
+		"date_uploaded":       "timestamp without time zone not null",
+		"clean_dt":            "timestamp without time zone",
+		"aprimo_record_id":    "character varying(255)",
+		"aprimo_record_dt":    "timestamp without time zone",
+		"aprimo_upload_token": "character varying(255)",
+		"aprimo_upload_dt":    "timestamp without time zone",
+		"trace_parent":        "character varying(55)",
+		"aprimo_error":        "text",
+		"aprimo_error_dt":     "timestamp without time zone",
+		"aprimo_abandoned_dt": "timestamp without time zone",
+	},
+	"mfa": {
+		"request_id":   "character varying(20) not null",
+		"code":         "character varying(20) not null",
+		"date_created": "timestamp without time zone not null",
+		"user_id":      "character varying(255)",
+	},
+	"password_history": {
+		"id":            "character varying(20) not null",
+		"user_id":       "character varying(255) not null",
+		"creation_date": "timestamp without time zone not null",
+		"salt":          "character varying(10) not null",
+		"pass_hash":     "character varying(255) not null",
+	},
+	"erasures": {
+		"id":                       "character varying(20) not null",
+		"pseudonym":                "character varying(255) not null",
+		"subject_hash":             "character varying(64) not null",
+		"erased_by":                "character varying(255) not null",
+		"reason":                   "text",
+		"date_erased":              "timestamp without time zone not null",
+		"invites_pseudonymized":    "integer not null",
+		"password_history_deleted": "integer not null",
+		"mfa_deleted":              "integer not null",
+		"uploads_retained":         "integer not null",
+		"login_attempts_deleted":   "integer not null",
+		"emails_deleted":           "integer not null",
+		"job_rows_pseudonymized":   "integer not null",
+		"audit_key_destroyed":      "boolean not null",
+	},
+	"invite_jobs": {
+		"id":           "character varying(20) not null",
+		"team":         "character varying(20) not null",
+		"created_by":   "character varying(255) not null",
+		"date_created": "timestamp without time zone not null",
+		"total":        "integer not null",
+	},
+	"invite_job_rows": {
+		"job_id":        "character varying(20) not null",
+		"row_number":    "integer not null",
+		"email":         "character varying(255) not null",
+		"status":        "character varying(20) not null",
+		"reason":        "text",
+		"date_modified": "timestamp without time zone not null",
+		"email_id":      "character varying(20)",
+	},
+	"audit_events": {
+		"id":          "bigint not null",
+		"occurred_at": "timestamp without time zone not null",
+		"actor":       "character varying(255) not null",
+		"actor_role":  "character varying(20)",
+		"action":      "character varying(64) not null",
+		"target":      "character varying(255)",
+		"changes":     "jsonb",
+		"source_ip":   "character varying(64)",
+		"user_agent":  "text",
+		"request_id":  "character varying(128)",
+		"prev_hash":   "character varying(64) not null",
+		"hash":        "character varying(64) not null",
+	},
+	"audit_checkpoints": {
+		"day":           "date not null",
+		"last_event_id": "bigint not null",
+		"event_count":   "bigint not null",
+		"digest":        "character varying(64) not null",
+		"signature":     "character varying(64) not null",
+		"date_created":  "timestamp without time zone not null",
+	},
+	"audit_subjects": {
+		"subject":      "character varying(255) not null",
+		"token":        "character varying(72) not null",
+		"key":          "bytea not null",
+		"date_created": "timestamp without time zone not null",
+	},
+	"login_attempts": {
+		"id":           "bigint not null",
+		"email":        "character varying(255) not null",
+		"attempted_at": "timestamp without time zone not null",
+		"success":      "boolean not null",
+		"reason":       "character varying(32)",
+		"source_ip":    "character varying(64)",
+		"user_agent":   "text",
+	},
+	"email_outbox": {
+		"id":              "character varying(20) not null",
+		"recipient":       "character varying(255) not null",
+		"template":        "character varying(32) not null",
+		"subject":         "text not null",
+		"body_html":       "text",
+		"body_text":       "text",
+		"status":          "character varying(10) not null",
+		"attempts":        "smallint not null",
+		"last_error":      "text",
+		"next_attempt_at": "timestamp without time zone not null",
+		"message_id":      "character varying(255)",
+		"date_created":    "timestamp without time zone not null",
+		"date_sent":       "timestamp without time zone",
+	},
+	"email_suppressions": {
+		"email":           "character varying(255) not null",
+		"reason":          "character varying(16) not null",
+		"detail":          "text",
+		"feedback_id":     "character varying(255)",
+		"date_suppressed": "timestamp without time zone not null",
+	},
+	"expiration_reminders": {
+		"invitee":    "character varying(255) not null",
+		"expiration": "timestamp without time zone not null",
+		"days":       "integer not null",
+		"date_sent":  "timestamp without time zone not null",
+	},
+	"migrations": {
+		"id":           "character varying(20) not null",
+		"title":        "character varying(255) not null",
+		"date_applied": "timestamp without time zone not null",
+		"checksum":     "character varying(64)",
+	},
+}
+
+// expectedViews lists the views that should be present in the database.
+var expectedViews = []string{"guest_auth_data", "recent_invites"}
+
+// retrieveActualSchema reads the columns of every table in the public schema from
+// `information_schema`, formatted in the same way as the expected schema.
+func retrieveActualSchema() (map[string]map[string]string, []string, error) {
+	actual := map[string]map[string]string{}
+	var views []string
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT c.table_name, c.column_name,
+		 CASE
+		   WHEN c.data_type = 'USER-DEFINED' THEN c.udt_name
+		   WHEN c.character_maximum_length IS NOT NULL THEN c.data_type || '(' || c.character_maximum_length || ')'
+		   ELSE c.data_type
+		 END || CASE WHEN c.is_nullable = 'NO' THEN ' not null' ELSE '' END
+		 FROM information_schema.columns c
+		 JOIN information_schema.tables t ON c.table_schema = t.table_schema AND c.table_name = t.table_name
+		 WHERE c.table_schema = 'public' AND t.table_type = 'BASE TABLE'`
+	rows, err := pool.Query(query)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Schema Query Error")
+		return actual, views, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, column, spec string
+
+		if err := rows.Scan(&table, &column, &spec); err != nil {
+			logs.LogError(err, "Retrieve Schema Scan Error")
+			return actual, views, err
+		}
+
+		if actual[table] == nil {
+			actual[table] = map[string]string{}
+		}
+
+		actual[table][column] = spec
+	}
+
+	if err = rows.Err(); err != nil {
+		return actual, views, err
+	}
+
+	viewRows, err := pool.Query(`SELECT table_name FROM information_schema.views WHERE table_schema = 'public'`)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Views Query Error")
+		return actual, views, err
+	}
+
+	defer viewRows.Close()
+
+	for viewRows.Next() {
+		var view string
+
+		if err := viewRows.Scan(&view); err != nil {
+			logs.LogError(err, "Retrieve Views Scan Error")
+			return actual, views, err
+		}
+
+		views = append(views, view)
+	}
+
+	return actual, views, viewRows.Err()
+}
+
+// compareSchemas lists every difference between the expected and actual schemas.
+func compareSchemas(expected map[string]map[string]string, actual map[string]map[string]string, expectedViews []string, actualViews []string) []string {
+	var drift []string
+
+	for table, columns := range expected {
+		actualColumns, ok := actual[table]
+
+		if !ok {
+			drift = append(drift, fmt.Sprintf("missing table %s", table))
+			continue
+		}
+
+		for column, spec := range columns {
+			actualSpec, ok := actualColumns[column]
+
+			if !ok {
+				drift = append(drift, fmt.Sprintf("missing column %s.%s", table, column))
+			} else if actualSpec != spec {
+				drift = append(drift, fmt.Sprintf("column %s.%s is %s, expected %s", table, column, actualSpec, spec))
+			}
+		}
+
+		for column := range actualColumns {
+			if _, ok := columns[column]; !ok {
+				drift = append(drift, fmt.Sprintf("unexpected column %s.%s", table, column))
+			}
+		}
+	}
+
+	for table := range actual {
+		if _, ok := expected[table]; !ok {
+			drift = append(drift, fmt.Sprintf("unexpected table %s", table))
+		}
+	}
+
+	present := map[string]bool{}
+
+	for _, view := range actualViews {
+		present[view] = true
+	}
+
+	for _, view := range expectedViews {
+		if !present[view] {
+			drift = append(drift, fmt.Sprintf("missing view %s", view))
+		}
+	}
+
+	sort.Strings(drift)
+
+	return drift
+}
+
+// CheckSchemaDrift compares the live database schema with the schema expected once all
+// migrations have been applied. It returns a description of each mismatch found.
+func CheckSchemaDrift() ([]string, error) {
+	actual, views, err := retrieveActualSchema()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return compareSchemas(expectedSchema, actual, expectedViews, views), nil
+}
@@ -39,18 +39,6 @@ func CheckForTable(tablename string) bool {
 	return exists
 }
 
-// stringArrayContains iterates through an array of string to check whether
-// a given string value is present as an item in the array.
-func stringArrayContains(arr []string, value string) bool {
-	for _, item := range arr {
-		if item == value {
-			return true
-		}
-	}
-
-	return false
-}
-
 // recordMigration saves the title of an schema migration and the date on which it was applied.
 func recordMigration(title string) error {
 	pool := data.ConnectToDB()
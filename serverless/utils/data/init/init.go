@@ -1,51 +1,70 @@
 package init
 
 import (
+	"context"
+	"time"
+
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/rs/xid"
 )
 
-// InitializeDatabase opens a connection to the database and runs
-// the queries needed to configure the database with the proper tables.
+// InitializeDatabase opens a connection to the database and creates the current
+// application schema in a single transaction. Rather than replaying every migration,
+// the migrations folded into the baseline are recorded as having been applied. Any
+// error aborts the set up, leaving the database untouched.
 func InitializeDatabase() error {
-	var err error
+	ctx := context.TODO()
+
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	_, err = pool.Exec(teamsQuery)
+	tx, err := pool.BeginTx(ctx, nil)
 
 	if err != nil {
-		logs.LogError(err, "Table Creation Error - Teams")
+		logs.LogError(err, "Begin Baseline Transaction Error")
+		return err
 	}
 
-	_, err = pool.Exec(adminsQuery)
+	// Rolling back is a no-op once the transaction has been committed.
+	defer tx.Rollback()
 
-	if err != nil {
-		logs.LogError(err, "Table Creation Error - Admins")
+	for _, query := range baselineQueries {
+		_, err = tx.Exec(query)
+
+		if err != nil {
+			logs.LogError(err, "Baseline Schema Creation Error")
+			return err
+		}
 	}
 
-	_, err = pool.Exec(guestsQuery)
+	currentTime := time.Now()
 
-	if err != nil {
-		logs.LogError(err, "Table Creation Error - Guests")
-	}
+	for _, m := range registry {
+		sum, err := m.checksum()
 
-	_, err = pool.Exec(invitesQuery)
+		if err != nil {
+			logs.LogError(err, "Compute Migration Checksum Error")
+			return err
+		}
 
-	if err != nil {
-		logs.LogError(err, "Table Creation Error - Invites")
-	}
+		query := `INSERT INTO migrations( id, title, date_applied, checksum ) VALUES ( $1, $2, $3, $4 );`
+		_, err = tx.Exec(query, xid.New(), m.title, currentTime, sum)
 
-	_, err = pool.Exec(uploadsQuery)
+		if err != nil {
+			logs.LogError(err, "Migration Registry Error")
+			return err
+		}
 
-	if err != nil {
-		logs.LogError(err, "Table Creation Error - Uploads")
+		if m.title == baselineMigration {
+			break
+		}
 	}
 
-	_, err = pool.Exec(migrationsQuery)
+	err = tx.Commit()
 
 	if err != nil {
-		logs.LogError(err, "Table Creation Error - Migrations")
+		logs.LogError(err, "Commit Baseline Transaction Error")
 	}
 
 	return err
@@ -0,0 +1,115 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+var archivalTables = []string{"guests", "admins", "teams"}
+
+// addArchivalColumns adds the columns used to soft delete records to the
+// guests, admins, and teams tables. A record is considered archived if
+// it has a non-null `archived_at` value.
+func addArchivalColumns(tx *sql.Tx) error {
+	var err error
+
+	for _, table := range archivalTables {
+		_, err = tx.Exec(
+			`ALTER TABLE ` + table + `
+			 ADD COLUMN archived_at TIMESTAMP DEFAULT NULL,
+			 ADD COLUMN archived_by VARCHAR(255) DEFAULT NULL,
+			 ADD COLUMN archive_reason TEXT DEFAULT NULL;`,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Add Archival Columns Query Error - "+table)
+
+			return err
+		}
+	}
+
+	return err
+}
+
+// dropArchivalColumns removes the soft delete columns from the guests, admins, and teams tables.
+func dropArchivalColumns(tx *sql.Tx) error {
+	var err error
+
+	for _, table := range archivalTables {
+		_, err = tx.Exec(
+			`ALTER TABLE ` + table + `
+			 DROP COLUMN archived_at,
+			 DROP COLUMN archived_by,
+			 DROP COLUMN archive_reason;`,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Drop Archival Columns Query Error - "+table)
+
+			return err
+		}
+	}
+
+	return err
+}
+
+// dropAuthView removes the guest_auth_data view, which depends on every column in the guests table.
+func dropAuthView(tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP VIEW IF EXISTS guest_auth_data;`)
+
+	if err != nil {
+		logs.LogError(err, "Drop View Query Error")
+	}
+
+	return err
+}
+
+// rebuildAuthView creates the guest_auth_data view anew. Views created with `SELECT *`
+// are expanded when they are created, so they do not pick up columns added after the fact.
+func rebuildAuthView(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		`CREATE VIEW guest_auth_data AS ( SELECT * FROM guests LEFT JOIN recent_invites ON guests.email = recent_invites.invitee );`,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Create View Query Error")
+	}
+
+	return err
+}
+
+// upMigration20231204 allows guests, admins, and teams to be archived
+// (soft deleted) rather than deactivated in an entity specific fashion.
+func upMigration20231204(tx *sql.Tx) error {
+	err := dropAuthView(tx)
+
+	if err != nil {
+		return err
+	}
+
+	err = addArchivalColumns(tx)
+
+	if err != nil {
+		return err
+	}
+
+	return rebuildAuthView(tx)
+}
+
+// downMigration20231204 removes support for archiving guests, admins, and teams.
+func downMigration20231204(tx *sql.Tx) error {
+	err := dropAuthView(tx)
+
+	if err != nil {
+		return err
+	}
+
+	err = dropArchivalColumns(tx)
+
+	if err != nil {
+		return err
+	}
+
+	return rebuildAuthView(tx)
+}
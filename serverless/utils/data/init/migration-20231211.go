@@ -0,0 +1,74 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// addMfaUserColumn associates 2FA requests with the user who initiated them
+// so that a user's outstanding codes can be removed when their data is erased.
+func addMfaUserColumn(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mfa ADD COLUMN user_id VARCHAR(255) DEFAULT NULL;`)
+
+	if err != nil {
+		logs.LogError(err, "Add MFA User Column Query Error")
+	}
+
+	return err
+}
+
+// createErasuresTable creates a table to hold the receipts generated when a guest's
+// personal data is erased. Receipts reference the guest only by their pseudonym and
+// a hash of their former email address.
+func createErasuresTable(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		`CREATE TABLE IF NOT EXISTS erasures (
+		 id VARCHAR(20) PRIMARY KEY,
+		 pseudonym VARCHAR(255) NOT NULL,
+		 subject_hash VARCHAR(64) NOT NULL,
+		 erased_by VARCHAR(255) NOT NULL,
+		 reason TEXT,
+		 date_erased TIMESTAMP NOT NULL,
+		 invites_pseudonymized INTEGER NOT NULL,
+		 password_history_deleted INTEGER NOT NULL,
+		 mfa_deleted INTEGER NOT NULL,
+		 uploads_retained INTEGER NOT NULL
+		);`,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Table Creation Query Error - Erasures")
+	}
+
+	return err
+}
+
+// upMigration20231211 supports the erasure of a guest user's personal data.
+func upMigration20231211(tx *sql.Tx) error {
+	err := addMfaUserColumn(tx)
+
+	if err != nil {
+		return err
+	}
+
+	return createErasuresTable(tx)
+}
+
+// downMigration20231211 removes the erasure receipts and the MFA user column.
+func downMigration20231211(tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS erasures;`)
+
+	if err != nil {
+		logs.LogError(err, "Drop Erasures Table Query Error")
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE mfa DROP COLUMN user_id;`)
+
+	if err != nil {
+		logs.LogError(err, "Drop MFA User Column Query Error")
+	}
+
+	return err
+}
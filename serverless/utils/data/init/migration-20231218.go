@@ -0,0 +1,63 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// createInviteJobTables creates the tables used to track bulk guest invitations.
+// Each job records the team and admin behind an uploaded CSV file, while the outcome
+// of every row in that file is stored separately so that it can be reported back.
+func createInviteJobTables(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		`CREATE TABLE IF NOT EXISTS invite_jobs (
+		 id VARCHAR(20) PRIMARY KEY,
+		 team VARCHAR(20) NOT NULL,
+		 created_by VARCHAR(255) NOT NULL,
+		 date_created TIMESTAMP NOT NULL,
+		 total INTEGER NOT NULL,
+		 CONSTRAINT invite_jobs_team_fkey FOREIGN KEY(team) REFERENCES teams(id) ON UPDATE CASCADE ON DELETE RESTRICT
+		);`,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Table Creation Query Error - Invite Jobs")
+		return err
+	}
+
+	_, err = tx.Exec(
+		`CREATE TABLE IF NOT EXISTS invite_job_rows (
+		 job_id VARCHAR(20) NOT NULL,
+		 row_number INTEGER NOT NULL,
+		 email VARCHAR(255) NOT NULL,
+		 status VARCHAR(20) NOT NULL,
+		 reason TEXT,
+		 date_modified TIMESTAMP NOT NULL,
+		 PRIMARY KEY(job_id, row_number),
+		 CONSTRAINT invite_job_rows_job_id_fkey FOREIGN KEY(job_id) REFERENCES invite_jobs(id) ON DELETE CASCADE
+		);`,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Table Creation Query Error - Invite Job Rows")
+	}
+
+	return err
+}
+
+// upMigration20231218 supports the bulk invitation of guest users.
+func upMigration20231218(tx *sql.Tx) error {
+	return createInviteJobTables(tx)
+}
+
+// downMigration20231218 removes the bulk invitation tables.
+func downMigration20231218(tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS invite_job_rows, invite_jobs;`)
+
+	if err != nil {
+		logs.LogError(err, "Drop Invite Job Tables Query Error")
+	}
+
+	return err
+}
@@ -0,0 +1,67 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// createAuditEventsTable creates the append-only log of administrative and security
+// relevant actions. A trigger rejects any attempt to alter or remove recorded events.
+func createAuditEventsTable(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS audit_events (
+		 id BIGSERIAL PRIMARY KEY,
+		 occurred_at TIMESTAMP NOT NULL,
+		 actor VARCHAR(255) NOT NULL,
+		 actor_role VARCHAR(20),
+		 action VARCHAR(64) NOT NULL,
+		 target VARCHAR(255),
+		 changes JSONB,
+		 source_ip VARCHAR(64),
+		 user_agent TEXT,
+		 request_id VARCHAR(128)
+		);`,
+		`CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor, occurred_at);`,
+		`CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target, occurred_at);`,
+		`CREATE INDEX IF NOT EXISTS audit_events_action_idx ON audit_events (action, occurred_at);`,
+		`CREATE OR REPLACE FUNCTION prevent_audit_mutation() RETURNS TRIGGER AS $$
+		 BEGIN
+		   RAISE EXCEPTION 'audit events are append-only';
+		 END;
+		 $$ LANGUAGE plpgsql;`,
+		`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+		 FOR EACH STATEMENT EXECUTE FUNCTION prevent_audit_mutation();`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Table Creation Query Error - Audit Events")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upMigration20231226 adds the audit log.
+func upMigration20231226(tx *sql.Tx) error {
+	return createAuditEventsTable(tx)
+}
+
+// downMigration20231226 removes the audit log and its append-only trigger.
+func downMigration20231226(tx *sql.Tx) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS audit_events;`,
+		`DROP FUNCTION IF EXISTS prevent_audit_mutation();`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Audit Events Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240102 chains each audit event to the one before it with a SHA-256 hash
+// and adds the table of signed daily checkpoints. Events recorded before this migration
+// are linked in the order in which they were recorded.
+func upMigration20240102(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE audit_events ADD COLUMN prev_hash VARCHAR(64), ADD COLUMN hash VARCHAR(64);`,
+		`ALTER TABLE audit_events DISABLE TRIGGER audit_events_append_only;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Add Audit Hash Columns Query Error")
+			return err
+		}
+	}
+
+	if err := audit.ChainExisting(tx); err != nil {
+		return err
+	}
+
+	queries = []string{
+		`ALTER TABLE audit_events ENABLE TRIGGER audit_events_append_only;`,
+		`ALTER TABLE audit_events ALTER COLUMN prev_hash SET NOT NULL, ALTER COLUMN hash SET NOT NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS audit_events_hash_idx ON audit_events (hash);`,
+		`CREATE TABLE IF NOT EXISTS audit_checkpoints (
+		 day DATE PRIMARY KEY,
+		 last_event_id BIGINT NOT NULL,
+		 event_count BIGINT NOT NULL,
+		 digest VARCHAR(64) NOT NULL,
+		 signature VARCHAR(64) NOT NULL,
+		 date_created TIMESTAMP NOT NULL
+		);`,
+		`CREATE TRIGGER audit_checkpoints_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_checkpoints
+		 FOR EACH STATEMENT EXECUTE FUNCTION prevent_audit_mutation();`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Audit Hash Chain Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240102 removes the audit checkpoints and the hash chain.
+func downMigration20240102(tx *sql.Tx) error {
+	queries := []string{
+		`DROP TABLE IF EXISTS audit_checkpoints;`,
+		`DROP INDEX IF EXISTS audit_events_hash_idx;`,
+		`ALTER TABLE audit_events DROP COLUMN IF EXISTS prev_hash, DROP COLUMN IF EXISTS hash;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Audit Hash Chain Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240109 adds the history of guest authentication attempts, along with a
+// count of the attempts deleted when a guest's personal data is erased.
+func upMigration20240109(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS login_attempts (
+		 id BIGSERIAL PRIMARY KEY,
+		 email VARCHAR(255) NOT NULL,
+		 attempted_at TIMESTAMP NOT NULL,
+		 success BOOLEAN NOT NULL,
+		 reason VARCHAR(32),
+		 source_ip VARCHAR(64),
+		 user_agent TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS login_attempts_email_idx ON login_attempts (email, attempted_at);`,
+		`ALTER TABLE erasures ADD COLUMN IF NOT EXISTS login_attempts_deleted INTEGER NOT NULL DEFAULT 0;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Table Creation Query Error - Login Attempts")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240109 removes the login history.
+func downMigration20240109(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE erasures DROP COLUMN IF EXISTS login_attempts_deleted;`,
+		`DROP TABLE IF EXISTS login_attempts;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Login Attempts Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240110 records the trace context of each upload, so that the trace
+// begun when the file was uploaded can be continued once it is transferred to Aprimo.
+func upMigration20240110(tx *sql.Tx) error {
+	query := `ALTER TABLE uploads ADD COLUMN IF NOT EXISTS trace_parent VARCHAR(55) DEFAULT NULL;`
+
+	if _, err := tx.Exec(query); err != nil {
+		logs.LogError(err, "Add Column Query Error - Upload Trace Parent")
+		return err
+	}
+
+	return nil
+}
+
+// downMigration20240110 removes the trace context of uploads.
+func downMigration20240110(tx *sql.Tx) error {
+	query := `ALTER TABLE uploads DROP COLUMN IF EXISTS trace_parent;`
+
+	if _, err := tx.Exec(query); err != nil {
+		logs.LogError(err, "Drop Column Query Error - Upload Trace Parent")
+		return err
+	}
+
+	return nil
+}
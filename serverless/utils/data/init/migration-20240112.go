@@ -0,0 +1,41 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240112 records the most recent error encountered while transferring an
+// upload to Aprimo, and when a transfer which could not be completed was abandoned.
+func upMigration20240112(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE uploads ADD COLUMN IF NOT EXISTS aprimo_error TEXT DEFAULT NULL;`,
+		`ALTER TABLE uploads ADD COLUMN IF NOT EXISTS aprimo_error_dt TIMESTAMP DEFAULT NULL;`,
+		`ALTER TABLE uploads ADD COLUMN IF NOT EXISTS aprimo_abandoned_dt TIMESTAMP DEFAULT NULL;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Add Column Query Error - Upload Aprimo Error")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240112 removes the Aprimo transfer errors of uploads.
+func downMigration20240112(tx *sql.Tx) error {
+	query := `ALTER TABLE uploads
+		DROP COLUMN IF EXISTS aprimo_error,
+		DROP COLUMN IF EXISTS aprimo_error_dt,
+		DROP COLUMN IF EXISTS aprimo_abandoned_dt;`
+
+	if _, err := tx.Exec(query); err != nil {
+		logs.LogError(err, "Drop Column Query Error - Upload Aprimo Error")
+		return err
+	}
+
+	return nil
+}
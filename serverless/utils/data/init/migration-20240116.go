@@ -0,0 +1,31 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240116 records the language in which each guest prefers to receive emails.
+func upMigration20240116(tx *sql.Tx) error {
+	query := `ALTER TABLE guests ADD COLUMN IF NOT EXISTS locale VARCHAR(8) NOT NULL DEFAULT 'en';`
+
+	if _, err := tx.Exec(query); err != nil {
+		logs.LogError(err, "Add Column Query Error - Guest Locale")
+		return err
+	}
+
+	return nil
+}
+
+// downMigration20240116 removes the language preference of guests.
+func downMigration20240116(tx *sql.Tx) error {
+	query := `ALTER TABLE guests DROP COLUMN IF EXISTS locale;`
+
+	if _, err := tx.Exec(query); err != nil {
+		logs.LogError(err, "Drop Column Query Error - Guest Locale")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240118 adds the outbox of emails awaiting delivery, which are written in
+// the same transaction as the change that prompts them and sent by the dispatcher, along
+// with a count of the emails deleted when a guest's personal data is erased.
+func upMigration20240118(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS email_outbox (
+		 id VARCHAR(20) PRIMARY KEY,
+		 recipient VARCHAR(255) NOT NULL,
+		 template VARCHAR(32) NOT NULL,
+		 subject TEXT NOT NULL,
+		 body_html TEXT,
+		 body_text TEXT,
+		 status VARCHAR(10) NOT NULL DEFAULT 'pending',
+		 attempts SMALLINT NOT NULL DEFAULT 0,
+		 last_error TEXT,
+		 next_attempt_at TIMESTAMP NOT NULL,
+		 message_id VARCHAR(255),
+		 date_created TIMESTAMP NOT NULL,
+		 date_sent TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS email_outbox_due_idx ON email_outbox (status, next_attempt_at);`,
+		`CREATE INDEX IF NOT EXISTS email_outbox_recipient_idx ON email_outbox (recipient, date_created);`,
+		`ALTER TABLE erasures ADD COLUMN IF NOT EXISTS emails_deleted INTEGER NOT NULL DEFAULT 0;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Table Creation Query Error - Email Outbox")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240118 removes the email outbox.
+func downMigration20240118(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE erasures DROP COLUMN IF EXISTS emails_deleted;`,
+		`DROP TABLE IF EXISTS email_outbox;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Email Outbox Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
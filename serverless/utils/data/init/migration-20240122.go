@@ -0,0 +1,50 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240122 adds the list of addresses to which emails are no longer sent after
+// they bounced or their recipient complained, and flags the admins and guests affected.
+func upMigration20240122(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS email_suppressions (
+		 email VARCHAR(255) PRIMARY KEY,
+		 reason VARCHAR(16) NOT NULL,
+		 detail TEXT,
+		 feedback_id VARCHAR(255),
+		 date_suppressed TIMESTAMP NOT NULL
+		);`,
+		`ALTER TABLE admins ADD COLUMN IF NOT EXISTS email_suppressed_at TIMESTAMP;`,
+		`ALTER TABLE guests ADD COLUMN IF NOT EXISTS email_suppressed_at TIMESTAMP;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Table Creation Query Error - Email Suppressions")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240122 removes the email suppression list and flags.
+func downMigration20240122(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE guests DROP COLUMN IF EXISTS email_suppressed_at;`,
+		`ALTER TABLE admins DROP COLUMN IF EXISTS email_suppressed_at;`,
+		`DROP TABLE IF EXISTS email_suppressions;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Email Suppressions Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
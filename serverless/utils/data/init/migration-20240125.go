@@ -0,0 +1,40 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240125 adds the record of the reminders sent to guests whose access is
+// about to expire, so that each reminder is only sent once.
+func upMigration20240125(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS expiration_reminders (
+		 invitee VARCHAR(255) NOT NULL,
+		 expiration TIMESTAMP NOT NULL,
+		 days INTEGER NOT NULL,
+		 date_sent TIMESTAMP NOT NULL,
+		 PRIMARY KEY (invitee, expiration, days)
+		);`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Table Creation Query Error - Expiration Reminders")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240125 removes the record of expiration reminders.
+func downMigration20240125(tx *sql.Tx) error {
+	if _, err := tx.Exec(`DROP TABLE IF EXISTS expiration_reminders;`); err != nil {
+		logs.LogError(err, "Drop Expiration Reminders Query Error")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240129 lets admins opt in to a daily digest of their team's activity, and
+// records when each was last sent so that a repeated run does not send it twice.
+func upMigration20240129(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE admins ADD COLUMN IF NOT EXISTS digest BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE admins ADD COLUMN IF NOT EXISTS digest_sent_at TIMESTAMP;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Alter Admins Query Error - Digest")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240129 removes the digest preference from admins.
+func downMigration20240129(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE admins DROP COLUMN IF EXISTS digest_sent_at;`,
+		`ALTER TABLE admins DROP COLUMN IF EXISTS digest;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Admin Digest Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
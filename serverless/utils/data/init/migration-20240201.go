@@ -0,0 +1,61 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// rebuildInviteViews drops and recreates the views over the invites table, running the
+// provided queries in between. Views created with `SELECT *` are expanded when they are
+// created, so they must be rebuilt to pick up columns added to, or dropped from, invites.
+func rebuildInviteViews(tx *sql.Tx, queries []string) error {
+	err := dropAuthView(tx)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DROP VIEW IF EXISTS recent_invites;`); err != nil {
+		logs.LogError(err, "Drop View Query Error")
+		return err
+	}
+
+	for _, query := range queries {
+		if _, err = tx.Exec(query); err != nil {
+			logs.LogError(err, "Alter Invites Query Error - Rejections")
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`CREATE VIEW recent_invites AS ( SELECT DISTINCT ON(invitee) * FROM invites ORDER BY invitee, date_invited DESC );`)
+
+	if err != nil {
+		logs.LogError(err, "Create View Query Error")
+		return err
+	}
+
+	return rebuildAuthView(tx)
+}
+
+// upMigration20240201 records who rejected a proposed invitation, when, and why. A
+// rejected invite remains pending, so that the guest cannot log in, but is no longer
+// awaiting approval.
+func upMigration20240201(tx *sql.Tx) error {
+	return rebuildInviteViews(tx, []string{
+		`ALTER TABLE invites
+		 ADD COLUMN IF NOT EXISTS rejected_at TIMESTAMP DEFAULT NULL,
+		 ADD COLUMN IF NOT EXISTS rejected_by VARCHAR(255) DEFAULT NULL,
+		 ADD COLUMN IF NOT EXISTS reject_reason TEXT DEFAULT NULL;`,
+	})
+}
+
+// downMigration20240201 removes the record of rejected invitations.
+func downMigration20240201(tx *sql.Tx) error {
+	return rebuildInviteViews(tx, []string{
+		`ALTER TABLE invites
+		 DROP COLUMN IF EXISTS reject_reason,
+		 DROP COLUMN IF EXISTS rejected_by,
+		 DROP COLUMN IF EXISTS rejected_at;`,
+	})
+}
@@ -0,0 +1,44 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240205 links each bulk invitation row to the outbox email carrying the
+// guest's credentials, so that the credentials are only issued once per row, and records
+// on each erasure receipt the number of rows whose email address was pseudonymized.
+func upMigration20240205(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE invite_job_rows ADD COLUMN IF NOT EXISTS email_id VARCHAR(20);`,
+		`ALTER TABLE erasures ADD COLUMN IF NOT EXISTS job_rows_pseudonymized INTEGER NOT NULL DEFAULT 0;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Alter Tables Query Error - Invite Job Rows")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240205 removes the outbox email from bulk invitation rows, and the
+// count of pseudonymized rows from erasures.
+func downMigration20240205(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE erasures DROP COLUMN IF EXISTS job_rows_pseudonymized;`,
+		`ALTER TABLE invite_job_rows DROP COLUMN IF EXISTS email_id;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Invite Job Row Columns Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package init
+
+import (
+	"database/sql"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// upMigration20240206 adds the keys under which the people named in the audit log are
+// recorded, and records on each erasure receipt whether the guest's key was destroyed.
+func upMigration20240206(tx *sql.Tx) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS audit_subjects (
+			subject VARCHAR(255) PRIMARY KEY,
+			token VARCHAR(72) NOT NULL UNIQUE,
+			key BYTEA NOT NULL,
+			date_created TIMESTAMP NOT NULL
+		);`,
+		`ALTER TABLE erasures ADD COLUMN IF NOT EXISTS audit_key_destroyed BOOLEAN NOT NULL DEFAULT false;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Create Table Query Error - Audit Subjects")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downMigration20240206 removes the audit subject keys, and the record of their
+// destruction from erasures.
+func downMigration20240206(tx *sql.Tx) error {
+	queries := []string{
+		`ALTER TABLE erasures DROP COLUMN IF EXISTS audit_key_destroyed;`,
+		`DROP TABLE IF EXISTS audit_subjects;`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			logs.LogError(err, "Drop Audit Subjects Query Error")
+			return err
+		}
+	}
+
+	return nil
+}
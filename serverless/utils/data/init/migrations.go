@@ -1,10 +1,22 @@
 package init
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/rs/xid"
 )
 
 // A list of schema migrations. Please add newest to the bottom.
@@ -21,17 +33,206 @@ const mig20231023 = "20231023_auth_with_invites"
 const mig20231024 = "20231024_invite_password_reset"
 const mig20231030 = "20231030_password_history"
 const mig20231116 = "20231116_file_description_type"
+const mig20231204 = "20231204_archival"
+const mig20231211 = "20231211_guest_erasure"
+const mig20231218 = "20231218_invite_jobs"
+const mig20231226 = "20231226_audit_events"
+const mig20240102 = "20240102_audit_hash_chain"
+const mig20240109 = "20240109_login_attempts"
+const mig20240110 = "20240110_upload_trace"
+const mig20240112 = "20240112_upload_aprimo_error"
+const mig20240116 = "20240116_guest_locale"
+const mig20240118 = "20240118_email_outbox"
+const mig20240122 = "20240122_email_suppressions"
+const mig20240125 = "20240125_expiration_reminders"
+const mig20240129 = "20240129_admin_digest"
+const mig20240201 = "20240201_invite_rejections"
+const mig20240205 = "20240205_invite_job_rows"
+const mig20240206 = "20240206_audit_subjects"
+
+// migrationLockId is the key of the Postgres advisory lock held while migrations
+// are applied or reverted, so that concurrent invocations cannot interleave.
+const migrationLockId = 20230831
+
+// The source files of the migrations, used to compute their checksums. Once applied,
+// a migration's code is frozen, though its comments and formatting may still change.
+//
+//go:embed migration-*.go
+var migrationSources embed.FS
+
+// migration describes a single schema update. Migrations written before the
+// registry existed apply and record themselves outside of a transaction (legacy).
+// All others provide an `up` step, and optionally a `down` step, which are
+// run within a transaction alongside the update to the `migrations` table.
+type migration struct {
+	title  string
+	source string
+	legacy func(title string) error
+	up     func(tx *sql.Tx) error
+	down   func(tx *sql.Tx) error
+}
 
-// getAppliedMigrations queries the `migrations` table in that database
-// for a list of schema updates that have already been executed.
-func getAppliedMigrations() ([]string, error) {
-	var applied []string
-	var err error
+// The registry of schema migrations, which are applied in the order listed.
+// New migrations should be added to the bottom.
+var registry = []migration{
+	{title: mig20230831, source: "migration-20230831.go", legacy: applyMigration20230831},
+	{title: mig20230926, source: "migration-20230926.go", legacy: applyMigration20230926},
+	{title: mig20230927, source: "migration-20230927.go", legacy: applyMigration20230927},
+	{title: mig20230929, source: "migration-20230929.go", legacy: applyMigration20230929},
+	{title: mig20231002, source: "migration-20231002.go", legacy: applyMigration20231002},
+	{title: mig20231010, source: "migration-20231010.go", legacy: applyMigration20231010},
+	{title: mig20231016, source: "migration-20231016.go", legacy: applyMigration20231016},
+	{title: mig20231023, source: "migration-20231023.go", legacy: applyMigration20231023},
+	{title: mig20231024, source: "migration-20231024.go", legacy: applyMigration20231024},
+	{title: mig20231030, source: "migration-20231030.go", legacy: applyMigration20231030},
+	{title: mig20231116, source: "migration-20231116.go", legacy: applyMigration20231116},
+	{title: mig20231204, source: "migration-20231204.go", up: upMigration20231204, down: downMigration20231204},
+	{title: mig20231211, source: "migration-20231211.go", up: upMigration20231211, down: downMigration20231211},
+	{title: mig20231218, source: "migration-20231218.go", up: upMigration20231218, down: downMigration20231218},
+	{title: mig20231226, source: "migration-20231226.go", up: upMigration20231226, down: downMigration20231226},
+	{title: mig20240102, source: "migration-20240102.go", up: upMigration20240102, down: downMigration20240102},
+	{title: mig20240109, source: "migration-20240109.go", up: upMigration20240109, down: downMigration20240109},
+	{title: mig20240110, source: "migration-20240110.go", up: upMigration20240110, down: downMigration20240110},
+	{title: mig20240112, source: "migration-20240112.go", up: upMigration20240112, down: downMigration20240112},
+	{title: mig20240116, source: "migration-20240116.go", up: upMigration20240116, down: downMigration20240116},
+	{title: mig20240118, source: "migration-20240118.go", up: upMigration20240118, down: downMigration20240118},
+	{title: mig20240122, source: "migration-20240122.go", up: upMigration20240122, down: downMigration20240122},
+	{title: mig20240125, source: "migration-20240125.go", up: upMigration20240125, down: downMigration20240125},
+	{title: mig20240129, source: "migration-20240129.go", up: upMigration20240129, down: downMigration20240129},
+	{title: mig20240201, source: "migration-20240201.go", up: upMigration20240201, down: downMigration20240201},
+	{title: mig20240205, source: "migration-20240205.go", up: upMigration20240205, down: downMigration20240205},
+	{title: mig20240206, source: "migration-20240206.go", up: upMigration20240206, down: downMigration20240206},
+}
 
-	pool := data.ConnectToDB()
-	defer pool.Close()
+// MigrationStatus reports the state of a single schema migration.
+type MigrationStatus struct {
+	Title        string     `json:"title"`
+	Applied      bool       `json:"applied"`
+	DateApplied  *time.Time `json:"dateApplied"`
+	Checksum     string     `json:"checksum"`
+	Modified     bool       `json:"modified"`
+	Reversible   bool       `json:"reversible"`
+	Unregistered bool       `json:"unregistered"`
+}
+
+type appliedMigration struct {
+	dateApplied time.Time
+	checksum    sql.NullString
+}
+
+// checksum computes a SHA-256 hash of the migration's source file. Only the tokens that
+// follow the imports are hashed, so comments, formatting, and imports may be changed
+// freely, while a change in the hash of an applied migration indicates that its steps
+// were edited after the fact.
+func (m migration) checksum() (string, error) {
+	src, err := migrationSources.ReadFile(m.source)
+
+	if err != nil {
+		return "", err
+	}
+
+	return checksumSource(src)
+}
+
+// checksumSource hashes the tokens of a Go source file, leaving out its comments,
+// automatically inserted semicolons, and import declarations.
+func checksumSource(src []byte) (string, error) {
+	var s scanner.Scanner
+	var scanErr error
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, src, func(_ token.Position, msg string) { scanErr = errors.New(msg) }, 0)
+
+	hash := sha256.New()
+	importDepth := -1
+
+	for {
+		_, tok, lit := s.Scan()
+
+		if tok == token.EOF {
+			break
+		} else if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		// Skip over import declarations, whether single or grouped.
+		if tok == token.IMPORT {
+			importDepth = 0
+			continue
+		} else if importDepth >= 0 {
+			switch tok {
+			case token.LPAREN:
+				importDepth++
+			case token.RPAREN:
+				importDepth--
+			}
+
+			if importDepth == 0 && (tok == token.RPAREN || tok == token.STRING) {
+				importDepth = -1
+			}
+
+			continue
+		}
+
+		fmt.Fprintf(hash, "%s %s\n", tok, lit)
+	}
+
+	if scanErr != nil {
+		return "", scanErr
+	}
 
-	rows, err := pool.Query(`SELECT title FROM migrations`)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// lockMigrations reserves a single connection from the pool and acquires the migration
+// advisory lock on it, waiting for any other holder to release the lock. The returned
+// function releases both the lock and the connection.
+func lockMigrations(ctx context.Context, pool *sql.DB) (*sql.Conn, func(), error) {
+	conn, err := pool.Conn(ctx)
+
+	if err != nil {
+		logs.LogError(err, "Reserve Migration Connection Error")
+		return nil, nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockId)
+
+	if err != nil {
+		logs.LogError(err, "Acquire Migration Lock Error")
+		conn.Close()
+
+		return nil, nil, err
+	}
+
+	unlock := func() {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockId)
+
+		if err != nil {
+			logs.LogError(err, "Release Migration Lock Error")
+		}
+
+		conn.Close()
+	}
+
+	return conn, unlock, nil
+}
+
+// getAppliedMigrations queries the `migrations` table in that database for the
+// schema updates that have already been executed, keyed by their title. Checksums
+// are only recorded from the introduction of the registry onwards, so the column
+// is created here if need be.
+func getAppliedMigrations(ctx context.Context, conn *sql.Conn) (map[string]appliedMigration, error) {
+	applied := map[string]appliedMigration{}
+
+	_, err := conn.ExecContext(ctx, `ALTER TABLE migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);`)
+
+	if err != nil {
+		logs.LogError(err, "Add Migration Checksum Column Error")
+		return applied, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT title, date_applied, checksum FROM migrations`)
 
 	if err != nil {
 		logs.LogError(err, "Fetch Migrations Error")
@@ -42,149 +243,298 @@ func getAppliedMigrations() ([]string, error) {
 
 	for rows.Next() {
 		var title string
-		if err := rows.Scan(&title); err != nil {
+		var record appliedMigration
+
+		if err := rows.Scan(&title, &record.dateApplied, &record.checksum); err != nil {
 			logs.LogError(err, "Get Migrations Title Error")
 			return applied, err
 		}
 
-		applied = append(applied, title)
+		applied[title] = record
 	}
 
-	return applied, err
+	return applied, rows.Err()
 }
 
-// ApplyMigrations loops through the list of schema updates already applied to the
-// database. If an update has not already been applied, it is executed. Migrations
-// should be listed in chronological order, with the most recent appearing last.
-func ApplyMigrations() error {
-	var err error
-
-	applied, err := getAppliedMigrations()
+// verifyChecksums compares the recorded checksum of each applied migration with that
+// of its current source. Migrations applied before checksums were tracked have their
+// current checksum recorded. An error listing any edited migrations is returned.
+func verifyChecksums(ctx context.Context, conn *sql.Conn, applied map[string]appliedMigration) error {
+	var modified []string
 
-	if err != nil {
-		return err
-	}
+	for _, m := range registry {
+		record, ok := applied[m.title]
 
-	// Apply the migration from August 31, 2023
-	if !stringArrayContains(applied, mig20230831) {
-		fmt.Printf("Applying migration - %s\n", mig20230831)
+		if !ok {
+			continue
+		}
 
-		err = applyMigration20230831(mig20230831)
+		sum, err := m.checksum()
 
 		if err != nil {
+			logs.LogError(err, "Compute Migration Checksum Error")
 			return err
 		}
+
+		if !record.checksum.Valid {
+			_, err = conn.ExecContext(ctx, `UPDATE migrations SET checksum = $1 WHERE title = $2`, sum, m.title)
+
+			if err != nil {
+				logs.LogError(err, "Record Migration Checksum Error")
+				return err
+			}
+		} else if record.checksum.String != sum {
+			modified = append(modified, m.title)
+		}
 	}
 
-	// Apply the migration from September 26, 2023
-	if !stringArrayContains(applied, mig20230926) {
-		fmt.Printf("Applying migration - %s\n", mig20230926)
+	if len(modified) > 0 {
+		err := fmt.Errorf("applied migrations have been modified: %s", strings.Join(modified, ", "))
+		logs.LogError(err, "Migration Checksum Mismatch")
 
-		err = applyMigration20230926(mig20230926)
+		return err
+	}
+
+	return nil
+}
+
+// applyMigration runs a single migration and records it, along with its checksum.
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) error {
+	sum, err := m.checksum()
+
+	if err != nil {
+		logs.LogError(err, "Compute Migration Checksum Error")
+		return err
+	}
+
+	if m.legacy != nil {
+		err = m.legacy(m.title)
 
 		if err != nil {
 			return err
 		}
-	}
-
-	// Apply the migration from September 27, 2023
-	if !stringArrayContains(applied, mig20230927) {
-		fmt.Printf("Applying migration - %s\n", mig20230927)
 
-		err = applyMigration20230927(mig20230927)
+		_, err = conn.ExecContext(ctx, `UPDATE migrations SET checksum = $1 WHERE title = $2`, sum, m.title)
 
 		if err != nil {
-			return err
+			logs.LogError(err, "Record Migration Checksum Error")
 		}
+
+		return err
 	}
 
-	// Apply the migration from September 29, 2023
-	if !stringArrayContains(applied, mig20230929) {
-		fmt.Printf("Applying migration - %s\n", mig20230929)
+	tx, err := conn.BeginTx(ctx, nil)
 
-		err = applyMigration20230929(mig20230929)
+	if err != nil {
+		logs.LogError(err, "Begin Migration Transaction Error")
+		return err
+	}
 
-		if err != nil {
-			return err
-		}
+	// Rolling back is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	err = m.up(tx)
+
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO migrations( id, title, date_applied, checksum ) VALUES ( $1, $2, $3, $4 );`
+	_, err = tx.Exec(query, xid.New(), m.title, time.Now(), sum)
+
+	if err != nil {
+		logs.LogError(err, "Migration Registry Error")
+		return err
 	}
 
-	// Apply the migration from October 02, 2023
-	if !stringArrayContains(applied, mig20231002) {
-		fmt.Printf("Applying migration - %s\n", mig20231002)
+	return tx.Commit()
+}
 
-		err = applyMigration20231002(mig20231002)
+// ApplyMigrations applies, in order, any migration in the registry that has not yet been
+// applied to the database. It holds the migration lock throughout and refuses to continue
+// if any applied migration has been modified since it was run.
+func ApplyMigrations() error {
+	ctx := context.TODO()
 
-		if err != nil {
-			return err
-		}
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	conn, unlock, err := lockMigrations(ctx, pool)
+
+	if err != nil {
+		return err
 	}
 
-	// Apply the migration from October 10, 2023
-	if !stringArrayContains(applied, mig20231010) {
-		fmt.Printf("Applying migration - %s\n", mig20231010)
+	defer unlock()
 
-		err = applyMigration20231010(mig20231010)
+	applied, err := getAppliedMigrations(ctx, conn)
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return err
 	}
 
-	// Apply the migration from October 16, 2023
-	if !stringArrayContains(applied, mig20231016) {
-		fmt.Printf("Applying migration - %s\n", mig20231016)
+	err = verifyChecksums(ctx, conn, applied)
 
-		err = applyMigration20231016(mig20231016)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			return err
+	for _, m := range registry {
+		if _, ok := applied[m.title]; ok {
+			continue
 		}
-	}
 
-	// Apply the migration from October 23, 2023
-	if !stringArrayContains(applied, mig20231023) {
-		fmt.Printf("Applying migration - %s\n", mig20231023)
+		logs.Info("Applying migration", "migration", m.title)
 
-		err = applyMigration20231023(mig20231023)
+		err = applyMigration(ctx, conn, m)
 
 		if err != nil {
 			return err
 		}
 	}
 
-	// Apply the migration from October 24, 2023
-	if !stringArrayContains(applied, mig20231024) {
-		fmt.Printf("Applying migration - %s\n", mig20231024)
+	return nil
+}
 
-		err = applyMigration20231024(mig20231024)
+// RevertLastMigration runs the down step of the most recently applied migration and
+// removes it from the `migrations` table. Only migrations that define a down step
+// can be reverted. It returns the title of the reverted migration.
+func RevertLastMigration() (string, error) {
+	ctx := context.TODO()
 
-		if err != nil {
-			return err
-		}
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	conn, unlock, err := lockMigrations(ctx, pool)
+
+	if err != nil {
+		return "", err
 	}
 
-	// Apply the migration from October 30, 2023
-	if !stringArrayContains(applied, mig20231030) {
-		fmt.Printf("Applying migration - %s\n", mig20231030)
+	defer unlock()
 
-		err = applyMigration20231030(mig20231030)
+	applied, err := getAppliedMigrations(ctx, conn)
 
-		if err != nil {
-			return err
+	if err != nil {
+		return "", err
+	}
+
+	var last *migration
+
+	for i := len(registry) - 1; i >= 0; i-- {
+		if _, ok := applied[registry[i].title]; ok {
+			last = &registry[i]
+			break
 		}
 	}
 
-	// Apply the migration from November 16, 2023
-	if !stringArrayContains(applied, mig20231116) {
-		fmt.Printf("Applying migration - %s\n", mig20231116)
+	if last == nil {
+		return "", errors.New("no migrations have been applied")
+	} else if last.down == nil {
+		return last.title, fmt.Errorf("migration %s cannot be reverted", last.title)
+	}
+
+	logs.Info("Reverting migration", "migration", last.title)
 
-		err = applyMigration20231116(mig20231116)
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		logs.LogError(err, "Begin Migration Transaction Error")
+		return last.title, err
+	}
+
+	// Rolling back is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	err = last.down(tx)
+
+	if err != nil {
+		return last.title, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM migrations WHERE title = $1`, last.title)
+
+	if err != nil {
+		logs.LogError(err, "Migration Registry Error")
+		return last.title, err
+	}
+
+	return last.title, tx.Commit()
+}
+
+// GetMigrationStatus reports on every registered migration, indicating whether it has
+// been applied and whether it has been modified since. Applied migrations which are
+// missing from the registry are listed at the end.
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	ctx := context.TODO()
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	conn, unlock, err := lockMigrations(ctx, pool)
+
+	if err != nil {
+		return statuses, err
+	}
+
+	defer unlock()
+
+	applied, err := getAppliedMigrations(ctx, conn)
+
+	if err != nil {
+		return statuses, err
+	}
+
+	for _, m := range registry {
+		sum, err := m.checksum()
 
 		if err != nil {
-			return err
+			logs.LogError(err, "Compute Migration Checksum Error")
+			return statuses, err
 		}
+
+		status := MigrationStatus{
+			Title:      m.title,
+			Checksum:   sum,
+			Reversible: m.down != nil,
+		}
+
+		if record, ok := applied[m.title]; ok {
+			dateApplied := record.dateApplied
+
+			status.Applied = true
+			status.DateApplied = &dateApplied
+
+			status.Modified = record.checksum.Valid && record.checksum.String != sum
+
+			delete(applied, m.title)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	var unregistered []string
+
+	for title := range applied {
+		unregistered = append(unregistered, title)
+	}
+
+	sort.Strings(unregistered)
+
+	for _, title := range unregistered {
+		record := applied[title]
+		dateApplied := record.dateApplied
+
+		statuses = append(statuses, MigrationStatus{
+			Title:        title,
+			Applied:      true,
+			DateApplied:  &dateApplied,
+			Checksum:     record.checksum.String,
+			Unregistered: true,
+		})
 	}
 
-	return err
+	return statuses, nil
 }
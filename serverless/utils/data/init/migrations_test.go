@@ -0,0 +1,113 @@
+package init
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryOrder(t *testing.T) {
+	for i := 1; i < len(registry); i++ {
+		if registry[i-1].title >= registry[i].title {
+			t.Fatalf("registry lists %s before %s, want chronological order", registry[i-1].title, registry[i].title)
+		}
+	}
+}
+
+func TestRegistryEntries(t *testing.T) {
+	for _, m := range registry {
+		if (m.legacy == nil) == (m.up == nil) {
+			t.Fatalf("migration %s must define exactly one of legacy or up", m.title)
+		}
+
+		if m.legacy != nil && m.down != nil {
+			t.Fatalf("legacy migration %s cannot define a down step", m.title)
+		}
+
+		if m.source != "migration-"+m.title[:8]+".go" {
+			t.Fatalf("migration %s has source %s, want the file matching its date", m.title, m.source)
+		}
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	for _, m := range registry {
+		sum, err := m.checksum()
+		if len(sum) != 64 || err != nil {
+			t.Fatalf("checksum for %s returned %s/%v, want 64 character hash/nil", m.title, sum, err)
+		}
+
+		again, _ := m.checksum()
+		if sum != again {
+			t.Fatalf("checksum for %s returned %s then %s, want stable hash", m.title, sum, again)
+		}
+	}
+}
+
+func TestChecksumIgnoresFormatting(t *testing.T) {
+	original := "package init\n\nimport \"database/sql\"\n\nfunc up(tx *sql.Tx) error {\n\t_, err := tx.Exec(`DROP TABLE a;`)\n\treturn err\n}\n"
+	reformatted := "package init\n\nimport (\n\t\"database/sql\"\n\n\t\"github.com/IIP-Design/commons-gateway/utils/logs\"\n)\n\n// up drops a table.\nfunc up(tx *sql.Tx) error {\n\t_, err := tx.Exec(`DROP TABLE a;`) // no index\n\n\treturn err\n}\n"
+	edited := strings.Replace(original, "DROP TABLE a;", "DROP TABLE b;", 1)
+
+	sum, _ := checksumSource([]byte(original))
+	if again, _ := checksumSource([]byte(reformatted)); again != sum {
+		t.Fatalf("checksumSource returned %s for reformatted source, want %s", again, sum)
+	}
+
+	if changed, _ := checksumSource([]byte(edited)); changed == sum {
+		t.Fatal("checksumSource ignored a change to a statement")
+	}
+}
+
+func TestCompareSchemasMatch(t *testing.T) {
+	drift := compareSchemas(expectedSchema, expectedSchema, expectedViews, expectedViews)
+	if len(drift) != 0 {
+		t.Fatalf("compareSchemas returned %v, want no drift", drift)
+	}
+}
+
+func TestCompareSchemasDrift(t *testing.T) {
+	expected := map[string]map[string]string{
+		"teams": {"id": "character varying(20) not null", "team_name": "character varying(255) not null"},
+		"mfa":   {"code": "character varying(20) not null"},
+	}
+	actual := map[string]map[string]string{
+		"teams": {"id": "character varying(255) not null", "extra": "text"},
+		"stray": {"id": "integer"},
+	}
+
+	drift := compareSchemas(expected, actual, []string{"guest_auth_data"}, []string{})
+	want := []string{
+		"column teams.id is character varying(255) not null, expected character varying(20) not null",
+		"missing column teams.team_name",
+		"missing table mfa",
+		"missing view guest_auth_data",
+		"unexpected column teams.extra",
+		"unexpected table stray",
+	}
+
+	if len(drift) != len(want) {
+		t.Fatalf("compareSchemas returned %v, want %v", drift, want)
+	}
+
+	for i := range want {
+		if drift[i] != want[i] {
+			t.Fatalf("compareSchemas returned %v, want %v", drift, want)
+		}
+	}
+}
+
+func TestBaselineCoversExpectedTables(t *testing.T) {
+	for table := range expectedSchema {
+		found := false
+
+		for _, query := range baselineQueries {
+			if strings.HasPrefix(query, "CREATE TABLE "+table+" (") {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatalf("baseline does not create expected table %s", table)
+		}
+	}
+}
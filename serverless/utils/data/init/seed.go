@@ -1,62 +1,610 @@
 package init
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/IIP-Design/commons-gateway/utils/data/admins"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
-	"github.com/IIP-Design/commons-gateway/utils/data/teams"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/security/hashing"
+	"github.com/rs/xid"
 )
 
-func SeedDbRecord(rec []string) error {
-	switch rec[0] {
-	case "admins":
-		var admin data.User
+// The columns recognized in a seed file. Every row must identify the table it
+// seeds, the remaining columns are used as required by that table:
+//
+//	teams:  team, aprimo_name
+//	admins: email, first_name, last_name, role, team
+//	guests: email, first_name, last_name, role, team, expiration, inviter, proposer, pending
+//
+// Teams are referenced by name, so names shared by several existing teams are
+// rejected rather than resolved to an arbitrary one of them. Guests must have an inviter (an admin) or a proposer
+// (a guest admin), in which case the invitation is pending unless it has an inviter too.
+var seedColumns = []string{
+	"table", "email", "first_name", "last_name", "role", "team",
+	"aprimo_name", "expiration", "inviter", "proposer", "pending",
+}
 
-		team, err := teams.GetTeamIdByName(rec[1])
+// The order in which tables are seeded, so that references can be satisfied.
+var seedTableOrder = []string{"teams", "admins", "guests"}
 
-		if err != nil {
-			logs.LogError(err, "Admin Creation Error - Team Not Found")
-			return err
+// SeedRecord is a single row of a seed file, keyed by column name.
+type SeedRecord struct {
+	Row    int
+	Values map[string]string
+}
+
+// SeedResult describes the outcome of seeding a single row.
+type SeedResult struct {
+	Row    int    `json:"row"`
+	Table  string `json:"table"`
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SeedReport describes the outcome of seeding an entire file.
+type SeedReport struct {
+	Source  string       `json:"source"`
+	Applied bool         `json:"applied"`
+	Date    time.Time    `json:"date"`
+	Error   string       `json:"error,omitempty"`
+	Results []SeedResult `json:"results"`
+}
+
+// get returns the trimmed value of the given column.
+func (rec SeedRecord) get(column string) string {
+	return strings.TrimSpace(rec.Values[column])
+}
+
+// key returns the natural key of the record, used to identify it in the report.
+func (rec SeedRecord) key() string {
+	if rec.get("table") == "teams" {
+		return rec.get("team")
+	}
+
+	return rec.get("email")
+}
+
+// checkSeedColumns ensures that every column in a seed file is recognized.
+func checkSeedColumns(columns []string) error {
+	hasTable := false
+
+	for _, column := range columns {
+		known := false
+
+		for _, c := range seedColumns {
+			if column == c {
+				known = true
+			}
 		}
 
-		admin.Team = team
-		admin.Email = rec[2]
-		admin.NameFirst = rec[3]
-		admin.NameLast = rec[4]
-		admin.Role = rec[5]
+		if !known {
+			return fmt.Errorf("unrecognized seed column %s", column)
+		} else if column == "table" {
+			hasTable = true
+		}
+	}
+
+	if !hasTable {
+		return errors.New("seed files must have a table column")
+	}
+
+	return nil
+}
 
-		err = admins.CreateAdmin(admin)
+// parseSeedCSV reads a CSV seed file, the first row of which names the columns.
+func parseSeedCSV(content []byte) ([]SeedRecord, error) {
+	var records []SeedRecord
 
-		if err != nil {
-			logs.LogError(err, "Admin Creation Error")
-			return err
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return records, fmt.Errorf("unable to read seed header: %w", err)
+	}
+
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	if err = checkSeedColumns(header); err != nil {
+		return records, err
+	}
+
+	// Row numbers match the line numbers of the file, the header being line one.
+	row := 1
+
+	for {
+		fields, err := reader.Read()
+		row++
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return records, fmt.Errorf("unable to read seed row %d: %w", row, err)
 		}
-	case "teams":
-		err := teams.CreateTeam(rec[1], rec[6])
 
-		if err != nil {
-			logs.LogError(err, "Team Creation Error")
-			return err
+		rec := SeedRecord{Row: row, Values: map[string]string{}}
+
+		for i, column := range header {
+			if i < len(fields) {
+				rec.Values[column] = fields[i]
+			}
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// parseSeedJSON reads a JSON seed file, which contains an array of objects
+// whose properties are the seed columns.
+func parseSeedJSON(content []byte) ([]SeedRecord, error) {
+	var records []SeedRecord
+	var rows []map[string]any
+
+	if err := json.Unmarshal(content, &rows); err != nil {
+		return records, fmt.Errorf("unable to read seed file: %w", err)
+	}
+
+	for i, row := range rows {
+		rec := SeedRecord{Row: i + 1, Values: map[string]string{}}
+		var columns []string
+
+		for column, value := range row {
+			column = strings.ToLower(column)
+			columns = append(columns, column)
+
+			switch v := value.(type) {
+			case nil:
+			case string:
+				rec.Values[column] = v
+			case bool:
+				rec.Values[column] = strconv.FormatBool(v)
+			default:
+				return records, fmt.Errorf("seed row %d has a non-string value for %s", rec.Row, column)
+			}
+		}
+
+		if err := checkSeedColumns(columns); err != nil {
+			return records, fmt.Errorf("seed row %d: %w", rec.Row, err)
 		}
 
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// ParseSeedFile reads the records from a CSV or JSON seed file, based on its extension.
+func ParseSeedFile(name string, content []byte) ([]SeedRecord, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return parseSeedCSV(content)
+	case ".json":
+		return parseSeedJSON(content)
 	default:
-		fmt.Printf("No case for table %s", rec[0])
+		return nil, fmt.Errorf("unsupported seed file type %s", path.Ext(name))
+	}
+}
+
+// parseSeedExpiration accepts either a full RFC 3339 timestamp or a plain date.
+func parseSeedExpiration(value string) (time.Time, error) {
+	if expires, err := time.Parse(time.RFC3339, value); err == nil {
+		return expires, nil
+	}
+
+	return time.Parse("2006-01-02", value)
+}
+
+// seedState tracks the teams, admins, and guest admins that seeded rows may refer to,
+// whether they already exist in the database or are created by the seed file itself.
+type seedState struct {
+	teams          map[string]bool
+	ambiguousTeams map[string]bool
+	admins         map[string]bool
+	guestAdmins    map[string]bool
+	seen           map[string]bool
+}
+
+// loadSeedState reads the teams, admins, and guest admins already present in the database.
+func loadSeedState(pool *sql.DB) (seedState, error) {
+	state := seedState{
+		teams:          map[string]bool{},
+		ambiguousTeams: map[string]bool{},
+		admins:         map[string]bool{},
+		guestAdmins:    map[string]bool{},
+		seen:           map[string]bool{},
+	}
+
+	queries := []struct {
+		query string
+		set   map[string]bool
+	}{
+		{`SELECT team_name FROM teams`, state.teams},
+		{`SELECT team_name FROM teams GROUP BY team_name HAVING COUNT(*) > 1`, state.ambiguousTeams},
+		{`SELECT email FROM admins`, state.admins},
+		{`SELECT email FROM guests WHERE role = 'guest admin'`, state.guestAdmins},
+	}
+
+	for _, q := range queries {
+		rows, err := pool.Query(q.query)
+
+		if err != nil {
+			logs.LogError(err, "Load Seed State Query Error")
+			return state, err
+		}
+
+		for rows.Next() {
+			var value string
+
+			if err = rows.Scan(&value); err != nil {
+				rows.Close()
+				logs.LogError(err, "Load Seed State Scan Error")
+
+				return state, err
+			}
+
+			q.set[value] = true
+		}
+
+		rows.Close()
+	}
+
+	return state, nil
+}
+
+// validateSeedRecord checks a single record, returning a description of the first problem found.
+func validateSeedRecord(rec SeedRecord, state seedState) error {
+	table := rec.get("table")
+
+	required := map[string][]string{
+		"teams":  {"team", "aprimo_name"},
+		"admins": {"email", "first_name", "last_name", "role", "team"},
+		"guests": {"email", "first_name", "last_name", "team", "expiration"},
+	}
+
+	columns, ok := required[table]
+
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+
+	for _, column := range columns {
+		if rec.get(column) == "" {
+			return fmt.Errorf("missing %s", column)
+		}
+	}
+
+	if state.seen[table+"/"+rec.key()] {
+		return fmt.Errorf("duplicate %s row for %s", table, rec.key())
+	} else if state.ambiguousTeams[rec.get("team")] {
+		return fmt.Errorf("team name %s is shared by several teams", rec.get("team"))
+	}
+
+	if table == "teams" {
+		return nil
+	}
+
+	if !strings.Contains(rec.get("email"), "@") {
+		return fmt.Errorf("invalid email %s", rec.get("email"))
+	} else if !state.teams[rec.get("team")] {
+		return fmt.Errorf("unknown team %s", rec.get("team"))
+	}
+
+	if table == "admins" {
+		if role := rec.get("role"); role != "super admin" && role != "admin" {
+			return fmt.Errorf("invalid admin role %s", role)
+		}
+
+		return nil
+	}
+
+	if role := rec.get("role"); role != "" && role != "guest" && role != "guest admin" {
+		return fmt.Errorf("invalid guest role %s", role)
+	}
+
+	if _, err := parseSeedExpiration(rec.get("expiration")); err != nil {
+		return fmt.Errorf("invalid expiration %s", rec.get("expiration"))
+	}
+
+	if pending := rec.get("pending"); pending != "" {
+		if _, err := strconv.ParseBool(pending); err != nil {
+			return fmt.Errorf("invalid pending value %s", pending)
+		}
+	}
+
+	inviter := rec.get("inviter")
+	proposer := rec.get("proposer")
+
+	if inviter == "" && proposer == "" {
+		return errors.New("guests require an inviter or proposer")
+	} else if inviter != "" && !state.admins[inviter] {
+		return fmt.Errorf("inviter %s is not an admin", inviter)
+	} else if proposer != "" && !state.guestAdmins[proposer] {
+		return fmt.Errorf("proposer %s is not a guest admin", proposer)
 	}
 
 	return nil
 }
 
-// Return true if already seeded, false otherwise
-// All other errors are logged but not acted on, which is not good
-func SeedForTest(records [][]string) bool {
+// validateSeedRecords checks every record, returning a result for each and whether
+// all of them are valid. Records may refer to teams, admins, and guest admins that are
+// defined anywhere in the seed file, so those are collected before validating.
+func validateSeedRecords(records []SeedRecord, state seedState) ([]SeedResult, bool) {
+	var results []SeedResult
+
+	valid := true
+
 	for _, rec := range records {
-		err := SeedDbRecord(rec)
-		if err != nil {
-			return true
+		switch rec.get("table") {
+		case "teams":
+			state.teams[rec.get("team")] = true
+		case "admins":
+			state.admins[rec.key()] = true
+		case "guests":
+			if rec.get("role") == "guest admin" {
+				state.guestAdmins[rec.key()] = true
+			}
+		}
+	}
+
+	for _, rec := range records {
+		result := SeedResult{Row: rec.Row, Table: rec.get("table"), Key: rec.key(), Status: "valid"}
+
+		if err := validateSeedRecord(rec, state); err != nil {
+			result.Status = "invalid"
+			result.Error = err.Error()
+			valid = false
+		}
+
+		state.seen[result.Table+"/"+result.Key] = true
+
+		results = append(results, result)
+	}
+
+	return results, valid
+}
+
+// upsertTeam creates or updates a team, identified by its name.
+func upsertTeam(tx *sql.Tx, rec SeedRecord, now time.Time) (bool, error) {
+	result, err := tx.Exec(
+		`UPDATE teams SET aprimo_name = $1, date_modified = $2 WHERE team_name = $3`,
+		rec.get("aprimo_name"), now, rec.get("team"),
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO teams( id, team_name, aprimo_name, active, date_created, date_modified )
+		 VALUES ( $1, $2, $3, TRUE, $4, $4 )`,
+		xid.New(), rec.get("team"), rec.get("aprimo_name"), now,
+	)
+
+	return true, err
+}
+
+// addSeededUser ensures that a seeded user appears in the list of all users.
+func addSeededUser(tx *sql.Tx, column string, email string) error {
+	_, err := tx.Exec(
+		`INSERT INTO all_users( user_id, `+column+` )
+		 SELECT $1, $2 WHERE NOT EXISTS ( SELECT 1 FROM all_users WHERE `+column+` = $2 )`,
+		xid.New(), email,
+	)
+
+	return err
+}
+
+// upsertAdmin creates or updates an admin, identified by their email.
+func upsertAdmin(tx *sql.Tx, rec SeedRecord, now time.Time) (bool, error) {
+	var created bool
+
+	err := tx.QueryRow(
+		`INSERT INTO admins( email, first_name, last_name, role, team, active, date_created, date_modified )
+		 VALUES ( $1, $2, $3, $4, ( SELECT id FROM teams WHERE team_name = $5 ), TRUE, $6, $6 )
+		 ON CONFLICT ( email ) DO UPDATE SET first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name,
+		 role = EXCLUDED.role, team = EXCLUDED.team, date_modified = EXCLUDED.date_modified
+		 RETURNING ( xmax = 0 )`,
+		rec.key(), rec.get("first_name"), rec.get("last_name"), rec.get("role"), rec.get("team"), now,
+	).Scan(&created)
+
+	if err != nil {
+		return false, err
+	}
+
+	return created, addSeededUser(tx, "admin_id", rec.key())
+}
+
+// upsertGuest creates or updates a guest, identified by their email, along with their
+// most recent invitation. Newly invited guests are issued a random password, which is
+// never disclosed, so an admin must reset it before the guest can log in.
+func upsertGuest(tx *sql.Tx, rec SeedRecord, now time.Time) (bool, error) {
+	var created bool
+
+	role := rec.get("role")
+
+	if role == "" {
+		role = "guest"
+	}
+
+	err := tx.QueryRow(
+		`INSERT INTO guests( email, first_name, last_name, role, team, date_created, date_modified )
+		 VALUES ( $1, $2, $3, $4, ( SELECT id FROM teams WHERE team_name = $5 ), $6, $6 )
+		 ON CONFLICT ( email ) DO UPDATE SET first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name,
+		 role = EXCLUDED.role, team = EXCLUDED.team, date_modified = EXCLUDED.date_modified
+		 RETURNING ( xmax = 0 )`,
+		rec.key(), rec.get("first_name"), rec.get("last_name"), role, rec.get("team"), now,
+	).Scan(&created)
+
+	if err != nil {
+		return false, err
+	}
+
+	if err = addSeededUser(tx, "guest_id", rec.key()); err != nil {
+		return created, err
+	}
+
+	expires, _ := parseSeedExpiration(rec.get("expiration"))
+
+	inviter := sql.NullString{String: rec.get("inviter"), Valid: rec.get("inviter") != ""}
+	proposer := sql.NullString{String: rec.get("proposer"), Valid: rec.get("proposer") != ""}
+
+	// Proposals remain pending until approved by an admin, unless stated otherwise.
+	pending := !inviter.Valid
+
+	if rec.get("pending") != "" {
+		pending, _ = strconv.ParseBool(rec.get("pending"))
+	}
+
+	result, err := tx.Exec(
+		`UPDATE invites SET inviter = $1, proposer = $2, pending = $3, expiration = $4
+		 WHERE invitee = $5 AND date_invited = ( SELECT MAX(date_invited) FROM invites WHERE invitee = $5 )`,
+		inviter, proposer, pending, expires, rec.key(),
+	)
+
+	if err != nil {
+		return created, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return created, nil
+	}
+
+	pass, salt := hashing.GenerateCredentials()
+	hash := hashing.GenerateHash(pass, salt)
+
+	_, err = tx.Exec(
+		`INSERT INTO invites( invitee, inviter, proposer, pending, date_invited, pass_hash, salt, expiration, password_reset, first_login )
+		 VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, TRUE, TRUE )`,
+		rec.key(), inviter, proposer, pending, now, hash, salt, expires,
+	)
+
+	return created, err
+}
+
+// applySeedRecords upserts every record within a single transaction, seeding the tables
+// in dependency order. If any record fails, the whole transaction is rolled back.
+func applySeedRecords(pool *sql.DB, records []SeedRecord, results []SeedResult) error {
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Seed Transaction Error")
+		return err
+	}
+
+	// Rolling back is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	upserts := map[string]func(*sql.Tx, SeedRecord, time.Time) (bool, error){
+		"teams":  upsertTeam,
+		"admins": upsertAdmin,
+		"guests": upsertGuest,
+	}
+
+	// Guest admins are seeded before other guests, since they may propose them.
+	ordered := func(rec SeedRecord, table string, pass int) bool {
+		if rec.get("table") != table {
+			return false
+		} else if table != "guests" {
+			return pass == 0
 		}
+
+		return (rec.get("role") == "guest admin") == (pass == 0)
 	}
 
-	return false
+	for _, table := range seedTableOrder {
+		for pass := 0; pass < 2; pass++ {
+			for i, rec := range records {
+				if !ordered(rec, table, pass) {
+					continue
+				}
+
+				created, err := upserts[table](tx, rec, now)
+
+				if err != nil {
+					logs.LogError(err, "Seed Record Error")
+
+					results[i].Status = "failed"
+					results[i].Error = err.Error()
+
+					return err
+				}
+
+				if created {
+					results[i].Status = "created"
+				} else {
+					results[i].Status = "updated"
+				}
+			}
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		logs.LogError(err, "Commit Seed Transaction Error")
+	}
+
+	return err
+}
+
+// SeedDatabase validates every record in a seed file and, only if they are all valid,
+// upserts them in a single transaction. Seeding the same file repeatedly is safe. The
+// returned report describes the outcome for each row.
+func SeedDatabase(source string, records []SeedRecord) (SeedReport, error) {
+	report := SeedReport{Source: source, Date: time.Now()}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	state, err := loadSeedState(pool)
+
+	if err != nil {
+		return report, err
+	}
+
+	results, valid := validateSeedRecords(records, state)
+	report.Results = results
+
+	if !valid {
+		return report, errors.New("seed file contains invalid rows, no changes were made")
+	}
+
+	err = applySeedRecords(pool, records, report.Results)
+
+	if err != nil {
+		// Nothing was saved, so mark the rows that appeared to succeed accordingly.
+		for i := range report.Results {
+			if report.Results[i].Status == "created" || report.Results[i].Status == "updated" {
+				report.Results[i].Status = "rolled back"
+			}
+		}
+
+		return report, err
+	}
+
+	report.Applied = true
+
+	return report, nil
 }
@@ -0,0 +1,131 @@
+package init
+
+import (
+	"testing"
+)
+
+const seedCSV = `table,team,aprimo_name,email,first_name,last_name,role,expiration,inviter,proposer,pending
+teams,Fox,GPAVideo,,,,,,,,
+admins,Fox,,admin@test.test,Ad,Min,admin,,,,
+guests,Fox,,lead@test.test,Guest,Lead,guest admin,2030-01-01,admin@test.test,,
+guests,Fox,,proposed@test.test,Pro,Posed,guest,2030-01-01T00:00:00Z,,lead@test.test,
+`
+
+const seedJSON = `[
+  {"table": "teams", "team": "Fox", "aprimo_name": "GPAVideo"},
+  {"table": "guests", "team": "Fox", "email": "guest@test.test", "first_name": "A", "last_name": "B",
+   "expiration": "2030-01-01", "inviter": "admin@test.test", "pending": false}
+]`
+
+func emptySeedState() seedState {
+	return seedState{
+		teams:          map[string]bool{},
+		ambiguousTeams: map[string]bool{},
+		admins:         map[string]bool{"admin@test.test": true},
+		guestAdmins:    map[string]bool{},
+		seen:           map[string]bool{},
+	}
+}
+
+func TestParseSeedCSV(t *testing.T) {
+	records, err := ParseSeedFile("seed/initial.csv", []byte(seedCSV))
+	if err != nil || len(records) != 4 {
+		t.Fatalf("ParseSeedFile returned %d records/%v, want 4/nil", len(records), err)
+	}
+
+	if records[1].Row != 3 || records[1].key() != "admin@test.test" || records[0].key() != "Fox" {
+		t.Fatalf("ParseSeedFile returned %+v, want rows numbered by line and keyed", records)
+	}
+}
+
+func TestParseSeedJSON(t *testing.T) {
+	records, err := ParseSeedFile("seed/initial.json", []byte(seedJSON))
+	if err != nil || len(records) != 2 || records[1].get("pending") != "false" {
+		t.Fatalf("ParseSeedFile returned %+v/%v, want 2 records/nil", records, err)
+	}
+}
+
+func TestParseSeedUnknownColumn(t *testing.T) {
+	_, err := ParseSeedFile("seed/initial.csv", []byte("table,password\nguests,secret\n"))
+	if err == nil {
+		t.Fatalf("ParseSeedFile returned nil error, want unrecognized column error")
+	}
+}
+
+func TestParseSeedUnknownType(t *testing.T) {
+	_, err := ParseSeedFile("seed/initial.txt", []byte(seedCSV))
+	if err == nil {
+		t.Fatalf("ParseSeedFile returned nil error, want unsupported type error")
+	}
+}
+
+func TestValidateSeedRecords(t *testing.T) {
+	records, _ := ParseSeedFile("seed/initial.csv", []byte(seedCSV))
+
+	results, valid := validateSeedRecords(records, emptySeedState())
+	if !valid {
+		t.Fatalf("validateSeedRecords returned %+v, want all valid", results)
+	}
+}
+
+func TestValidateSeedRecordsInvalid(t *testing.T) {
+	content := `table,team,email,first_name,last_name,role,expiration,inviter,proposer
+guests,Nowhere,a@test.test,A,B,guest,2030-01-01,admin@test.test,
+guests,,b@test.test,A,B,guest,2030-01-01,admin@test.test,
+admins,Nowhere,c@test.test,A,B,owner,,,
+guests,Nowhere,d@test.test,A,B,guest,soon,admin@test.test,
+guests,Nowhere,e@test.test,A,B,guest,2030-01-01,,f@test.test
+guests,Nowhere,g@test.test,A,B,guest,2030-01-01,,
+teams,Nowhere,,,,,,,
+`
+	records, _ := ParseSeedFile("seed/invalid.csv", []byte(content))
+
+	state := emptySeedState()
+	results, valid := validateSeedRecords(records, state)
+	if valid {
+		t.Fatalf("validateSeedRecords returned valid, want invalid rows")
+	}
+
+	want := []string{
+		"valid",
+		"invalid",
+		"invalid",
+		"invalid",
+		"invalid",
+		"invalid",
+		"invalid",
+	}
+
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Fatalf("row %d has status %s (%s), want %s", result.Row, result.Status, result.Error, want[i])
+		}
+	}
+}
+
+func TestValidateSeedRecordsDuplicate(t *testing.T) {
+	content := "table,team,aprimo_name\nteams,Fox,A\nteams,Fox,B\n"
+	records, _ := ParseSeedFile("seed/duplicate.csv", []byte(content))
+
+	results, valid := validateSeedRecords(records, emptySeedState())
+	if valid || results[1].Status != "invalid" {
+		t.Fatalf("validateSeedRecords returned %+v, want duplicate row invalid", results)
+	}
+}
+
+func TestValidateSeedRecordsAmbiguousTeam(t *testing.T) {
+	content := `table,team,aprimo_name,email,first_name,last_name,role
+teams,Fox,GPAVideo,,,,
+admins,Fox,,c@test.test,A,B,admin
+`
+	records, _ := ParseSeedFile("seed/ambiguous.csv", []byte(content))
+
+	state := emptySeedState()
+	state.teams["Fox"] = true
+	state.ambiguousTeams["Fox"] = true
+
+	results, valid := validateSeedRecords(records, state)
+	if valid || results[0].Status != "invalid" || results[1].Status != "invalid" {
+		t.Fatalf("validateSeedRecords returned %+v, want rows naming a shared team invalid", results)
+	}
+}
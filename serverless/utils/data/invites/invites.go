@@ -1,6 +1,7 @@
 package invites
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
@@ -8,10 +9,11 @@ import (
 	"github.com/rs/xid"
 )
 
-// saveInvite opens a database connection and records the association between an admin
-// user inviter and a guest user invitee along with the date of the invitation.
+// SaveInvite records the association between an admin user inviter and a guest user
+// invitee along with the date of the invitation, as part of the provided transaction.
 // TODO: Document
 func SaveInvite(
+	tx *sql.Tx,
 	adminEmail string,
 	guestEmail string,
 	expires time.Time,
@@ -23,21 +25,18 @@ func SaveInvite(
 ) error {
 	var err error
 
-	pool := data.ConnectToDB()
-	defer pool.Close()
-
 	currentTime := time.Now()
 
 	if setPending {
 		insertInvite :=
 			`INSERT INTO invites( invitee, proposer, pending, date_invited, pass_hash, salt, expiration, password_reset, first_login )
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
-		_, err = pool.Exec(insertInvite, guestEmail, adminEmail, setPending, currentTime, hash, salt, expires, setPasswordReset, firstLogin)
+		_, err = tx.Exec(insertInvite, guestEmail, adminEmail, setPending, currentTime, hash, salt, expires, setPasswordReset, firstLogin)
 	} else {
 		insertInvite :=
 			`INSERT INTO invites( invitee, inviter, pending, date_invited, pass_hash, salt, expiration, password_reset, first_login )
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
-		_, err = pool.Exec(insertInvite, guestEmail, adminEmail, setPending, currentTime, hash, salt, expires, setPasswordReset, firstLogin)
+		_, err = tx.Exec(insertInvite, guestEmail, adminEmail, setPending, currentTime, hash, salt, expires, setPasswordReset, firstLogin)
 	}
 
 	if err != nil {
@@ -47,33 +46,31 @@ func SaveInvite(
 	return err
 }
 
-// SaveCredentials opens a database connection and saves the provided user credentials
-// to the `credentials` table. Specifically, it stores the the user email, a hash of
+// SaveCredentials saves the provided user credentials to the `credentials` table as
+// part of the provided transaction. Specifically, it stores the the user email, a hash of
 // their password, and the salt with which the password was hashed, as well as the date
 // on which the password was generated.
 // TODO: Document
-func SaveCredentials(guest data.User) error {
+func SaveCredentials(tx *sql.Tx, guest data.User) error {
 	var err error
 
-	pool := data.ConnectToDB()
-	defer pool.Close()
-
 	currentTime := time.Now()
 
 	insertCreds :=
-		`INSERT INTO guests( email, first_name, last_name, role, team, date_created, date_modified )
-		 VALUES ($1, $2, $3, $4, $5, $6, $7);`
-	_, err = pool.Exec(insertCreds, guest.Email, guest.NameFirst, guest.NameLast, guest.Role, guest.Team, currentTime, currentTime)
+		`INSERT INTO guests( email, first_name, last_name, role, team, date_created, date_modified, locale )
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE( NULLIF( $8, '' ), 'en' ));`
+	_, err = tx.Exec(insertCreds, guest.Email, guest.NameFirst, guest.NameLast, guest.Role, guest.Team, currentTime, currentTime, guest.Locale)
 
 	if err != nil {
 		logs.LogError(err, "Save Credentials Query Error")
+		return err
 	}
 
 	// Add the guest to the list of all users
 	guid := xid.New()
 
 	insertAllUsers := `INSERT INTO all_users( user_id, guest_id ) VALUES ( $1, $2 );`
-	_, err = pool.Exec(insertAllUsers, guid, guest.Email)
+	_, err = tx.Exec(insertAllUsers, guid, guest.Email)
 
 	if err != nil {
 		logs.LogError(err, "Add Guest to All Users Query Error")
@@ -81,3 +78,26 @@ func SaveCredentials(guest data.User) error {
 
 	return err
 }
+
+// ReinstateCredentials restores a previously archived or rejected guest user as part of
+// the provided transaction, updating their personal data with the values provided in the
+// new invitation. Any lock and failed login attempts from before they were archived are
+// cleared, so that the new invitation starts afresh.
+func ReinstateCredentials(tx *sql.Tx, guest data.User) error {
+	var err error
+
+	currentTime := time.Now()
+
+	query :=
+		`UPDATE guests SET first_name = $1, last_name = $2, role = $3, team = $4, date_modified = $5,
+		 archived_at = NULL, archived_by = NULL, archive_reason = NULL, locale = COALESCE( NULLIF( $7, '' ), locale ),
+		 locked = false, login_attempt = 0, login_date = NULL
+		 WHERE email = $6;`
+	_, err = tx.Exec(query, guest.NameFirst, guest.NameLast, guest.Role, guest.Team, currentTime, guest.Email, guest.Locale)
+
+	if err != nil {
+		logs.LogError(err, "Reinstate Credentials Query Error")
+	}
+
+	return err
+}
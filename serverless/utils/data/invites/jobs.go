@@ -0,0 +1,165 @@
+package invites
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/rs/xid"
+)
+
+// The possible states of a single row in a bulk invitation job.
+const (
+	JobRowPending = "pending"
+	JobRowQueued  = "queued"
+	JobRowSent    = "sent"
+	JobRowFailed  = "failed"
+)
+
+// InviteJobRow records the outcome of a single row in a bulk invitation CSV file.
+type InviteJobRow struct {
+	Row          int       `json:"row"`
+	Email        string    `json:"email"`
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason,omitempty"`
+	DateModified time.Time `json:"dateModified"`
+}
+
+// InviteJob describes a bulk invitation along with the outcome of each of its rows.
+type InviteJob struct {
+	Id          string         `json:"id"`
+	Team        string         `json:"team"`
+	CreatedBy   string         `json:"createdBy"`
+	DateCreated time.Time      `json:"dateCreated"`
+	Total       int            `json:"total"`
+	Summary     map[string]int `json:"summary"`
+	Rows        []InviteJobRow `json:"rows"`
+}
+
+// CreateInviteJob opens a database connection and records a new bulk invitation job
+// along with the initial status of each of its rows. It returns the id of the job.
+func CreateInviteJob(team string, createdBy string, rows []InviteJobRow) (string, error) {
+	jobId := xid.New().String()
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Invite Job Transaction Error")
+		return jobId, err
+	}
+
+	defer tx.Rollback()
+
+	currentTime := time.Now()
+
+	query :=
+		`INSERT INTO invite_jobs( id, team, created_by, date_created, total )
+		 VALUES ( $1, $2, $3, $4, $5 );`
+	_, err = tx.Exec(query, jobId, team, createdBy, currentTime, len(rows))
+
+	if err != nil {
+		logs.LogError(err, "Create Invite Job Query Error")
+		return jobId, err
+	}
+
+	for _, row := range rows {
+		query =
+			`INSERT INTO invite_job_rows( job_id, row_number, email, status, reason, date_modified )
+			 VALUES ( $1, $2, $3, $4, NULLIF( $5, '' ), $6 );`
+		_, err = tx.Exec(query, jobId, row.Row, row.Email, row.Status, row.Reason, currentTime)
+
+		if err != nil {
+			logs.LogError(err, "Create Invite Job Row Query Error")
+			return jobId, err
+		}
+	}
+
+	return jobId, tx.Commit()
+}
+
+// UpdateInviteJobRow opens a database connection and sets the status of a single row
+// within a bulk invitation job. The reason should be empty unless the row has failed.
+func UpdateInviteJobRow(jobId string, row int, status string, reason string) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`UPDATE invite_job_rows SET status = $1, reason = NULLIF( $2, '' ), date_modified = $3
+		 WHERE job_id = $4 AND row_number = $5;`
+	_, err := pool.Exec(query, status, reason, time.Now(), jobId, row)
+
+	if err != nil {
+		logs.LogError(err, "Update Invite Job Row Query Error")
+	}
+
+	return err
+}
+
+// SetJobRowEmail records the outbox email carrying the credentials for a single row within
+// a bulk invitation job, as part of the transaction which enqueues it. It returns
+// audit.ErrNoChange if an email had already been recorded for the row.
+func SetJobRowEmail(tx *sql.Tx, jobId string, row int, emailId string) error {
+	query :=
+		`UPDATE invite_job_rows SET email_id = $1, status = $2, reason = NULL, date_modified = $3
+		 WHERE job_id = $4 AND row_number = $5 AND email_id IS NULL;`
+
+	return audit.ExecChange(tx, "Set Invite Job Row Email Query Error", query, emailId, JobRowQueued, time.Now(), jobId, row)
+}
+
+// RetrieveInviteJob opens a database connection and retrieves a bulk invitation job
+// with the status of each of its rows. The status of a row whose credentials email is
+// in the outbox follows the delivery of that email. It returns false if no such job exists.
+func RetrieveInviteJob(jobId string) (InviteJob, bool, error) {
+	job := InviteJob{Summary: map[string]int{}, Rows: []InviteJobRow{}}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query := `SELECT id, team, created_by, date_created, total FROM invite_jobs WHERE id = $1;`
+	err := pool.QueryRow(query, jobId).Scan(&job.Id, &job.Team, &job.CreatedBy, &job.DateCreated, &job.Total)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return job, false, nil
+		}
+
+		logs.LogError(err, "Retrieve Invite Job Query Error")
+		return job, false, err
+	}
+
+	query =
+		`SELECT r.row_number, r.email,
+		 CASE WHEN o.status = $2 THEN $3 WHEN o.status = $4 THEN $5 ELSE r.status END,
+		 COALESCE( r.reason, CASE WHEN o.status = $4 THEN 'email delivery failed' END, '' ),
+		 GREATEST( r.date_modified, o.date_sent )
+		 FROM invite_job_rows r LEFT JOIN email_outbox o ON o.id = r.email_id
+		 WHERE r.job_id = $1 ORDER BY r.row_number;`
+	rows, err := pool.Query(query, jobId, outbox.StatusSent, JobRowSent, outbox.StatusFailed, JobRowFailed)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Invite Job Rows Query Error")
+		return job, true, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var row InviteJobRow
+
+		if err := rows.Scan(&row.Row, &row.Email, &row.Status, &row.Reason, &row.DateModified); err != nil {
+			logs.LogError(err, "Retrieve Invite Job Rows Scan Error")
+			return job, true, err
+		}
+
+		job.Summary[row.Status]++
+		job.Rows = append(job.Rows, row)
+	}
+
+	return job, true, rows.Err()
+}
@@ -0,0 +1,129 @@
+package logins
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// The reasons for which an authentication attempt may fail.
+const (
+	ReasonArchived   = "archived"
+	ReasonBadHash    = "bad_hash"
+	ReasonBadMFA     = "bad_mfa"
+	ReasonCaptcha    = "captcha_failure"
+	ReasonExpired    = "expired"
+	ReasonLocked     = "locked"
+	ReasonUnapproved = "unapproved"
+)
+
+// HistoryLimit is the number of recent attempts included in a guest's login history.
+const HistoryLimit = 20
+
+// Attempt describes a single attempt by a guest user to authenticate.
+type Attempt struct {
+	Email       string    `json:"-"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+	Success     bool      `json:"success"`
+	Reason      string    `json:"reason,omitempty"`
+	SourceIP    string    `json:"sourceIp"`
+	UserAgent   string    `json:"userAgent"`
+}
+
+// Failed returns a copy of the attempt marked as having failed for the given reason.
+func (a Attempt) Failed(reason string) Attempt {
+	a.Success = false
+	a.Reason = reason
+
+	return a
+}
+
+// Succeeded returns a copy of the attempt marked as successful.
+func (a Attempt) Succeeded() Attempt {
+	a.Success = true
+	a.Reason = ""
+
+	return a
+}
+
+// RecordAttempt opens a database connection and saves an authentication attempt
+// to the guest's login history. Attempts naming an address which does not belong to
+// a guest are discarded, so that personal data is not kept about whoever is named
+// in an arbitrary login request.
+func RecordAttempt(attempt Attempt) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`INSERT INTO login_attempts( email, attempted_at, success, reason, source_ip, user_agent )
+		 SELECT $1, $2::TIMESTAMP, $3::BOOLEAN, NULLIF( $4::TEXT, '' ), NULLIF( $5::TEXT, '' ),
+		 NULLIF( $6::TEXT, '' )
+		 WHERE EXISTS ( SELECT 1 FROM guests WHERE email = $1 );`
+	_, err := pool.Exec(
+		query,
+		attempt.Email,
+		time.Now(),
+		attempt.Success,
+		attempt.Reason,
+		attempt.SourceIP,
+		attempt.UserAgent,
+	)
+
+	if err != nil {
+		logs.LogError(err, "Record Login Attempt Query Error")
+	}
+
+	return err
+}
+
+// RetrieveHistory opens a database connection and retrieves a guest's most recent
+// authentication attempts, newest first, along with the time of their last successful
+// login. The last login is nil if the guest has never successfully logged in.
+func RetrieveHistory(email string) ([]Attempt, *time.Time, error) {
+	history := []Attempt{}
+	var lastLogin sql.NullTime
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query := `SELECT MAX( attempted_at ) FROM login_attempts WHERE email = $1 AND success;`
+	err := pool.QueryRow(query, email).Scan(&lastLogin)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Last Login Query Error")
+		return history, nil, err
+	}
+
+	query =
+		`SELECT attempted_at, success, COALESCE( reason, '' ), COALESCE( source_ip, '' ), COALESCE( user_agent, '' )
+		 FROM login_attempts WHERE email = $1 ORDER BY attempted_at DESC LIMIT $2;`
+	rows, err := pool.Query(query, email, HistoryLimit)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Login History Query Error")
+		return history, nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		attempt := Attempt{Email: email}
+
+		err = rows.Scan(&attempt.AttemptedAt, &attempt.Success, &attempt.Reason, &attempt.SourceIP, &attempt.UserAgent)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Login History Scan Error")
+			return history, nil, err
+		}
+
+		history = append(history, attempt)
+	}
+
+	if !lastLogin.Valid {
+		return history, nil, rows.Err()
+	}
+
+	return history, &lastLogin.Time, rows.Err()
+}
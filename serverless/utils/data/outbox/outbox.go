@@ -0,0 +1,512 @@
+// Package outbox stores the emails generated by the gateway until they are delivered.
+//
+// Emails are enqueued within the same transaction as the change that prompts them, so
+// that an email is never lost once the change has been committed, nor sent for a change
+// which was rolled back. The email dispatcher then delivers each pending email, retrying
+// with an exponential backoff before giving up and marking it as failed. Admins may
+// resend failed emails, which returns them to the queue.
+//
+// The content of queued emails is encrypted, since it may include a temporary password,
+// and is discarded once the email is sent. The content of failed emails which include a
+// temporary password is discarded as well, so these cannot be resent; the guest's
+// password should be reset instead, which issues a new email.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/xid"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// The delivery statuses of an email.
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+const (
+	// MaxAttempts is the number of times delivery is attempted before an email is failed.
+	MaxAttempts = 6
+	// BatchSize is the number of emails claimed by each run of the dispatcher.
+	BatchSize = 25
+	// HistoryLimit is the number of recent emails included in a guest's details.
+	HistoryLimit = 20
+	// Lease is how long a claimed email is held by a dispatcher. Emails still being sent
+	// after the lease expires, such as when a dispatcher times out, are claimed again.
+	Lease = 5 * time.Minute
+)
+
+// The delay before the first retry, which doubles with each attempt up to maxBackoff.
+const (
+	baseBackoff = time.Minute
+	maxBackoff  = time.Hour
+)
+
+// errorLimit is the length at which delivery errors are truncated.
+const errorLimit = 1000
+
+// ErrorSuppressed is recorded against emails which are not sent because their recipient's
+// address previously bounced or its owner complained.
+const ErrorSuppressed = "recipient address is suppressed"
+
+var (
+	ErrNotFailed = errors.New("only failed emails may be resent")
+	ErrPurged    = errors.New("the content of this email has been discarded, reset the password to send a new one")
+)
+
+// secretTemplates are the templates whose emails include a temporary password.
+var secretTemplates = []string{email.TemplateCredentials}
+
+// execer runs a statement against either a connection pool or a transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Notify is called within the transaction which assigns a user a new temporary password,
+// so that the email providing the password is enqueued if and only if it is saved.
+type Notify func(tx *sql.Tx, pass string) error
+
+// Email describes an email in the outbox, without its content.
+type Email struct {
+	Id            string     `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Template      string     `json:"template"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	DateCreated   time.Time  `json:"dateCreated"`
+	DateSent      *time.Time `json:"dateSent,omitempty"`
+}
+
+// claimed is an email taken from the outbox for delivery. Unreadable is set if its
+// content could not be decrypted, in which case the email cannot be sent.
+type claimed struct {
+	Id         string
+	Attempts   int
+	Message    email.Message
+	Unreadable error
+}
+
+// Summary counts the outcomes of a dispatch.
+type Summary struct {
+	Sent     int `json:"sent"`
+	Retrying int `json:"retrying"`
+	Failed   int `json:"failed"`
+}
+
+// Backoff returns the delay before retrying an email which has failed the given number
+// of delivery attempts.
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
+
+// Enqueue adds an email rendered from the named template to the outbox as part of the
+// provided transaction, returning its id. It is delivered once the transaction commits.
+func Enqueue(tx *sql.Tx, template string, message email.Message) (string, error) {
+	if len(message.To) != 1 {
+		return "", errors.New("an outbox email must have exactly one recipient")
+	}
+
+	aead, err := newSealer()
+
+	if err != nil {
+		logs.LogError(err, "Prepare Outbox Cipher Error")
+		return "", err
+	}
+
+	html, err := seal(aead, message.Html)
+
+	if err != nil {
+		logs.LogError(err, "Seal Email Content Error")
+		return "", err
+	}
+
+	text, err := seal(aead, message.Text)
+
+	if err != nil {
+		logs.LogError(err, "Seal Email Content Error")
+		return "", err
+	}
+
+	id := xid.New().String()
+	currentTime := time.Now()
+
+	query :=
+		`INSERT INTO email_outbox( id, recipient, template, subject, body_html, body_text, status, next_attempt_at, date_created )
+		 VALUES ( $1, $2, $3, $4, $5, NULLIF( $6, '' ), $7, $8, $8 );`
+	_, err = tx.Exec(query, id, message.To[0], template, message.Subject, html, text, StatusPending, currentTime)
+
+	if err != nil {
+		logs.LogError(err, "Enqueue Email Query Error")
+	}
+
+	return id, err
+}
+
+// Cancel marks as failed any undelivered emails to a recipient, as part of the provided
+// transaction, recording the reason as the emails' last error.
+func Cancel(tx *sql.Tx, recipient string, reason string) (int64, error) {
+	query :=
+		`UPDATE email_outbox SET status = $1, last_error = $2
+		 WHERE LOWER( recipient ) = LOWER( $3 ) AND status IN ( $4, $5 );`
+	result, err := tx.Exec(query, StatusFailed, reason, recipient, StatusPending, StatusSending)
+
+	if err != nil {
+		logs.LogError(err, "Cancel Emails Query Error")
+		return 0, err
+	}
+
+	if err = purgeSecrets(tx, recipient); err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// purgeSecrets discards the content of failed emails which include a temporary password,
+// so that it is not kept once the email can no longer be delivered. The purge may be
+// narrowed to the emails for a single recipient.
+func purgeSecrets(exec execer, recipient string) error {
+	query :=
+		`UPDATE email_outbox SET body_html = NULL, body_text = NULL
+		 WHERE status = $1 AND template = ANY( $2 ) AND body_html IS NOT NULL
+		 AND ( $3 = '' OR LOWER( recipient ) = LOWER( $3 ) );`
+	_, err := exec.Exec(query, StatusFailed, pq.Array(secretTemplates), recipient)
+
+	if err != nil {
+		logs.LogError(err, "Purge Failed Emails Query Error")
+	}
+
+	return err
+}
+
+// failUndeliverable marks as failed the emails whose final delivery attempt never reported
+// back before its lease expired, so that they are not claimed indefinitely, along with
+// any emails to suppressed addresses, which must not be sent.
+func failUndeliverable(pool *sql.DB, now time.Time) error {
+	query :=
+		`UPDATE email_outbox SET status = $1, last_error = COALESCE( last_error, 'delivery did not complete' )
+		 WHERE status = $2 AND next_attempt_at <= $3 AND attempts >= $4;`
+	_, err := pool.Exec(query, StatusFailed, StatusSending, now, MaxAttempts)
+
+	if err != nil {
+		logs.LogError(err, "Fail Abandoned Emails Query Error")
+		return err
+	}
+
+	query =
+		`UPDATE email_outbox SET status = $1, last_error = $2
+		 WHERE status = $3 AND LOWER( recipient ) IN ( SELECT email FROM email_suppressions );`
+	_, err = pool.Exec(query, StatusFailed, ErrorSuppressed, StatusPending)
+
+	if err != nil {
+		logs.LogError(err, "Fail Suppressed Emails Query Error")
+		return err
+	}
+
+	return purgeSecrets(pool, "")
+}
+
+// claim marks up to limit due emails as being sent and returns them. Emails locked by a
+// concurrent dispatcher are skipped. The claim may be narrowed to a single recipient.
+// Emails whose content cannot be decrypted are returned marked as unreadable, so that
+// they fail without holding up the others.
+func claim(limit int, recipient string) ([]claimed, error) {
+	emails := []claimed{}
+	now := time.Now()
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	aead, err := newSealer()
+
+	if err != nil {
+		logs.LogError(err, "Prepare Outbox Cipher Error")
+		return emails, err
+	}
+
+	if err = failUndeliverable(pool, now); err != nil {
+		return emails, err
+	}
+
+	query :=
+		`UPDATE email_outbox SET status = $1, attempts = attempts + 1, next_attempt_at = $2
+		 WHERE id IN (
+		   SELECT id FROM email_outbox
+		   WHERE status IN ( $3, $1 ) AND next_attempt_at <= $4 AND attempts < $5
+		   AND ( $6 = '' OR recipient = $6 )
+		   ORDER BY next_attempt_at LIMIT $7 FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id, recipient, subject, COALESCE( body_html, '' ), COALESCE( body_text, '' ), attempts;`
+	rows, err := pool.Query(query, StatusSending, now.Add(Lease), StatusPending, now, MaxAttempts, recipient, limit)
+
+	if err != nil {
+		logs.LogError(err, "Claim Emails Query Error")
+		return emails, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e claimed
+		var to string
+
+		err = rows.Scan(&e.Id, &to, &e.Message.Subject, &e.Message.Html, &e.Message.Text, &e.Attempts)
+
+		if err != nil {
+			logs.LogError(err, "Claim Emails Scan Error")
+			return emails, err
+		}
+
+		e.Message.To = []string{to}
+
+		if e.Message.Html, err = unseal(aead, e.Message.Html); err == nil {
+			e.Message.Text, err = unseal(aead, e.Message.Text)
+		}
+
+		if err != nil {
+			logs.LogError(err, "Unseal Email Content Error")
+			e.Unreadable = err
+		}
+
+		emails = append(emails, e)
+	}
+
+	return emails, rows.Err()
+}
+
+// markSent records the delivery of an email. Its content is discarded, since it may
+// include a temporary password which should not be kept any longer than necessary.
+func markSent(id string, messageId string) error {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`UPDATE email_outbox SET status = $1, message_id = $2, date_sent = $3, last_error = NULL,
+		 body_html = NULL, body_text = NULL WHERE id = $4;`
+	_, err := pool.Exec(query, StatusSent, messageId, time.Now(), id)
+
+	if err != nil {
+		logs.LogError(err, "Mark Email Sent Query Error")
+	}
+
+	return err
+}
+
+// markFailed records an unsuccessful delivery attempt, scheduling a retry after a backoff
+// or, once the email has been attempted MaxAttempts times, marking it as failed and
+// discarding any temporary password it includes. It returns the new status of the email.
+func markFailed(id string, attempts int, sendErr error) (string, error) {
+	status := StatusPending
+
+	if attempts >= MaxAttempts {
+		status = StatusFailed
+	}
+
+	message := sendErr.Error()
+
+	if len(message) > errorLimit {
+		message = message[:errorLimit]
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query := `UPDATE email_outbox SET status = $1, last_error = $2, next_attempt_at = $3 WHERE id = $4;`
+	_, err := pool.Exec(query, status, message, time.Now().Add(Backoff(attempts)), id)
+
+	if err != nil {
+		logs.LogError(err, "Mark Email Failed Query Error")
+		return status, err
+	}
+
+	if status == StatusFailed {
+		err = purgeSecrets(pool, "")
+	}
+
+	return status, err
+}
+
+// Dispatch delivers up to limit due emails through the provided mailer, recording the
+// outcome of each. The dispatch may be narrowed to the emails for a single recipient.
+func Dispatch(ctx context.Context, mailer email.Mailer, recipient string, limit int) (Summary, error) {
+	var summary Summary
+
+	emails, err := claim(limit, recipient)
+
+	if err != nil {
+		return summary, err
+	}
+
+	for _, e := range emails {
+		// Content which cannot be decrypted never will be, so the email fails at once.
+		if e.Unreadable != nil {
+			_, markErr := markFailed(e.Id, MaxAttempts, e.Unreadable)
+			err = errors.Join(err, markErr)
+			summary.Failed++
+
+			continue
+		}
+
+		messageId, sendErr := mailer.Send(ctx, e.Message)
+
+		if sendErr == nil {
+			summary.Sent++
+			err = errors.Join(err, markSent(e.Id, messageId))
+
+			continue
+		}
+
+		logs.LogError(sendErr, "Send Outbox Email Error")
+
+		status, markErr := markFailed(e.Id, e.Attempts, sendErr)
+		err = errors.Join(err, markErr)
+
+		if status == StatusFailed {
+			summary.Failed++
+		} else {
+			summary.Retrying++
+		}
+	}
+
+	return summary, err
+}
+
+// Flush makes an immediate attempt to deliver the pending emails for a recipient, so that
+// they need not wait for the next run of the dispatcher. Any emails which cannot be sent
+// are left for the dispatcher to retry, so errors are logged rather than returned.
+func Flush(ctx context.Context, recipient string) {
+	mailer, err := email.GetMailer()
+
+	if err != nil {
+		logs.LogError(err, "Get Mailer Error")
+		return
+	}
+
+	summary, err := Dispatch(ctx, mailer, recipient, BatchSize)
+
+	if err != nil {
+		logs.LogError(err, "Flush Outbox Error")
+	} else if summary.Retrying > 0 || summary.Failed > 0 {
+		logs.Info("Emails left for the dispatcher to retry", "recipient", recipient, "retrying", summary.Retrying)
+	}
+}
+
+// RetrieveEmails opens a database connection and retrieves the most recent emails sent
+// to a recipient, newest first.
+func RetrieveEmails(recipient string) ([]Email, error) {
+	emails := []Email{}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT id, recipient, template, subject, status, attempts, COALESCE( last_error, '' ), next_attempt_at, date_created, date_sent
+		 FROM email_outbox WHERE recipient = $1 ORDER BY date_created DESC LIMIT $2;`
+	rows, err := pool.Query(query, recipient, HistoryLimit)
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Emails Query Error")
+		return emails, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Email
+		var dateSent sql.NullTime
+
+		err = rows.Scan(&e.Id, &e.Recipient, &e.Template, &e.Subject, &e.Status, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.DateCreated, &dateSent)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Emails Scan Error")
+			return emails, err
+		}
+
+		if dateSent.Valid {
+			e.DateSent = &dateSent.Time
+		}
+
+		emails = append(emails, e)
+	}
+
+	return emails, rows.Err()
+}
+
+// RetrieveEmail opens a database connection and retrieves a single email from the outbox.
+func RetrieveEmail(id string) (Email, error) {
+	var e Email
+	var dateSent sql.NullTime
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT id, recipient, template, subject, status, attempts, COALESCE( last_error, '' ), next_attempt_at, date_created, date_sent
+		 FROM email_outbox WHERE id = $1;`
+	err := pool.QueryRow(query, id).Scan(&e.Id, &e.Recipient, &e.Template, &e.Subject, &e.Status, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.DateCreated, &dateSent)
+
+	if err != nil && err != sql.ErrNoRows {
+		logs.LogError(err, "Retrieve Email Query Error")
+	}
+
+	if dateSent.Valid {
+		e.DateSent = &dateSent.Time
+	}
+
+	return e, err
+}
+
+// Resend returns a failed email to the outbox with a fresh set of delivery attempts,
+// recording the provided audit event. It returns ErrNotFailed if the email had not failed
+// and ErrPurged if its content was discarded.
+func Resend(id string, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "email_outbox", Column: "id", Key: id}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		var purged bool
+
+		query := `SELECT body_html IS NULL FROM email_outbox WHERE id = $1 AND status = $2;`
+		err := tx.QueryRow(query, id, StatusFailed).Scan(&purged)
+
+		if err == sql.ErrNoRows {
+			return ErrNotFailed
+		} else if err != nil {
+			logs.LogError(err, "Check Email Content Query Error")
+			return err
+		} else if purged {
+			return ErrPurged
+		}
+
+		query =
+			`UPDATE email_outbox SET status = $1, attempts = 0, next_attempt_at = $2
+			 WHERE id = $3 AND status = $4`
+
+		return audit.ExecChange(tx, "Resend Email Query Error", query, StatusPending, time.Now(), id, StatusFailed)
+	})
+
+	if errors.Is(err, audit.ErrNoChange) {
+		return ErrNotFailed
+	}
+
+	return err
+}
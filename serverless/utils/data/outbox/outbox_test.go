@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/email"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		6:  32 * time.Minute,
+		7:  time.Hour,
+		50: time.Hour,
+	}
+
+	for attempts, want := range cases {
+		if got := Backoff(attempts); got != want {
+			t.Fatalf("Backoff(%d) result %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestEnqueueRecipients(t *testing.T) {
+	if _, err := Enqueue(nil, "credentials", email.Message{Subject: "Nobody"}); err == nil {
+		t.Fatal("Enqueue returned no error for an email without recipients")
+	}
+
+	if _, err := Enqueue(nil, "credentials", email.Message{To: []string{"a@example.com", "b@example.com"}}); err == nil {
+		t.Fatal("Enqueue returned no error for an email with several recipients")
+	}
+}
+
+func TestSeal(t *testing.T) {
+	t.Setenv("OUTBOX_KEY", "test-outbox-key")
+
+	aead, err := newSealer()
+	if err != nil {
+		t.Fatalf("newSealer error: %v", err)
+	}
+
+	sealed, err := seal(aead, "<p>Tmp-Pass-123</p>")
+	if err != nil {
+		t.Fatalf("seal error: %v", err)
+	}
+
+	if strings.Contains(sealed, "Tmp-Pass-123") {
+		t.Fatalf("seal result %q contains the content in plain text", sealed)
+	}
+
+	if content, err := unseal(aead, sealed); content != "<p>Tmp-Pass-123</p>" || err != nil {
+		t.Fatalf("unseal result %q/%v, want the original content", content, err)
+	}
+
+	if content, err := unseal(aead, "<p>Plain</p>"); content != "" || err == nil {
+		t.Fatalf("unseal result %q/%v, want error for content which was not sealed", content, err)
+	}
+
+	if content, err := unseal(aead, ""); content != "" || err != nil {
+		t.Fatalf("unseal result %q/%v, want empty content left empty", content, err)
+	}
+
+	if sealed, err := seal(aead, ""); sealed != "" || err != nil {
+		t.Fatalf("seal result %q/%v, want empty content left empty", sealed, err)
+	}
+}
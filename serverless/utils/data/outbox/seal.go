@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+)
+
+// sealedPrefix marks email content encrypted with the outbox key.
+const sealedPrefix = "v1:"
+
+// outboxKey retrieves the key used to encrypt the content of queued emails from the environment.
+func outboxKey() ([]byte, error) {
+	key := os.Getenv("OUTBOX_KEY")
+
+	if key == "" {
+		return nil, errors.New("no outbox key configured")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return sum[:], nil
+}
+
+// newSealer prepares the AES-GCM cipher with which email content is encrypted.
+func newSealer() (cipher.AEAD, error) {
+	key, err := outboxKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts email content, which may include a temporary password, for storage.
+// Empty content is left empty so that a missing plain text body remains NULL.
+func seal(aead cipher.AEAD, content string) (string, error) {
+	if content == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(content), nil)
+
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unseal decrypts email content stored by seal. Content which was not sealed is rejected,
+// except for empty content, which seal leaves empty.
+func unseal(aead cipher.AEAD, stored string) (string, error) {
+	if stored == "" {
+		return "", nil
+	}
+
+	encoded, ok := strings.CutPrefix(stored, sealedPrefix)
+
+	if !ok {
+		return "", errors.New("email content is not sealed")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("sealed email content is truncated")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	content, err := aead.Open(nil, nonce, ciphertext, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
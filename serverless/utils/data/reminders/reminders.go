@@ -0,0 +1,186 @@
+// Package reminders records the reminders sent to guests, and to the users who invited
+// them, ahead of the expiration of the guests' access.
+package reminders
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// DefaultWindows lists the number of days before a guest's access expires at which
+// they are reminded, if no other windows are configured.
+var DefaultWindows = []int{14, 3, 1}
+
+// Reminder describes a guest whose access expires within one of the reminder windows.
+type Reminder struct {
+	Guest   data.User
+	Expires time.Time
+	Days    int
+}
+
+// Notify is called within the transaction which records a reminder, with the user who
+// most recently invited or proposed the guest. The inviter is empty if they are unknown
+// or cannot be emailed.
+type Notify func(tx *sql.Tx, reminder Reminder, inviter data.User) error
+
+// ParseWindows reads a comma separated list of the number of days before expiration at
+// which to send reminders, e.g. `14,3,1`. The windows are returned from longest to
+// shortest, without duplicates. An empty list returns the default windows.
+func ParseWindows(list string) ([]int, error) {
+	if strings.TrimSpace(list) == "" {
+		return DefaultWindows, nil
+	}
+
+	seen := map[int]bool{}
+	windows := []int{}
+
+	for _, part := range strings.Split(list, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(part))
+
+		if err != nil || days < 1 {
+			return nil, fmt.Errorf("invalid reminder window %q, must be a positive number of days", part)
+		}
+
+		if !seen[days] {
+			seen[days] = true
+			windows = append(windows, days)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(windows)))
+
+	return windows, nil
+}
+
+// Window returns the shortest of the windows, given from longest to shortest, within
+// which an expiration falls. It returns false if the access has already expired, or
+// expires later than the longest window.
+func Window(windows []int, expires time.Time, now time.Time) (int, bool) {
+	remaining := expires.Sub(now)
+
+	if remaining <= 0 {
+		return 0, false
+	}
+
+	for i := len(windows) - 1; i >= 0; i-- {
+		if remaining <= time.Duration(windows[i])*24*time.Hour {
+			return windows[i], true
+		}
+	}
+
+	return 0, false
+}
+
+// Due opens a database connection and lists the guests whose access expires within
+// one of the windows and who have not yet been reminded for that window. Guests whose
+// latest invitation is still pending approval, and archived guests, are not reminded.
+func Due(windows []int, now time.Time) ([]Reminder, error) {
+	due := []Reminder{}
+
+	if len(windows) == 0 {
+		return due, nil
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT g.email, g.first_name, g.last_name, g.role, g.team, g.locale, i.expiration
+		 FROM guests g
+		 JOIN ( SELECT DISTINCT ON ( invitee ) invitee, expiration, pending FROM invites ORDER BY invitee, date_invited DESC ) i
+		 ON i.invitee = g.email
+		 WHERE NOT i.pending AND g.archived_at IS NULL AND i.expiration > $1 AND i.expiration <= $2
+		 ORDER BY i.expiration;`
+	rows, err := pool.Query(query, now, now.AddDate(0, 0, windows[0]))
+
+	if err != nil {
+		logs.LogError(err, "Retrieve Expiring Guests Query Error")
+		return due, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var reminder Reminder
+
+		err := rows.Scan(
+			&reminder.Guest.Email,
+			&reminder.Guest.NameFirst,
+			&reminder.Guest.NameLast,
+			&reminder.Guest.Role,
+			&reminder.Guest.Team,
+			&reminder.Guest.Locale,
+			&reminder.Expires,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Retrieve Expiring Guests Scan Error")
+			return due, err
+		}
+
+		if days, ok := Window(windows, reminder.Expires, now); ok {
+			reminder.Days = days
+			due = append(due, reminder)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		logs.LogError(err, "Retrieve Expiring Guests Row Error")
+	}
+
+	return due, err
+}
+
+// Send opens a database connection and records that a reminder has been sent, calling
+// notify in the same transaction so that the reminder's emails are queued only if it is
+// recorded. It returns false if the reminder had already been sent, in which case notify
+// is not called. A guest who is reauthorized has a new expiration, and so is reminded again.
+func Send(reminder Reminder, notify Notify) (bool, error) {
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	tx, err := pool.Begin()
+
+	if err != nil {
+		logs.LogError(err, "Begin Reminder Transaction Error")
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	query :=
+		`INSERT INTO expiration_reminders( invitee, expiration, days, date_sent ) VALUES ( $1, $2, $3, $4 )
+		 ON CONFLICT DO NOTHING;`
+	result, err := tx.Exec(query, reminder.Guest.Email, reminder.Expires, reminder.Days, time.Now())
+
+	if err != nil {
+		logs.LogError(err, "Record Reminder Query Error")
+		return false, err
+	}
+
+	if recorded, _ := result.RowsAffected(); recorded == 0 {
+		return false, nil
+	}
+
+	_, inviter, found, err := guests.RetrieveInviter(tx, reminder.Guest.Email)
+
+	if err != nil {
+		return false, err
+	} else if !found {
+		inviter = data.User{}
+	}
+
+	if err = notify(tx, reminder, inviter); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
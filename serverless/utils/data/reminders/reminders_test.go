@@ -0,0 +1,58 @@
+package reminders
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	tests := []struct {
+		list string
+		want []int
+	}{
+		{"", DefaultWindows},
+		{"14,3,1", []int{14, 3, 1}},
+		{" 1, 30 ,7,7 ", []int{30, 7, 1}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseWindows(tt.list)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("ParseWindows(%q) result %v/%v, want %v/nil", tt.list, got, err, tt.want)
+		}
+	}
+
+	for _, list := range []string{"14,,1", "0", "-3", "two"} {
+		if _, err := ParseWindows(list); err == nil {
+			t.Fatalf("ParseWindows(%q) returned no error", list)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Date(2024, time.January, 25, 14, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		remaining time.Duration
+		want      int
+		ok        bool
+	}{
+		{-time.Hour, 0, false},
+		{12 * time.Hour, 1, true},
+		{day, 1, true},
+		{day + time.Hour, 3, true},
+		{3 * day, 3, true},
+		{10 * day, 14, true},
+		{14 * day, 14, true},
+		{15 * day, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Window(DefaultWindows, now.Add(tt.remaining), now)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("Window for %v result %d/%t, want %d/%t", tt.remaining, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package suppressions records the email addresses to which the gateway no longer sends,
+// because an email to the address bounced permanently or its owner marked one as spam.
+package suppressions
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// The reasons for which an address may be suppressed.
+const (
+	ReasonBounce    = "bounce"
+	ReasonComplaint = "complaint"
+)
+
+var ErrSuppressed = errors.New("emails to this address are suppressed")
+
+// Suppression describes an address to which emails are no longer sent.
+type Suppression struct {
+	Email          string    `json:"email"`
+	Reason         string    `json:"reason"`
+	Detail         string    `json:"detail,omitempty"`
+	FeedbackId     string    `json:"feedbackId,omitempty"`
+	DateSuppressed time.Time `json:"dateSuppressed"`
+}
+
+// Notify is called within the transaction which suppresses a guest's address, with the
+// guest and the user who invited them, so that the inviter learns the guest is unreachable.
+type Notify func(tx *sql.Tx, guest data.User, inviter data.User) error
+
+// IsSuppressed opens a database connection and checks whether emails to an address are
+// suppressed. Addresses are compared without regard to case.
+func IsSuppressed(address string) (bool, error) {
+	var suppressed bool
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query := `SELECT EXISTS ( SELECT 1 FROM email_suppressions WHERE email = LOWER( $1 ) );`
+	err := pool.QueryRow(query, address).Scan(&suppressed)
+
+	if err != nil {
+		logs.LogError(err, "Check Email Suppression Query Error")
+	}
+
+	return suppressed, err
+}
+
+// Check returns ErrSuppressed if emails to an address are suppressed, so that callers
+// sending directly rather than through the outbox may skip the address.
+func Check(address string) error {
+	suppressed, err := IsSuppressed(address)
+
+	if err != nil {
+		return err
+	} else if suppressed {
+		return ErrSuppressed
+	}
+
+	return nil
+}
+
+// Suppress opens a database connection and stops all further emails to an address. Any
+// admin or guest with the address is flagged, their undelivered emails are cancelled,
+// and, if they are a guest, notify is called so that their inviter may be told. The
+// change is recorded with the provided audit event. It returns false if the address had
+// already been suppressed, in which case nothing is changed.
+func Suppress(suppression Suppression, notify Notify, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{
+		{Table: "admins", Column: "email", Key: suppression.Email},
+		{Table: "guests", Column: "email", Key: suppression.Email},
+	}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`INSERT INTO email_suppressions( email, reason, detail, feedback_id, date_suppressed )
+			 VALUES ( LOWER( $1 ), $2, NULLIF( $3, '' ), NULLIF( $4, '' ), $5 )
+			 ON CONFLICT ( email ) DO NOTHING;`
+		err := audit.ExecChange(
+			tx,
+			"Suppress Email Query Error",
+			query,
+			suppression.Email,
+			suppression.Reason,
+			suppression.Detail,
+			suppression.FeedbackId,
+			currentTime,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		for _, table := range []string{"admins", "guests"} {
+			query = `UPDATE ` + table + ` SET email_suppressed_at = $1 WHERE LOWER( email ) = LOWER( $2 ) AND email_suppressed_at IS NULL;`
+
+			if _, err = tx.Exec(query, currentTime, suppression.Email); err != nil {
+				logs.LogError(err, "Flag Suppressed User Query Error")
+				return err
+			}
+		}
+
+		if _, err = outbox.Cancel(tx, suppression.Email, outbox.ErrorSuppressed); err != nil {
+			return err
+		}
+
+		if notify == nil {
+			return nil
+		}
+
+		guest, inviter, found, err := guests.RetrieveInviter(tx, suppression.Email)
+
+		if err != nil || !found {
+			return err
+		}
+
+		return notify(tx, guest, inviter)
+	})
+
+	return audit.Changed(err)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
 
@@ -61,26 +62,28 @@ func CheckForExistingTeamById(teamId string) (bool, error) {
 	return team == teamId, err
 }
 
-// CreateTeam opens a database connection and saves a new team record.
-func CreateTeam(teamName string, aprimoName string) error {
-	var err error
+// CreateTeam opens a database connection and saves a new team record, recording the
+// provided audit event. The event's target is set to the id of the new team.
+func CreateTeam(teamName string, aprimoName string, event audit.Event) error {
+	guid := xid.New().String()
+	subjects := []audit.Subject{{Table: "teams", Column: "id", Key: guid}}
 
-	pool := data.ConnectToDB()
-	defer pool.Close()
+	event.Target = guid
 
-	guid := xid.New()
-	currentTime := time.Now()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	insertTeam :=
-		`INSERT INTO teams( id, team_name, aprimo_name, active, date_created, date_modified )
-		 VALUES ($1, $2, $3, $4, $5, $6);`
-	_, err = pool.Exec(insertTeam, guid, teamName, aprimoName, true, currentTime, currentTime)
+		insertTeam :=
+			`INSERT INTO teams( id, team_name, aprimo_name, active, date_created, date_modified )
+			 VALUES ($1, $2, $3, $4, $5, $6);`
+		_, err := tx.Exec(insertTeam, guid, teamName, aprimoName, true, currentTime, currentTime)
 
-	if err != nil {
-		logs.LogError(err, "Create Team Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Create Team Query Error")
+		}
 
-	return err
+		return err
+	})
 }
 
 // GetTeamIdByName uses a team's name to retrieve it's unique identifier.
@@ -103,42 +106,42 @@ func GetTeamIdByName(teamName string) (string, error) {
 	return id, err
 }
 
-// UpdateTeam opens a database connection and updates and existing team record.
-func UpdateTeam(teamId string, teamName string, aprimoName string, active bool) error {
-	var err error
-
-	pool := data.ConnectToDB()
-	defer pool.Close()
+// UpdateTeam opens a database connection and updates and existing team record,
+// recording the provided audit event.
+func UpdateTeam(teamId string, teamName string, aprimoName string, active bool, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "teams", Column: "id", Key: teamId}}
 
-	currentTime := time.Now()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	query := `UPDATE teams SET team_name = $1, aprimo_name = $2, active = $3, date_modified = $4 WHERE id = $5;`
-	_, err = pool.Exec(query, teamName, aprimoName, active, currentTime, teamId)
+		query := `UPDATE teams SET team_name = $1, aprimo_name = $2, active = $3, date_modified = $4 WHERE id = $5;`
+		_, err := tx.Exec(query, teamName, aprimoName, active, currentTime, teamId)
 
-	if err != nil {
-		logs.LogError(err, "Update Team Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Update Team Query Error")
+		}
 
-	return err
+		return err
+	})
 }
 
-// UpdateTeamStatus opens a database connection and updates and existing team's status.
-func UpdateTeamStatus(teamId string, active bool) error {
-	var err error
-
-	pool := data.ConnectToDB()
-	defer pool.Close()
+// UpdateTeamStatus opens a database connection and updates and existing team's status,
+// recording the provided audit event.
+func UpdateTeamStatus(teamId string, active bool, event audit.Event) error {
+	subjects := []audit.Subject{{Table: "teams", Column: "id", Key: teamId}}
 
-	currentTime := time.Now()
+	return audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
 
-	query := `UPDATE teams SET active = $1, date_modified = $2 WHERE id = $3;`
-	_, err = pool.Exec(query, active, currentTime, teamId)
+		query := `UPDATE teams SET active = $1, date_modified = $2 WHERE id = $3;`
+		_, err := tx.Exec(query, active, currentTime, teamId)
 
-	if err != nil {
-		logs.LogError(err, "Update Team Status Query Error")
-	}
+		if err != nil {
+			logs.LogError(err, "Update Team Status Query Error")
+		}
 
-	return err
+		return err
+	})
 }
 
 // RetrieveTeams opens a database connection and retrieves the full list of teams.
@@ -149,7 +152,10 @@ func RetrieveTeams() ([]data.Team, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	rows, err := pool.Query(`SELECT id, team_name, active, aprimo_name FROM teams ORDER BY team_name`)
+	rows, err := pool.Query(
+		`SELECT id, team_name, active, aprimo_name FROM teams
+		 WHERE archived_at IS NULL ORDER BY team_name`,
+	)
 
 	if err != nil {
 		logs.LogError(err, "Get Teams Query Error")
@@ -175,3 +181,40 @@ func RetrieveTeams() ([]data.Team, error) {
 
 	return teams, err
 }
+
+// ArchiveTeam opens a database connection and soft deletes the given team,
+// recording who archived it and why along with the provided audit event. It returns
+// false if the team had already been archived.
+func ArchiveTeam(teamId string, archivedBy string, reason string, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "teams", Column: "id", Key: teamId}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`UPDATE teams SET archived_at = $1, archived_by = $2, archive_reason = $3, date_modified = $1
+			 WHERE id = $4 AND archived_at IS NULL`
+
+		return audit.ExecChange(tx, "Archive Team Query Error", query, currentTime, archivedBy, reason, teamId)
+	})
+
+	return audit.Changed(err)
+}
+
+// RestoreTeam opens a database connection and clears the archival data from the given
+// team, recording the provided audit event. It returns false if the team was not archived.
+func RestoreTeam(teamId string, event audit.Event) (bool, error) {
+	subjects := []audit.Subject{{Table: "teams", Column: "id", Key: teamId}}
+
+	err := audit.Transact(event, subjects, func(tx *sql.Tx) error {
+		currentTime := time.Now()
+
+		query :=
+			`UPDATE teams SET archived_at = NULL, archived_by = NULL, archive_reason = NULL, date_modified = $1
+			 WHERE id = $2 AND archived_at IS NOT NULL`
+
+		return audit.ExecChange(tx, "Restore Team Query Error", query, currentTime, teamId)
+	})
+
+	return audit.Changed(err)
+}
@@ -0,0 +1,92 @@
+package uploads
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// maxErrorLength limits the length of the error saved against an upload, since the
+// errors returned by Aprimo may include the full body of its response.
+const maxErrorLength = 1000
+
+// AprimoTransfer describes the progress of an upload's transfer to Aprimo.
+type AprimoTransfer struct {
+	S3Id          string     `json:"s3Id"`
+	Uploader      string     `json:"uploader"`
+	Team          string     `json:"team"`
+	LastError     string     `json:"lastError"`
+	LastErrorDate *time.Time `json:"lastErrorDate"`
+	Abandoned     *time.Time `json:"abandonedDate"`
+}
+
+// RecordAprimoError saves the most recent error encountered while transferring an upload
+// to Aprimo, so that it can be reviewed should the transfer ultimately fail.
+func RecordAprimoError(s3Id string, transferErr error) {
+	if transferErr == nil {
+		return
+	}
+
+	message := transferErr.Error()
+
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query := `UPDATE uploads SET aprimo_error = $1, aprimo_error_dt = NOW() WHERE s3_id = $2;`
+
+	if _, err := pool.Exec(query, message, s3Id); err != nil {
+		logs.LogError(err, "Record Aprimo Error Query Error")
+	}
+}
+
+// RetrieveAprimoTransfer looks up the uploader, team, and most recent transfer error of
+// an upload. The boolean return value is false if there is no such upload.
+func RetrieveAprimoTransfer(s3Id string) (AprimoTransfer, bool, error) {
+	transfer := AprimoTransfer{S3Id: s3Id}
+
+	var errorDate, abandoned sql.NullTime
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT COALESCE( all_users.guest_id, all_users.admin_id, uploads.user_id ), teams.team_name,
+		 COALESCE( aprimo_error, '' ), aprimo_error_dt, aprimo_abandoned_dt
+		 FROM uploads
+		 INNER JOIN teams ON uploads.team_id = teams.id
+		 LEFT JOIN all_users ON uploads.user_id = all_users.user_id
+		 WHERE s3_id = $1;`
+	err := pool.QueryRow(query, s3Id).Scan(&transfer.Uploader, &transfer.Team, &transfer.LastError, &errorDate, &abandoned)
+
+	if err == sql.ErrNoRows {
+		return transfer, false, nil
+	} else if err != nil {
+		logs.LogError(err, "Get Aprimo Transfer Query Error")
+		return transfer, false, err
+	}
+
+	transfer.LastErrorDate = nullTimePointer(errorDate)
+	transfer.Abandoned = nullTimePointer(abandoned)
+
+	return transfer, true, nil
+}
+
+// AbandonAprimoTransfer marks the transfer of an upload to Aprimo as abandoned, as part
+// of the provided transaction.
+func AbandonAprimoTransfer(tx *sql.Tx, s3Id string) error {
+	query := `UPDATE uploads SET aprimo_abandoned_dt = NOW() WHERE s3_id = $1;`
+
+	_, err := tx.Exec(query, s3Id)
+
+	if err != nil {
+		logs.LogError(err, "Abandon Aprimo Transfer Query Error")
+	}
+
+	return err
+}
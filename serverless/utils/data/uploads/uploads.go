@@ -0,0 +1,89 @@
+package uploads
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// Upload describes a single file submitted through the upload portal.
+type Upload struct {
+	S3Id           string     `json:"s3Id"`
+	Uploader       string     `json:"uploader"`
+	Team           string     `json:"team"`
+	FileType       string     `json:"fileType"`
+	Description    string     `json:"description"`
+	DateUploaded   time.Time  `json:"dateUploaded"`
+	DateCleaned    *time.Time `json:"dateCleaned"`
+	AprimoRecordId string     `json:"aprimoRecordId"`
+	AprimoUploaded *time.Time `json:"aprimoUploadDate"`
+}
+
+// nullTimePointer converts a nullable timestamp into a pointer that marshals to null.
+func nullTimePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// RetrieveUploads opens a database connection and retrieves the list of uploaded files,
+// most recent first. If a team is provided only the files uploaded to that team are returned.
+func RetrieveUploads(team string) ([]Upload, error) {
+	var uploads []Upload
+
+	pool := data.ConnectToDB()
+	defer pool.Close()
+
+	query :=
+		`SELECT s3_id, COALESCE( all_users.guest_id, all_users.admin_id, uploads.user_id ), team_id,
+		 file_type, description, date_uploaded, clean_dt, COALESCE( aprimo_record_id, '' ), aprimo_upload_dt
+		 FROM uploads
+		 LEFT JOIN all_users ON uploads.user_id = all_users.user_id
+		 WHERE $1 = '' OR team_id = $1
+		 ORDER BY date_uploaded DESC;`
+	rows, err := pool.Query(query, team)
+
+	if err != nil {
+		logs.LogError(err, "Get Uploads Query Error")
+		return uploads, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload Upload
+		var cleaned, uploaded sql.NullTime
+
+		err = rows.Scan(
+			&upload.S3Id,
+			&upload.Uploader,
+			&upload.Team,
+			&upload.FileType,
+			&upload.Description,
+			&upload.DateUploaded,
+			&cleaned,
+			&upload.AprimoRecordId,
+			&uploaded,
+		)
+
+		if err != nil {
+			logs.LogError(err, "Get Uploads Scan Error")
+			return uploads, err
+		}
+
+		upload.DateCleaned = nullTimePointer(cleaned)
+		upload.AprimoUploaded = nullTimePointer(uploaded)
+
+		uploads = append(uploads, upload)
+	}
+
+	if err = rows.Err(); err != nil {
+		logs.LogError(err, "Get Uploads Query Error")
+	}
+
+	return uploads, err
+}
@@ -9,16 +9,20 @@ import (
 )
 
 type UserRecord struct {
-	UserId  string
-	AdminId sql.NullString
-	GuestId sql.NullString
-	Type    string
+	UserId   string
+	AdminId  sql.NullString
+	GuestId  sql.NullString
+	Type     string
+	Archived bool
+	Rejected bool
 }
 
 // CheckIfUserExists opens a database connection and checks whether the provided
 // email (which is a unique value constraint in the admins and guests tables) is
 // present as either an admin_id or guest_id in the all_users table. An affirmative
 // check indicates that the given user has been registered as a user in the system.
+// The returned record also indicates whether that user has since been archived, and
+// whether the most recent invitation of a guest was a proposal which has been rejected.
 func CheckForExistingUser(email string) (bool, UserRecord, error) {
 	var err error
 	var user UserRecord
@@ -26,9 +30,17 @@ func CheckForExistingUser(email string) (bool, UserRecord, error) {
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	query := "SELECT user_id, admin_id, guest_id FROM all_users WHERE admin_id = $1 OR guest_id = $1;"
+	query :=
+		`SELECT user_id, admin_id, guest_id,
+		 COALESCE( guests.archived_at, admins.archived_at ) IS NOT NULL AS archived,
+		 recent_invites.rejected_at IS NOT NULL AS rejected
+		 FROM all_users
+		 LEFT JOIN guests ON all_users.guest_id = guests.email
+		 LEFT JOIN admins ON all_users.admin_id = admins.email
+		 LEFT JOIN recent_invites ON all_users.guest_id = recent_invites.invitee
+		 WHERE admin_id = $1 OR guest_id = $1;`
 
-	err = pool.QueryRow(query, email).Scan(&user.UserId, &user.AdminId, &user.GuestId)
+	err = pool.QueryRow(query, email).Scan(&user.UserId, &user.AdminId, &user.GuestId, &user.Archived, &user.Rejected)
 
 	if err != nil {
 		// Do not return an error if no results are found.
@@ -61,12 +73,12 @@ func retrieveExistingUser(email string, table string) (data.User, error) {
 	var query string
 
 	if table == "admins" {
-		query = "SELECT email, first_name, last_name, role, team FROM admins WHERE email = $1;"
+		query = "SELECT email, first_name, last_name, role, team, '' FROM admins WHERE email = $1;"
 	} else if table == "guests" {
-		query = "SELECT email, first_name, last_name, role, team FROM guests WHERE email = $1;"
+		query = "SELECT email, first_name, last_name, role, team, locale FROM guests WHERE email = $1;"
 	}
 
-	err = pool.QueryRow(query, email).Scan(&user.Email, &user.NameFirst, &user.NameLast, &user.Role, &user.Team)
+	err = pool.QueryRow(query, email).Scan(&user.Email, &user.NameFirst, &user.NameLast, &user.Role, &user.Team, &user.Locale)
 
 	if err != nil {
 		// Do not return an error if no results are found.
@@ -141,3 +153,30 @@ func CheckForExistingGuestUser(email string) (data.User, bool, error) {
 	// Handle any outlier cases
 	return userData, false, err
 }
+
+// ScopeTeamFilter determines which team a listing requested by the given user should
+// be restricted to. Super admins may view any team, or all teams if none is requested,
+// whereas all other users are always restricted to the team they belong to.
+func ScopeTeamFilter(email string, role string, requested string) (string, error) {
+	if role == "super admin" {
+		return requested, nil
+	}
+
+	var user data.User
+	var exists bool
+	var err error
+
+	if role == "admin" {
+		user, exists, err = CheckForExistingAdminUser(email)
+	} else {
+		user, exists, err = CheckForExistingGuestUser(email)
+	}
+
+	if err != nil {
+		return "", err
+	} else if !exists || user.Team == "" {
+		return "", fmt.Errorf("unable to determine the team of user %s", email)
+	}
+
+	return user.Team, nil
+}
@@ -53,3 +53,24 @@ func TestMiss(t *testing.T) {
 		t.Fatalf(`CheckForExistingUser result %t/%v, want true/nil`, success, err)
 	}
 }
+
+func TestScopeTeamFilterSuperAdmin(t *testing.T) {
+	team, err := ScopeTeamFilter("super@test.test", "super admin", "any")
+	if team != "any" || err != nil {
+		t.Fatalf(`ScopeTeamFilter result %s/%v, want any/nil`, team, err)
+	}
+}
+
+func TestScopeTeamFilterAdmin(t *testing.T) {
+	team, err := ScopeTeamFilter(testHelpers.ExampleAdmin["email"], "admin", "any")
+	if team != testHelpers.ExampleTeam["id"] || err != nil {
+		t.Fatalf(`ScopeTeamFilter result %s/%v, want %s/nil`, team, err, testHelpers.ExampleTeam["id"])
+	}
+}
+
+func TestScopeTeamFilterMiss(t *testing.T) {
+	_, err := ScopeTeamFilter("fake@test.fail", "admin", "")
+	if err == nil {
+		t.Fatalf(`ScopeTeamFilter result nil, want error`)
+	}
+}
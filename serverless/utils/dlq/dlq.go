@@ -0,0 +1,235 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/IIP-Design/commons-gateway/utils/clients"
+	"github.com/IIP-Design/commons-gateway/utils/data/uploads"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
+)
+
+// The Aprimo pipelines whose dead-letter queues can be inspected.
+const (
+	PipelineRecord = "record"
+	PipelineUpload = "upload"
+)
+
+// MaxMessages is the largest number of messages which are read from a dead-letter queue at once.
+const MaxMessages = 100
+
+// holdSeconds is how long messages read from a dead-letter queue are hidden from other
+// readers, so that repeated reads return further messages rather than the same ones.
+const holdSeconds = 30
+
+var ErrUnknownPipeline = errors.New("pipeline must be one of upload or record")
+
+// Pipeline identifies the queue feeding a stage of the transfer to Aprimo along with the
+// dead-letter queue collecting the messages which that stage repeatedly failed to process.
+type Pipeline struct {
+	Name          string
+	SourceUrl     string
+	DeadLetterUrl string
+}
+
+// Message is a dead-lettered message decoded into the context of the upload it concerns.
+type Message struct {
+	MessageId     string     `json:"messageId"`
+	Pipeline      string     `json:"pipeline"`
+	S3Id          string     `json:"s3Id"`
+	Team          string     `json:"team"`
+	Uploader      string     `json:"uploader"`
+	LastError     string     `json:"lastError"`
+	LastErrorDate *time.Time `json:"lastErrorDate"`
+	ReceiveCount  int        `json:"receiveCount"`
+	SentDate      *time.Time `json:"sentDate"`
+	DecodeError   string     `json:"decodeError,omitempty"`
+}
+
+// GetPipeline returns the queues of the named pipeline, whose URLs are read from the
+// environment.
+func GetPipeline(name string) (Pipeline, error) {
+	var source, deadLetter string
+
+	switch name {
+	case PipelineUpload:
+		source, deadLetter = "APRIMO_UPLOAD_QUEUE", "APRIMO_UPLOAD_DLQ"
+	case PipelineRecord:
+		source, deadLetter = "RECORD_CREATE_QUEUE", "RECORD_CREATE_DLQ"
+	default:
+		return Pipeline{}, ErrUnknownPipeline
+	}
+
+	pipeline := Pipeline{
+		Name:          name,
+		SourceUrl:     os.Getenv(source),
+		DeadLetterUrl: os.Getenv(deadLetter),
+	}
+
+	if pipeline.SourceUrl == "" || pipeline.DeadLetterUrl == "" {
+		return pipeline, fmt.Errorf("%s and %s must be set", source, deadLetter)
+	}
+
+	return pipeline, nil
+}
+
+// queueName extracts the name of a queue from its URL.
+func queueName(url string) string {
+	return path.Base(url)
+}
+
+// messageKey decodes the S3 key of the upload which a message concerns.
+func messageKey(pipeline string, message events.SQSMessage) (string, error) {
+	if pipeline == PipelineUpload {
+		records, err := queue.DecodeS3Notification(message)
+
+		if err != nil {
+			return "", err
+		} else if len(records) == 0 {
+			return "", errors.New("S3 test event")
+		}
+
+		return records[0].S3.Object.Key, nil
+	}
+
+	record, err := queue.Decode[queue.AprimoRecordMessage](message)
+
+	return record.Key, err
+}
+
+// toLambdaMessage converts a message received through the SQS API into the form
+// received by a Lambda function, so that it can be read by the same decoders.
+func toLambdaMessage(message types.Message) events.SQSMessage {
+	return events.SQSMessage{
+		MessageId: aws.ToString(message.MessageId),
+		Body:      aws.ToString(message.Body),
+	}
+}
+
+// describe decodes a dead-lettered message and looks up the upload which it concerns.
+func describe(pipeline string, received types.Message) Message {
+	message := Message{
+		MessageId: aws.ToString(received.MessageId),
+		Pipeline:  pipeline,
+	}
+
+	if count, err := strconv.Atoi(received.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]); err == nil {
+		message.ReceiveCount = count
+	}
+
+	if sent, err := strconv.ParseInt(received.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64); err == nil {
+		sentDate := time.UnixMilli(sent).UTC()
+		message.SentDate = &sentDate
+	}
+
+	key, err := messageKey(pipeline, toLambdaMessage(received))
+
+	if err != nil {
+		message.DecodeError = err.Error()
+		return message
+	}
+
+	message.S3Id = key
+
+	transfer, found, err := uploads.RetrieveAprimoTransfer(key)
+
+	if err != nil {
+		message.DecodeError = "unable to retrieve upload"
+	} else if found {
+		message.Team = transfer.Team
+		message.Uploader = transfer.Uploader
+		message.LastError = transfer.LastError
+		message.LastErrorDate = transfer.LastErrorDate
+	} else {
+		message.DecodeError = "no upload found for this key"
+	}
+
+	return message
+}
+
+// hold reads up to the given number of messages from a dead-letter queue, hiding each
+// from other readers until it is released, so that repeated reads return further messages.
+func hold(client clients.SQS, url string, max int) ([]types.Message, error) {
+	held := []types.Message{}
+	seen := map[string]bool{}
+
+	for len(held) < max {
+		resp, err := client.ReceiveMessage(context.TODO(), &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(url),
+			MaxNumberOfMessages:   int32(min(10, max-len(held))),
+			VisibilityTimeout:     holdSeconds,
+			WaitTimeSeconds:       1,
+			AttributeNames:        []types.QueueAttributeName{types.QueueAttributeNameAll},
+			MessageAttributeNames: []string{"All"},
+		})
+
+		if err != nil {
+			logs.LogError(err, "Receive Dead-Letter Messages Error")
+			return held, err
+		}
+
+		added := 0
+
+		for _, message := range resp.Messages {
+			// SQS may occasionally deliver the same message twice.
+			if id := aws.ToString(message.MessageId); !seen[id] {
+				seen[id] = true
+				held = append(held, message)
+				added++
+			}
+		}
+
+		if added == 0 {
+			break
+		}
+	}
+
+	return held, nil
+}
+
+// release makes held messages visible again, leaving them in the dead-letter queue.
+func release(client clients.SQS, url string, messages []types.Message) {
+	for _, message := range messages {
+		_, err := client.ChangeMessageVisibility(context.TODO(), &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(url),
+			ReceiptHandle:     message.ReceiptHandle,
+			VisibilityTimeout: 0,
+		})
+
+		if err != nil {
+			logs.LogError(err, "Release Dead-Letter Message Error")
+		}
+	}
+}
+
+// List reads up to the given number of messages from the dead-letter queue of a pipeline,
+// describing the upload each concerns. The messages remain in the queue.
+func List(pipeline Pipeline, max int) ([]Message, error) {
+	messages := []Message{}
+
+	client, err := clients.GetSQS()
+
+	if err != nil {
+		return messages, err
+	}
+
+	held, err := hold(client, pipeline.DeadLetterUrl, min(max, MaxMessages))
+	defer release(client, pipeline.DeadLetterUrl, held)
+
+	for _, received := range held {
+		messages = append(messages, describe(pipeline.Name, received))
+	}
+
+	return messages, err
+}
@@ -0,0 +1,199 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/IIP-Design/commons-gateway/utils/clients"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/queue"
+)
+
+const (
+	SOURCE_URL = "https://sqs.us-east-1.amazonaws.com/123456789012/content-gateway-dev-aprimo-record"
+	DLQ_URL    = "https://sqs.us-east-1.amazonaws.com/123456789012/content-gateway-dev-aprimo-record-dlq"
+)
+
+// fakeClient serves messages from memory in batches, recording which are released.
+// Sending and deleting messages fail with the given errors, if set.
+type fakeClient struct {
+	batches   [][]types.Message
+	released  []string
+	deleted   []string
+	sendErr   error
+	deleteErr error
+}
+
+func (c *fakeClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	if len(c.batches) == 0 {
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+
+	batch := c.batches[0]
+	c.batches = c.batches[1:]
+
+	return &sqs.ReceiveMessageOutput{Messages: batch}, nil
+}
+
+func (c *fakeClient) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	if c.sendErr != nil {
+		return nil, c.sendErr
+	}
+
+	return &sqs.SendMessageOutput{MessageId: aws.String("redriven")}, nil
+}
+
+func (c *fakeClient) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	if c.deleteErr != nil {
+		return nil, c.deleteErr
+	}
+
+	c.deleted = append(c.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (c *fakeClient) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	c.released = append(c.released, aws.ToString(params.ReceiptHandle))
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// useClient replaces the SQS client for the duration of a test.
+func useClient(t *testing.T, client *fakeClient) {
+	clients.SetSQS(client)
+	t.Cleanup(clients.Reset)
+}
+
+func makeMessage(id string, body string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("receipt-" + id),
+		Body:          aws.String(body),
+		Attributes: map[string]string{
+			"ApproximateReceiveCount": "5",
+			"SentTimestamp":           "1704067200000",
+		},
+	}
+}
+
+func TestGetPipeline(t *testing.T) {
+	t.Setenv("RECORD_CREATE_QUEUE", SOURCE_URL)
+	t.Setenv("RECORD_CREATE_DLQ", DLQ_URL)
+
+	pipeline, err := GetPipeline(PipelineRecord)
+	if err != nil || pipeline.SourceUrl != SOURCE_URL || pipeline.DeadLetterUrl != DLQ_URL {
+		t.Fatalf("GetPipeline result %+v/%v, want record queues/nil", pipeline, err)
+	}
+
+	if _, err = GetPipeline("email"); err != ErrUnknownPipeline {
+		t.Fatalf("GetPipeline error %v, want %v", err, ErrUnknownPipeline)
+	}
+
+	if _, err = GetPipeline(PipelineUpload); err == nil {
+		t.Fatal("GetPipeline returned no error for a pipeline without queues")
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	upload := `{"Records":[{"s3":{"bucket":{"name":"clean"},"object":{"key":"team/file.jpg"}}}]}`
+
+	key, err := messageKey(PipelineUpload, events.SQSMessage{Body: upload})
+	if key != "team/file.jpg" || err != nil {
+		t.Fatalf("messageKey result %s/%v, want team/file.jpg/nil", key, err)
+	}
+
+	record, _ := queue.Encode(queue.AprimoRecordMessage{Key: "team/file.jpg", FileType: "image/jpeg", FileToken: "token"})
+
+	key, err = messageKey(PipelineRecord, events.SQSMessage{Body: record})
+	if key != "team/file.jpg" || err != nil {
+		t.Fatalf("messageKey result %s/%v, want team/file.jpg/nil", key, err)
+	}
+
+	if _, err = messageKey(PipelineRecord, events.SQSMessage{Body: `{"key":"team/file.jpg"}`}); err == nil {
+		t.Fatal("messageKey returned no error for a message without an envelope")
+	}
+}
+
+func TestHold(t *testing.T) {
+	client := &fakeClient{batches: [][]types.Message{
+		{makeMessage("1", "{}"), makeMessage("2", "{}")},
+		{makeMessage("2", "{}"), makeMessage("3", "{}")},
+		{makeMessage("3", "{}")},
+	}}
+
+	held, err := hold(client, DLQ_URL, MaxMessages)
+	if len(held) != 3 || err != nil {
+		t.Fatalf("hold result %d/%v, want 3/nil", len(held), err)
+	}
+}
+
+func TestList(t *testing.T) {
+	client := &fakeClient{batches: [][]types.Message{{makeMessage("1", "not json")}}}
+	useClient(t, client)
+
+	messages, err := List(Pipeline{Name: PipelineRecord, SourceUrl: SOURCE_URL, DeadLetterUrl: DLQ_URL}, 10)
+	if len(messages) != 1 || err != nil {
+		t.Fatalf("List result %d/%v, want 1/nil", len(messages), err)
+	}
+
+	message := messages[0]
+
+	if message.ReceiveCount != 5 || message.SentDate == nil || message.DecodeError == "" {
+		t.Fatalf("List message %+v, want 5 receives, a sent date, and a decode error", message)
+	}
+
+	if len(client.released) != 1 || len(client.deleted) != 0 {
+		t.Fatalf("List released %v and deleted %v, want the message released", client.released, client.deleted)
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	client := &fakeClient{batches: [][]types.Message{{makeMessage("1", "{}")}}}
+	useClient(t, client)
+
+	pipeline := Pipeline{Name: PipelineRecord, SourceUrl: SOURCE_URL, DeadLetterUrl: DLQ_URL}
+
+	results, err := Resolve(pipeline, ActionRedrive, []string{"2", "2"}, audit.Event{Actor: "super@test.test"})
+	if len(results) != 1 || results[0].Status != StatusNotFound || err != nil {
+		t.Fatalf("Resolve result %+v/%v, want one not found result/nil", results, err)
+	}
+
+	if len(client.released) != 1 || len(client.deleted) != 0 {
+		t.Fatalf("Resolve released %v and deleted %v, want the unchosen message released", client.released, client.deleted)
+	}
+
+	if _, err = Resolve(pipeline, "retry", []string{"1"}, audit.Event{}); err != ErrUnknownAction {
+		t.Fatalf("Resolve error %v, want %v", err, ErrUnknownAction)
+	}
+}
+
+func TestRedrive(t *testing.T) {
+	pipeline := Pipeline{Name: PipelineRecord, SourceUrl: SOURCE_URL, DeadLetterUrl: DLQ_URL}
+	message := makeMessage("1", "{}")
+
+	client := &fakeClient{sendErr: errors.New("send failed")}
+	if err := redrive(client, pipeline, message); err == nil || len(client.deleted) != 0 {
+		t.Fatalf("redrive error %v and deleted %v, want send error and message kept", err, client.deleted)
+	}
+
+	client = &fakeClient{deleteErr: errors.New("delete failed")}
+	if err := redrive(client, pipeline, message); !errors.Is(err, errNotRemoved) {
+		t.Fatalf("redrive error %v, want %v", err, errNotRemoved)
+	}
+
+	client = &fakeClient{}
+	if err := redrive(client, pipeline, message); err != nil || len(client.deleted) != 1 {
+		t.Fatalf("redrive error %v and deleted %v, want nil and message removed", err, client.deleted)
+	}
+}
+
+func TestQueueName(t *testing.T) {
+	if name := queueName(DLQ_URL); name != "content-gateway-dev-aprimo-record-dlq" {
+		t.Fatalf("queueName result %s, want content-gateway-dev-aprimo-record-dlq", name)
+	}
+}
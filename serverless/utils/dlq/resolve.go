@@ -0,0 +1,217 @@
+package dlq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/IIP-Design/commons-gateway/utils/clients"
+	"github.com/IIP-Design/commons-gateway/utils/data/audit"
+	"github.com/IIP-Design/commons-gateway/utils/data/uploads"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// The actions which can be taken on a dead-lettered message.
+const (
+	ActionAbandon = "abandon"
+	ActionRedrive = "redrive"
+)
+
+// The outcome of an action on a single message.
+const (
+	StatusAbandoned = "abandoned"
+	StatusFailed    = "failed"
+	StatusNotFound  = "not found"
+	StatusRedriven  = "redriven"
+)
+
+var ErrUnknownAction = errors.New("action must be one of redrive or abandon")
+
+// errNotRemoved is returned when a message was redriven but remains in the dead-letter queue.
+var errNotRemoved = errors.New("redriven, but not removed from the dead-letter queue")
+
+// Result describes the outcome of an action on a single message.
+type Result struct {
+	MessageId string `json:"messageId"`
+	S3Id      string `json:"s3Id,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// deleteMessage removes a held message from the dead-letter queue.
+func deleteMessage(client clients.SQS, pipeline Pipeline, message types.Message) error {
+	_, err := client.DeleteMessage(context.TODO(), &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(pipeline.DeadLetterUrl),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+
+	if err != nil {
+		logs.LogError(err, "Delete Dead-Letter Message Error")
+	}
+
+	return err
+}
+
+// redrive returns a message to the queue from which it was dead-lettered, keeping its
+// attributes so that its trace continues, and then removes it from the dead-letter queue.
+// If the message is returned but cannot be removed, errNotRemoved is returned.
+func redrive(client clients.SQS, pipeline Pipeline, message types.Message) error {
+	resp, err := client.SendMessage(context.TODO(), &sqs.SendMessageInput{
+		QueueUrl:          aws.String(pipeline.SourceUrl),
+		MessageBody:       message.Body,
+		MessageAttributes: message.MessageAttributes,
+	})
+
+	if err != nil {
+		logs.LogError(err, "Redrive Dead-Letter Message Error")
+		return err
+	}
+
+	logs.Info("Redrove dead-letter message", "messageId", aws.ToString(message.MessageId), "newMessageId", aws.ToString(resp.MessageId))
+
+	if err = deleteMessage(client, pipeline, message); err != nil {
+		return fmt.Errorf("%w: %w", errNotRemoved, err)
+	}
+
+	return nil
+}
+
+// abandon marks the transfer of the upload which a message concerns as abandoned.
+func abandon(key string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		if key == "" {
+			return nil
+		}
+
+		return uploads.AbandonAprimoTransfer(tx, key)
+	}
+}
+
+// resolveMessage performs an action on a single held message, recording it in the
+// audit log against the upload which the message concerns. Since SQS cannot take part
+// in the transaction, the action is recorded first and the message is only moved once
+// it has been committed. A message which cannot be moved is reported as failed, unless
+// it was redriven but could not then be removed from the dead-letter queue, in which case
+// it is reported as redriven along with the error.
+func resolveMessage(client clients.SQS, pipeline Pipeline, action string, message types.Message, event audit.Event) Result {
+	result := Result{MessageId: aws.ToString(message.MessageId)}
+
+	key, err := messageKey(pipeline.Name, toLambdaMessage(message))
+
+	target := result.MessageId
+	subjects := []audit.Subject{}
+
+	if err == nil {
+		result.S3Id = key
+		target = key
+		subjects = append(subjects, audit.Subject{Table: "uploads", Column: "s3_id", Key: key})
+	}
+
+	event = event.For(target)
+	event.Changes = map[string]audit.Change{
+		"message_id": {Before: result.MessageId, After: nil},
+	}
+
+	change := func(tx *sql.Tx) error { return nil }
+
+	if action == ActionRedrive {
+		event.Action = audit.UploadRedrive
+		event.Changes["queue"] = audit.Change{Before: queueName(pipeline.DeadLetterUrl), After: queueName(pipeline.SourceUrl)}
+		result.Status = StatusRedriven
+	} else {
+		event.Action = audit.UploadAbandon
+		event.Changes["queue"] = audit.Change{Before: queueName(pipeline.DeadLetterUrl), After: nil}
+		change = abandon(result.S3Id)
+		result.Status = StatusAbandoned
+	}
+
+	if err = audit.Transact(event, subjects, change); err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+
+		return result
+	}
+
+	if action == ActionRedrive {
+		err = redrive(client, pipeline, message)
+	} else {
+		err = deleteMessage(client, pipeline, message)
+	}
+
+	if errors.Is(err, errNotRemoved) {
+		result.Error = err.Error()
+	} else if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Resolve redrives or abandons the chosen messages in the dead-letter queue of a pipeline,
+// recording each action in the audit log. The event describes the user performing the
+// action; its action and target are set for each message. Any chosen message which is not
+// found in the queue is reported as such.
+func Resolve(pipeline Pipeline, action string, messageIds []string, event audit.Event) ([]Result, error) {
+	results := []Result{}
+
+	if action != ActionRedrive && action != ActionAbandon {
+		return results, ErrUnknownAction
+	}
+
+	client, err := clients.GetSQS()
+
+	if err != nil {
+		return results, err
+	}
+
+	chosen := map[string]bool{}
+
+	for _, id := range messageIds {
+		chosen[id] = true
+	}
+
+	held, err := hold(client, pipeline.DeadLetterUrl, MaxMessages)
+
+	if err != nil {
+		release(client, pipeline.DeadLetterUrl, held)
+		return results, err
+	}
+
+	unchosen := []types.Message{}
+
+	for _, message := range held {
+		id := aws.ToString(message.MessageId)
+
+		if !chosen[id] {
+			unchosen = append(unchosen, message)
+			continue
+		}
+
+		delete(chosen, id)
+
+		result := resolveMessage(client, pipeline, action, message, event)
+
+		if result.Status == StatusFailed {
+			unchosen = append(unchosen, message)
+		}
+
+		results = append(results, result)
+	}
+
+	release(client, pipeline.DeadLetterUrl, unchosen)
+
+	for _, id := range messageIds {
+		if chosen[id] {
+			results = append(results, Result{MessageId: id, Status: StatusNotFound})
+			delete(chosen, id)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,277 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sesTypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/rs/xid"
+
+	"github.com/IIP-Design/commons-gateway/utils/clients"
+)
+
+// SESMailer sends emails through Amazon SES. If a configuration set is named, SES
+// publishes the bounces and complaints for the emails sent according to its event
+// destinations.
+type SESMailer struct {
+	From             string
+	ConfigurationSet string
+}
+
+// input converts a message into an SES request.
+func (m *SESMailer) input(message Message) ses.SendEmailInput {
+	input := ses.SendEmailInput{
+		Destination: &sesTypes.Destination{
+			CcAddresses: []string{},
+			ToAddresses: message.To,
+		},
+		Content: &sesTypes.EmailContent{
+			Simple: &sesTypes.Message{
+				Body: body(message),
+				Subject: &sesTypes.Content{
+					Charset: aws.String(CharSet),
+					Data:    aws.String(message.Subject),
+				},
+			},
+		},
+		FromEmailAddress: aws.String(sender(message, m.From)),
+	}
+
+	if m.ConfigurationSet != "" {
+		input.ConfigurationSetName = aws.String(m.ConfigurationSet)
+	}
+
+	return input
+}
+
+// body converts the HTML and plain text parts of a message into an SES body.
+func body(message Message) *sesTypes.Body {
+	body := &sesTypes.Body{
+		Html: &sesTypes.Content{
+			Charset: aws.String(CharSet),
+			Data:    aws.String(message.Html),
+		},
+	}
+
+	if message.Text != "" {
+		body.Text = &sesTypes.Content{
+			Charset: aws.String(CharSet),
+			Data:    aws.String(message.Text),
+		}
+	}
+
+	return body
+}
+
+// Send delivers an email through SES, returning the message id assigned by SES.
+func (m *SESMailer) Send(ctx context.Context, message Message) (string, error) {
+	client, err := clients.GetSES()
+
+	if err != nil {
+		return "", err
+	}
+
+	input := m.input(message)
+
+	resp, err := client.SendEmail(ctx, &input)
+
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(resp.MessageId), nil
+}
+
+// SMTPMailer sends emails to an SMTP server, authenticating only if a username is set.
+type SMTPMailer struct {
+	From     string
+	Addr     string
+	Username string
+	Password string
+}
+
+// NewSMTPMailer configures an SMTP mailer from the SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// and SMTP_PASSWORD environment variables. The server defaults to localhost:1025, the
+// address of a local MailHog.
+func NewSMTPMailer(from string) *SMTPMailer {
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+
+	if host == "" {
+		host = "localhost"
+	}
+
+	if port == "" {
+		port = "1025"
+	}
+
+	return &SMTPMailer{
+		From:     from,
+		Addr:     net.JoinHostPort(host, port),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+	}
+}
+
+// Send delivers an email to the SMTP server, returning the Message-ID it was given.
+func (m *SMTPMailer) Send(ctx context.Context, message Message) (string, error) {
+	var auth smtp.Auth
+
+	if m.Username != "" {
+		host, _, _ := net.SplitHostPort(m.Addr)
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	from := sender(message, m.From)
+	id := newMessageId(from)
+
+	err := smtp.SendMail(m.Addr, auth, from, message.To, format(message, from, id, time.Now()))
+
+	return id, err
+}
+
+// FileMailer writes each email to an .eml file, which can be opened by most mail clients.
+type FileMailer struct {
+	From string
+	Dir  string
+}
+
+// NewFileMailer writes emails to the directory in MAIL_SINK_DIR, defaulting to a
+// directory named gateway-mail within the system's temporary directory.
+func NewFileMailer(from string) *FileMailer {
+	dir := os.Getenv("MAIL_SINK_DIR")
+
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "gateway-mail")
+	}
+
+	return &FileMailer{From: from, Dir: dir}
+}
+
+// Send writes an email to the sink directory, returning the Message-ID it was given.
+func (m *FileMailer) Send(ctx context.Context, message Message) (string, error) {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return "", err
+	}
+
+	from := sender(message, m.From)
+	id := newMessageId(from)
+	name := strings.Trim(strings.SplitN(id, "@", 2)[0], "<") + ".eml"
+
+	err := os.WriteFile(filepath.Join(m.Dir, name), format(message, from, id, time.Now()), 0o644)
+
+	return id, err
+}
+
+// MemoryMailer keeps the emails sent so that tests can inspect them.
+type MemoryMailer struct {
+	From string
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+// Send records an email, returning its position in the list of sent emails as its id.
+func (m *MemoryMailer) Send(ctx context.Context, message Message) (string, error) {
+	if len(message.To) == 0 {
+		return "", errors.New("no recipients")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	message.From = sender(message, m.From)
+	m.sent = append(m.sent, message)
+
+	return fmt.Sprintf("memory-%d", len(m.sent)), nil
+}
+
+// Sent returns a copy of the emails sent so far.
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message{}, m.sent...)
+}
+
+// sender returns the sender of a message, falling back to the mailer's default.
+func sender(message Message, from string) string {
+	if message.From != "" {
+		return message.From
+	}
+
+	return from
+}
+
+// newMessageId generates a unique Message-ID in the domain of the sender.
+func newMessageId(from string) string {
+	domain := "localhost"
+
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	return fmt.Sprintf("<%s@%s>", xid.New().String(), domain)
+}
+
+// format renders a message in the Internet Message Format. Messages with a plain text
+// body are sent as multipart/alternative, so that clients may choose which part to show.
+func format(message Message, from string, id string, date time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode(CharSet, message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", id)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if message.Text == "" {
+		writePart(&buf, "text/html", message.Html)
+		return buf.Bytes()
+	}
+
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	for _, part := range []struct{ contentType, content string }{{"text/plain", message.Text}, {"text/html", message.Html}} {
+		w, _ := writer.CreatePart(nil)
+		writePart(w, part.contentType, part.content)
+	}
+
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+// writePart writes the headers and content of a single part of a message, encoding the
+// content as base64 so that long lines and non-ASCII text survive transport.
+func writePart(w io.Writer, contentType string, content string) {
+	fmt.Fprintf(w, "Content-Type: %s; charset=%s\r\n", contentType, CharSet)
+	fmt.Fprint(w, "Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+
+	for len(encoded) > 76 {
+		fmt.Fprint(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+
+	fmt.Fprint(w, encoded+"\r\n")
+}
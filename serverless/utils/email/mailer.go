@@ -0,0 +1,133 @@
+// Package email sends the emails generated by the gateway through a pluggable Mailer.
+//
+// The backend is chosen by the MAILER environment variable:
+//
+//	ses    - sends through Amazon SES (the default)
+//	smtp   - sends to the SMTP server at SMTP_HOST and SMTP_PORT, such as a local MailHog
+//	file   - writes each email to an .eml file in MAIL_SINK_DIR
+//	memory - keeps each email in memory, for use in tests
+//
+// Emails are sent from SOURCE_EMAIL_ADDRESS. This is required when sending through SES,
+// but defaults to DefaultSender for the other backends.
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The available mail backends.
+const (
+	BackendSES    = "ses"
+	BackendSMTP   = "smtp"
+	BackendFile   = "file"
+	BackendMemory = "memory"
+)
+
+// DefaultSender is the address used by the local backends when no source email is set.
+const DefaultSender = "no-reply@localhost"
+
+// CharSet is the character set of every email sent.
+const CharSet = "UTF-8"
+
+var ErrNotConfigured = errors.New("not configured for sending emails")
+
+// Message is a single email, with an HTML body and an optional plain text alternative.
+type Message struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Html    string   `json:"html"`
+	Text    string   `json:"text"`
+}
+
+// Mailer delivers emails, returning an identifier for the sent message.
+type Mailer interface {
+	Send(ctx context.Context, message Message) (string, error)
+}
+
+var (
+	mu     sync.Mutex
+	mailer Mailer
+)
+
+// newMailer builds the mailer selected by the environment.
+func newMailer() (Mailer, error) {
+	backend := os.Getenv("MAILER")
+	sender := os.Getenv("SOURCE_EMAIL_ADDRESS")
+
+	if backend == "" {
+		backend = BackendSES
+	}
+
+	if sender == "" {
+		if backend == BackendSES {
+			return nil, ErrNotConfigured
+		}
+
+		sender = DefaultSender
+	}
+
+	switch backend {
+	case BackendSES:
+		return &SESMailer{From: sender, ConfigurationSet: os.Getenv("SES_CONFIGURATION_SET")}, nil
+	case BackendSMTP:
+		return NewSMTPMailer(sender), nil
+	case BackendFile:
+		return NewFileMailer(sender), nil
+	case BackendMemory:
+		return &MemoryMailer{From: sender}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q, must be one of ses, smtp, file, or memory", backend)
+	}
+}
+
+// GetMailer returns the mailer selected by the environment, building it on first use.
+func GetMailer() (Mailer, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if mailer != nil {
+		return mailer, nil
+	}
+
+	m, err := newMailer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mailer = m
+
+	return mailer, nil
+}
+
+// SetMailer replaces the mailer, typically with a MemoryMailer in tests.
+func SetMailer(m Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	mailer = m
+}
+
+// Reset discards the mailer, so that it is selected afresh on next use.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	mailer = nil
+}
+
+// Send delivers an email using the mailer selected by the environment.
+func Send(ctx context.Context, message Message) (string, error) {
+	m, err := GetMailer()
+
+	if err != nil {
+		return "", err
+	}
+
+	return m.Send(ctx, message)
+}
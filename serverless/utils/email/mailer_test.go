@@ -0,0 +1,202 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func makeMessage() Message {
+	return Message{
+		To:      []string{"guest@example.com"},
+		Subject: "Vérification",
+		Html:    "<p>Hello</p>",
+		Text:    "Hello",
+	}
+}
+
+// useEnv selects a mailer for the duration of a test.
+func useEnv(t *testing.T, backend string, sender string) {
+	t.Setenv("MAILER", backend)
+	t.Setenv("SOURCE_EMAIL_ADDRESS", sender)
+
+	Reset()
+	t.Cleanup(Reset)
+}
+
+func TestGetMailer(t *testing.T) {
+	cases := map[string]Mailer{
+		"":            &SESMailer{},
+		BackendSES:    &SESMailer{},
+		BackendSMTP:   &SMTPMailer{},
+		BackendFile:   &FileMailer{},
+		BackendMemory: &MemoryMailer{},
+	}
+
+	for backend, want := range cases {
+		useEnv(t, backend, "gateway@example.com")
+
+		m, err := GetMailer()
+		if err != nil {
+			t.Fatalf("GetMailer error for %q: %v", backend, err)
+		}
+
+		if got, want := fmt.Sprintf("%T", m), fmt.Sprintf("%T", want); got != want {
+			t.Fatalf("GetMailer for %q returned %s, want %s", backend, got, want)
+		}
+	}
+
+	useEnv(t, "carrier-pigeon", "gateway@example.com")
+
+	if _, err := GetMailer(); err == nil {
+		t.Fatal("GetMailer returned no error for an unknown backend")
+	}
+}
+
+func TestGetMailerSender(t *testing.T) {
+	useEnv(t, BackendSES, "")
+
+	if _, err := GetMailer(); err != ErrNotConfigured {
+		t.Fatalf("GetMailer error %v, want %v", err, ErrNotConfigured)
+	}
+
+	useEnv(t, BackendMemory, "")
+
+	m, _ := GetMailer()
+	if from := m.(*MemoryMailer).From; from != DefaultSender {
+		t.Fatalf("MemoryMailer sender %s, want %s", from, DefaultSender)
+	}
+}
+
+func TestSESMailerConfigurationSet(t *testing.T) {
+	useEnv(t, BackendSES, "gateway@example.com")
+
+	m, _ := GetMailer()
+	if input := m.(*SESMailer).input(makeMessage()); input.ConfigurationSetName != nil {
+		t.Fatalf("SESMailer configuration set %q, want none", *input.ConfigurationSetName)
+	}
+
+	t.Setenv("SES_CONFIGURATION_SET", "commons-gateway-test")
+	useEnv(t, BackendSES, "gateway@example.com")
+
+	m, _ = GetMailer()
+	if input := m.(*SESMailer).input(makeMessage()); input.ConfigurationSetName == nil || *input.ConfigurationSetName != "commons-gateway-test" {
+		t.Fatalf("SESMailer configuration set %v, want commons-gateway-test", input.ConfigurationSetName)
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	sink := &MemoryMailer{From: "gateway@example.com"}
+	SetMailer(sink)
+	t.Cleanup(Reset)
+
+	if _, err := Send(context.TODO(), makeMessage()); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	sent := sink.Sent()
+	if len(sent) != 1 || sent[0].From != "gateway@example.com" || sent[0].To[0] != "guest@example.com" {
+		t.Fatalf("Sent %+v, want one email from gateway@example.com to guest@example.com", sent)
+	}
+
+	if _, err := sink.Send(context.TODO(), Message{Subject: "Nobody"}); err == nil {
+		t.Fatal("Send returned no error for an email without recipients")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	sink := &FileMailer{From: "gateway@example.com", Dir: t.TempDir()}
+
+	id, err := sink.Send(context.TODO(), makeMessage())
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(sink.Dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("Wrote %d files, want 1", len(files))
+	}
+
+	contents, _ := os.ReadFile(files[0])
+
+	for _, header := range []string{"Message-ID: " + id, "To: guest@example.com", "Subject: =?UTF-8?q?V=C3=A9rification?=", "Content-Type: multipart/alternative", "Content-Type: text/plain"} {
+		if !strings.Contains(string(contents), header) {
+			t.Fatalf("Email missing header %q:\n%s", header, contents)
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	t.Setenv("SMTP_HOST", host)
+	t.Setenv("SMTP_PORT", port)
+
+	if _, err = NewSMTPMailer("gateway@example.com").Send(context.TODO(), makeMessage()); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	if data := <-received; !strings.Contains(data, "From: gateway@example.com") {
+		t.Fatalf("Server received %q, want an email from gateway@example.com", data)
+	}
+}
+
+// serveSMTP accepts a single email, in the manner of a local MailHog.
+func serveSMTP(listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 go ahead")
+
+			var data strings.Builder
+
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+
+				data.WriteString(line)
+			}
+
+			received <- data.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -2,21 +2,11 @@ package propose
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"os"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/email"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
-	sesTypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
-)
-
-const (
-	Subject = "Content Commons Support Staff Request"
-	CharSet = "UTF-8"
 )
 
 type RequestSupportStaffData struct {
@@ -25,14 +15,17 @@ type RequestSupportStaffData struct {
 	Url      string    `json:"url"`
 }
 
-// getAdmins retrieves a list of admins assigned to a given team.
+// getAdmins retrieves a list of admins assigned to a given team, omitting those
+// whose addresses are suppressed.
 func getAdmins(team string) ([]data.User, error) {
 	var admins []data.User
 
 	pool := data.ConnectToDB()
 	defer pool.Close()
 
-	query := "SELECT email, first_name, last_name, role, team FROM admins WHERE team = $1"
+	query :=
+		`SELECT email, first_name, last_name, role, team FROM admins
+		 WHERE team = $1 AND LOWER( email ) NOT IN ( SELECT email FROM email_suppressions )`
 	rows, err := pool.Query(query, team)
 
 	if err != nil {
@@ -68,81 +61,36 @@ func getAdmins(team string) ([]data.User, error) {
 	return admins, nil
 }
 
-// formatEmailBody populates the template of the invite proposal
-// notification email with the relevant values.
-func formatEmailBody(
-	proposer data.User,
-	invitee data.User,
-	admin data.User,
-	url string,
-) string {
-	return fmt.Sprintf(
-		`<p>%s %s,</p>
-		 <p>%s %s has submitted a ticket for adding %s %s for your approval. Please follow <a href="%s">this link</a> to approve or deny this request.</p>
-		 <p>This email was generated automatically. Please do not reply to this email.</p>`,
-		admin.NameFirst, admin.NameLast,
-		proposer.NameFirst, proposer.NameLast,
-		invitee.NameFirst, invitee.NameLast,
-		url,
-	)
-}
-
-// formatEmail prepares the event object that is sent to SES in order
-// to initiate the invite proposal notification email.
+// formatEmail renders the invite proposal notification email
+// sent to a single admin.
 func formatEmail(
 	proposer data.User,
 	invitee data.User,
 	admin data.User,
 	url string,
-	sourceEmail string,
-) ses.SendEmailInput {
-	return ses.SendEmailInput{
-		Destination: &sesTypes.Destination{
-			CcAddresses: []string{},
-			ToAddresses: []string{
-				admin.Email,
-			},
-		},
-		Content: &sesTypes.EmailContent{
-			Simple: &sesTypes.Message{
-				Body: &sesTypes.Body{
-					Html: &sesTypes.Content{
-						Charset: aws.String(CharSet),
-						Data:    aws.String(formatEmailBody(proposer, invitee, admin, url)),
-					},
-				},
-				Subject: &sesTypes.Content{
-					Charset: aws.String(CharSet),
-					Data:    aws.String(Subject),
-				},
-			},
-		},
-		FromEmailAddress: &sourceEmail,
-	}
+) (email.Message, error) {
+	message, err := email.Render(email.TemplateProposal, admin.Locale, email.ProposalData{
+		Recipient: admin,
+		Proposer:  proposer,
+		Invitee:   invitee,
+		Url:       url,
+	})
+
+	message.To = []string{admin.Email}
+
+	return message, err
 }
 
 // MailProposedInvite sends an email to all admins of the relevant team, informing
 // them that the proposer has requested access for a new user (the invitee). The
 // list of recipient admin is derived from the team to which the proposer is assigned.
 func MailProposedInvite(proposer data.User, invitee data.User) error {
-	sourceEmail := os.Getenv("SOURCE_EMAIL_ADDRESS")
 	redirectUrl := os.Getenv("EMAIL_REDIRECT_URL")
 
-	if sourceEmail == "" {
-		err := errors.New("not configured for sending emails")
-
-		logs.LogError(err, "Missing Source Email Error")
-		return nil
-	}
-
-	awsRegion := os.Getenv("AWS_SES_REGION")
-
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(awsRegion),
-	)
+	mailer, err := email.GetMailer()
 
 	if err != nil {
-		logs.LogError(err, "Error Loading AWS Configuration")
+		logs.LogError(err, "Get Mailer Error")
 		return err
 	}
 
@@ -153,18 +101,20 @@ func MailProposedInvite(proposer data.User, invitee data.User) error {
 		return err
 	}
 
-	sesClient := ses.NewFromConfig(cfg)
-
 	for _, admin := range admins {
-		e := formatEmail(
+		e, err := formatEmail(
 			proposer,
 			invitee,
 			admin,
 			redirectUrl,
-			sourceEmail,
 		)
 
-		_, err := sesClient.SendEmail(context.TODO(), &e)
+		if err != nil {
+			logs.LogError(err, "Format Error")
+			continue
+		}
+
+		_, err = mailer.Send(context.TODO(), e)
 
 		if err != nil {
 			logs.LogError(err, "Send Error")
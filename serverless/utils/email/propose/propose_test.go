@@ -36,20 +36,21 @@ func TestFormatEmail(t *testing.T) {
 	}
 
 	url := os.Getenv("EMAIL_REDIRECT_URL")
-	sourceEmail := os.Getenv("SOURCE_EMAIL_ADDRESS")
 
-	e := formatEmail(
+	e, err := formatEmail(
 		proposer,
 		invitee,
 		admin,
 		url,
-		sourceEmail,
 	)
 
-	if len(e.Destination.ToAddresses) != 1 {
-		t.Fatalf(`ToAddresses length %d, want 1`, len(e.Destination.ToAddresses))
+	if err != nil {
+		t.Fatalf(`formatEmail error %v, want nil`, err)
 	}
-	if e.Destination.ToAddresses[0] != admin.Email {
-		t.Fatalf(`ToAddresses %s, want %s`, e.Destination.ToAddresses[0], invitee.Email)
+	if len(e.To) != 1 {
+		t.Fatalf(`To length %d, want 1`, len(e.To))
+	}
+	if e.To[0] != admin.Email {
+		t.Fatalf(`To %s, want %s`, e.To[0], invitee.Email)
 	}
 }
@@ -2,21 +2,15 @@ package provision
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"database/sql"
 	"os"
+	"time"
 
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
 	"github.com/IIP-Design/commons-gateway/utils/data/guests"
+	"github.com/IIP-Design/commons-gateway/utils/data/outbox"
+	"github.com/IIP-Design/commons-gateway/utils/email"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
-	sesTypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
-)
-
-const (
-	CharSet = "UTF-8"
 )
 
 // There are various cases in which we provision credentials.
@@ -29,108 +23,48 @@ const (
 	Reset
 )
 
-// Subject returns the email subject line appropriate to
-// a given provisioning action.
-func (pt ProvisionType) Subject() string {
-	switch pt {
-	case Create:
-		return "Content Commons Account Created"
-	case Reauth:
-		return "Content Commons Account Reactivation"
-	case Reset:
-		return "Content Commons Password Reset"
-	default:
-		return "Content Commons Account"
-	}
-}
-
-// Verb returns the email subject line appropriate to
-// a given provisioning action.
+// Verb returns the provisioning action described by the
+// credentials email for a given provisioning type.
 func (pt ProvisionType) Verb() string {
 	switch pt {
 	case Create:
-		return "created"
+		return email.ActionCreated
 	case Reauth:
-		return "reactivated"
+		return email.ActionReactivated
 	case Reset:
-		return "reset"
+		return email.ActionReset
 	default:
-		return "updated"
+		return email.ActionCreated
 	}
 }
 
-// formatExpirationLine retrieves the access expiration date for a given user. It then
-// formats that date into a common locale string and outputs a sentence informing the
-// user when their access expires. If there is a problem with retrieving the expiration
-// date, the function simply returns an empty string.
-func formatExpirationLine(email string) string {
-	// Defines an easily readable date format.
-	const LocaleDate = "January 2, 2006"
-
-	var expirationText string
-	expires, err := guests.RetrieveGuestExpiration(email)
+// retrieveExpiration looks up the access expiration date for a given user. If there is
+// a problem retrieving the date, the zero time is returned and the email omits it.
+func retrieveExpiration(address string) time.Time {
+	expires, err := guests.RetrieveGuestExpiration(address)
 
 	if err != nil {
 		logs.LogError(err, "Set Expiration Text Error")
-		expirationText = ""
-	} else {
-		textDate := expires.Format(LocaleDate)
-		expirationText = fmt.Sprintf("You have been granted access to the uploader portal until %s.", textDate)
+		return time.Time{}
 	}
 
-	return expirationText
+	return expires
 }
 
-// formatEmailBody populates the email template providing a user with their credentials. It replaces
-// placeholders with account information pertinent to the given user/account action.
-func formatEmailBody(invitee data.User, tmpPassword string, url string, verb string) string {
-
-	expirationText := formatExpirationLine(invitee.Email)
-
-	return fmt.Sprintf(
-		`<p>%s %s,</p>
-		<p>Your content upload account has been successfully %s. Please access the link below to finish provisioning your account.</p>
-		<a href="%s">%s</a>
-		<p>Please use this email address as your username. Your temporary password is: %s</p>
-		<p>%s</p>
-		<p>This email was generated automatically. Please do not reply to this email.</p>`,
-		invitee.NameFirst,
-		invitee.NameLast,
-		verb,
-		url,
-		url,
-		tmpPassword,
-		expirationText,
-	)
-}
+// formatEmail renders the email providing a user with their credentials, in the user's
+// preferred language, populated with the information specific to the account action.
+func formatEmail(invitee data.User, tmpPassword string, url string, action ProvisionType, expires time.Time) (email.Message, error) {
+	message, err := email.Render(email.TemplateCredentials, invitee.Locale, email.CredentialsData{
+		Recipient: invitee,
+		Action:    action.Verb(),
+		Url:       url,
+		Password:  tmpPassword,
+		Expires:   expires,
+	})
 
-// formatEmail populates an SES template with the information specific to the given user/account
-// action. This is then used to trigger an SES event that email the user their temporary credentials.
-func formatEmail(invitee data.User, tmpPassword string, url string, sourceEmail string, action ProvisionType) ses.SendEmailInput {
-
-	return ses.SendEmailInput{
-		Destination: &sesTypes.Destination{
-			CcAddresses: []string{},
-			ToAddresses: []string{
-				invitee.Email,
-			},
-		},
-		Content: &sesTypes.EmailContent{
-			Simple: &sesTypes.Message{
-				Body: &sesTypes.Body{
-					Html: &sesTypes.Content{
-						Charset: aws.String(CharSet),
-						Data:    aws.String(formatEmailBody(invitee, tmpPassword, url, action.Verb())),
-					},
-				},
-				Subject: &sesTypes.Content{
-					Charset: aws.String(CharSet),
-					Data:    aws.String(action.Subject()),
-				},
-			},
-		},
-		FromEmailAddress: &sourceEmail,
-	}
+	message.To = []string{invitee.Email}
+
+	return message, err
 }
 
 // MailProvisionedCreds emails the user a temporary password that can be used to login
@@ -141,43 +75,82 @@ func formatEmail(invitee data.User, tmpPassword string, url string, sourceEmail
 //	1 - used when reauthorizing an existing expired account
 //	2 - used when resetting an existing account password
 func MailProvisionedCreds(invitee data.User, tmpPassword string, action ProvisionType) (string, error) {
-	var err error
-	var messageId string
-
-	sourceEmail := os.Getenv("SOURCE_EMAIL_ADDRESS")
 	redirectUrl := os.Getenv("EMAIL_REDIRECT_URL")
 
-	if sourceEmail == "" {
-		logs.LogError(errors.New("not configured for sending emails"), "Source Email Empty Error")
-		return messageId, err
-	}
+	e, err := formatEmail(
+		invitee,
+		tmpPassword,
+		redirectUrl,
+		action,
+		retrieveExpiration(invitee.Email),
+	)
 
-	awsRegion := os.Getenv("AWS_SES_REGION")
+	if err != nil {
+		logs.LogError(err, "Format Credentials Email Error")
+		return "", err
+	}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(awsRegion))
+	messageId, err := email.Send(context.TODO(), e)
 
 	if err != nil {
-		logs.LogError(err, "Error Loading AWS Config")
-		return messageId, err
+		logs.LogError(err, "Credentials Provisioning Email Error")
 	}
 
-	sesClient := ses.NewFromConfig(cfg)
+	return messageId, err
+}
 
-	e := formatEmail(
-		invitee,
-		tmpPassword,
-		redirectUrl,
-		sourceEmail,
-		action,
-	)
+// recipientDetails looks up, within the transaction saving their credentials, the access
+// expiration date of a user and, if it was not provided, their preferred language. Users
+// without an invitation are returned unchanged, with the zero time as their expiration.
+func recipientDetails(tx *sql.Tx, invitee data.User) (data.User, time.Time, error) {
+	var expires time.Time
+	var locale string
+
+	query :=
+		`SELECT i.expiration, g.locale FROM invites i JOIN guests g ON g.email = i.invitee
+		 WHERE i.invitee = $1 ORDER BY i.date_invited DESC LIMIT 1`
+	err := tx.QueryRow(query, invitee.Email).Scan(&expires, &locale)
+
+	if err == sql.ErrNoRows {
+		return invitee, time.Time{}, nil
+	} else if err != nil {
+		logs.LogError(err, "Retrieve Recipient Details Query Error")
+		return invitee, time.Time{}, err
+	}
+
+	if invitee.Locale == "" {
+		invitee.Locale = locale
+	}
+
+	return invitee, expires, nil
+}
 
-	resp, err := sesClient.SendEmail(context.TODO(), &e)
+// Enqueue adds the email providing the user with their temporary password to the outbox,
+// as part of the transaction which saves the password, and returns the id of the email.
+func Enqueue(tx *sql.Tx, invitee data.User, pass string, action ProvisionType) (string, error) {
+	recipient, expires, err := recipientDetails(tx, invitee)
 
 	if err != nil {
-		logs.LogError(err, "Credentials Provisioning Email Error")
+		return "", err
 	}
 
-	messageId = *resp.MessageId
+	e, err := formatEmail(recipient, pass, os.Getenv("EMAIL_REDIRECT_URL"), action, expires)
 
-	return messageId, err
+	if err != nil {
+		logs.LogError(err, "Format Credentials Email Error")
+		return "", err
+	}
+
+	return outbox.Enqueue(tx, email.TemplateCredentials, e)
+}
+
+// Queue returns a notification which adds the email providing the user with their
+// temporary password to the outbox, in the same transaction that saves the password.
+// The email is then delivered by the dispatcher, or sooner by flushing the outbox.
+func Queue(invitee data.User, action ProvisionType) outbox.Notify {
+	return func(tx *sql.Tx, pass string) error {
+		_, err := Enqueue(tx, invitee, pass, action)
+
+		return err
+	}
 }
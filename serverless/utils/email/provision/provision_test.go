@@ -1,13 +1,26 @@
 package provision
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	"github.com/IIP-Design/commons-gateway/utils/clients"
 	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/IIP-Design/commons-gateway/utils/email"
+	ses "github.com/aws/aws-sdk-go-v2/service/sesv2"
 )
 
+// failingSES rejects every email, returning no output as the real client does.
+type failingSES struct{}
+
+func (failingSES) SendEmail(ctx context.Context, params *ses.SendEmailInput, optFns ...func(*ses.Options)) (*ses.SendEmailOutput, error) {
+	return nil, errors.New("email address is not verified")
+}
+
 func TestFormatEmail(t *testing.T) {
 	testConfig.ConfigureEmail()
 
@@ -21,20 +34,50 @@ func TestFormatEmail(t *testing.T) {
 
 	tmpPassword := "abcfef"
 	redirectUrl := os.Getenv("EMAIL_REDIRECT_URL")
-	sourceEmail := os.Getenv("SOURCE_EMAIL_ADDRESS")
 
-	e := formatEmail(
+	e, err := formatEmail(
 		invitee,
 		tmpPassword,
 		redirectUrl,
-		sourceEmail,
 		1,
+		time.Time{},
 	)
 
-	if len(e.Destination.ToAddresses) != 1 {
-		t.Fatalf(`ToAddresses length %d, want 1`, len(e.Destination.ToAddresses))
+	if err != nil {
+		t.Fatalf(`formatEmail error %v, want nil`, err)
+	}
+	if e.Subject != "Content Commons Account Reactivation" {
+		t.Fatalf(`Subject %s, want %s`, e.Subject, "Content Commons Account Reactivation")
+	}
+	if len(e.To) != 1 {
+		t.Fatalf(`To length %d, want 1`, len(e.To))
+	}
+	if e.To[0] != invitee.Email {
+		t.Fatalf(`To %s, want %s`, e.To[0], invitee.Email)
+	}
+}
+
+func TestFormatEmailLocale(t *testing.T) {
+	invitee := data.User{Email: "test@test.com", NameFirst: "João", NameLast: "Público", Locale: "pt"}
+
+	e, err := formatEmail(invitee, "abcfef", "https://example.com", Reset, time.Time{})
+	if err != nil || e.Subject != "Redefinição da senha do Content Commons" {
+		t.Fatalf(`formatEmail result %s/%v, want the Portuguese reset subject/nil`, e.Subject, err)
 	}
-	if e.Destination.ToAddresses[0] != invitee.Email {
-		t.Fatalf(`ToAddresses %s, want %s`, e.Destination.ToAddresses[0], invitee.Email)
+}
+
+func TestMailProvisionedCredsFailure(t *testing.T) {
+	testConfig.ConfigureEmail()
+
+	email.SetMailer(&email.SESMailer{From: os.Getenv("SOURCE_EMAIL_ADDRESS")})
+	clients.SetSES(failingSES{})
+	t.Cleanup(email.Reset)
+	t.Cleanup(clients.Reset)
+
+	invitee := data.User{Email: "test@test.com", NameFirst: "John", NameLast: "Public"}
+
+	messageId, err := MailProvisionedCreds(invitee, "abcfef", Create)
+	if messageId != "" || err == nil {
+		t.Fatalf(`MailProvisionedCreds result %s/%v, want ""/error`, messageId, err)
 	}
 }
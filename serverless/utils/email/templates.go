@@ -0,0 +1,222 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"path"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+)
+
+// The emails which can be rendered from templates.
+const (
+	TemplateCredentials   = "credentials"
+	TemplateDigest        = "digest"
+	TemplateExpiring      = "expiring"
+	TemplateGuestExpiring = "guest-expiring"
+	TemplateMFACode       = "mfa-code"
+	TemplateProposal      = "proposal"
+	TemplateRejected      = "rejected"
+	TemplateUndeliverable = "undeliverable"
+)
+
+// The provisioning actions described by the credentials email.
+const (
+	ActionCreated     = "created"
+	ActionReactivated = "reactivated"
+	ActionReset       = "reset"
+)
+
+// CredentialsData fills the email providing a guest with a temporary password.
+// Expires may be left as the zero time if the guest's access expiration is unknown.
+type CredentialsData struct {
+	Recipient data.User
+	Action    string
+	Url       string
+	Password  string
+	Expires   time.Time
+}
+
+// DigestData fills the daily email summarizing an admin's team activity. Each list may
+// be left empty, in which case its section is omitted.
+type DigestData struct {
+	Recipient data.User
+	Date      time.Time
+	Pending   []DigestItem
+	Expiring  []DigestItem
+	Expired   []DigestItem
+	Failed    []DigestItem
+}
+
+// DigestItem is an entry in the daily digest, linking to the user it concerns. Detail
+// holds the proposer of a pending invitation or the description of a failed upload, and
+// Date the requested or past expiration of a guest's access.
+type DigestItem struct {
+	User   data.User
+	Detail string
+	Date   time.Time
+	Url    string
+}
+
+// ExpiringData fills the email reminding a guest that their access is about to expire,
+// with the link at which they can be reauthorized. Inviter may be left empty if the
+// guest's inviter is unknown.
+type ExpiringData struct {
+	Recipient data.User
+	Inviter   data.User
+	Expires   time.Time
+	Url       string
+}
+
+// GuestExpiringData fills the email reminding the user who invited a guest that the
+// guest's access is about to expire, with a link to reauthorize them.
+type GuestExpiringData struct {
+	Recipient data.User
+	Guest     data.User
+	Expires   time.Time
+	Url       string
+}
+
+// MFACodeData fills the email providing a guest with a verification code.
+type MFACodeData struct {
+	Recipient data.User
+	Code      string
+}
+
+// ProposalData fills the email asking an admin to approve a proposed invitation.
+type ProposalData struct {
+	Recipient data.User
+	Proposer  data.User
+	Invitee   data.User
+	Url       string
+}
+
+// RejectedData fills the email telling a guest admin that an admin declined their
+// proposal to invite a guest, with a link to propose the guest again. Reason may be left
+// empty if the admin did not give one.
+type RejectedData struct {
+	Recipient data.User
+	Guest     data.User
+	Reason    string
+	Url       string
+}
+
+// UndeliverableData fills the email telling an admin that emails to a guest they invited
+// are no longer sent, because one bounced or the guest marked one as spam.
+type UndeliverableData struct {
+	Recipient data.User
+	Guest     data.User
+	Complaint bool
+	Url       string
+}
+
+// The default templates. Each email has a directory holding an HTML and a plain text
+// template per locale, e.g. `credentials/fr.html` and `credentials/fr.txt`. The plain
+// text template also defines the subject line. Every HTML template is rendered within
+// the shared `layout.html`.
+//
+//go:embed templates
+var templates embed.FS
+
+// rtlLocales lists the locales which are written from right to left.
+var rtlLocales = map[string]bool{"ar": true}
+
+// months holds the names of the months in each locale, from January to December.
+var months = map[string][12]string{
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	"fr": {"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	"ar": {"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+	"pt": {"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+}
+
+// formatDate writes a date in the long form customary in the given locale.
+func formatDate(locale string, date time.Time) string {
+	month := months[locale][date.Month()-1]
+
+	switch locale {
+	case "en":
+		return fmt.Sprintf("%s %d, %d", month, date.Day(), date.Year())
+	case "es", "pt":
+		return fmt.Sprintf("%d de %s de %d", date.Day(), month, date.Year())
+	default:
+		return fmt.Sprintf("%d %s %d", date.Day(), month, date.Year())
+	}
+}
+
+// funcs returns the functions available to the templates of a locale.
+func funcs(locale string) map[string]any {
+	return map[string]any{
+		"date": func(date time.Time) string { return formatDate(locale, date) },
+		"dir": func() string {
+			if rtlLocales[locale] {
+				return "rtl"
+			}
+
+			return "ltr"
+		},
+		"lang": func() string { return locale },
+	}
+}
+
+// resolveLocale picks the locale in which to render an email, falling back to the
+// default for recipients without a supported preference or templates without a variant.
+func resolveLocale(name string, locale string) string {
+	locale, err := data.NormalizeLocale(locale)
+
+	if err != nil || locale == "" {
+		return data.DefaultLocale
+	}
+
+	if _, err = templates.Open(path.Join("templates", name, locale+".txt")); err != nil {
+		return data.DefaultLocale
+	}
+
+	return locale
+}
+
+// Render builds the subject and the HTML and plain text bodies of an email from the
+// named template, in the given locale. Values are escaped in the HTML body.
+func Render(name string, locale string, values any) (Message, error) {
+	var message Message
+
+	locale = resolveLocale(name, locale)
+	dir := path.Join("templates", name)
+
+	text, err := textTemplate.New(locale+".txt").Funcs(funcs(locale)).ParseFS(templates, path.Join(dir, locale+".txt"))
+
+	if err != nil {
+		return message, err
+	}
+
+	html, err := htmlTemplate.New("layout.html").Funcs(funcs(locale)).ParseFS(templates, "templates/layout.html", path.Join(dir, locale+".html"))
+
+	if err != nil {
+		return message, err
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+
+	if err = text.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return message, err
+	}
+
+	if err = text.Execute(&textBody, values); err != nil {
+		return message, err
+	}
+
+	if err = html.Execute(&htmlBody, values); err != nil {
+		return message, err
+	}
+
+	message.Subject = strings.TrimSpace(subject.String())
+	message.Text = strings.TrimSpace(textBody.String()) + "\n"
+	message.Html = htmlBody.String()
+
+	return message, nil
+}
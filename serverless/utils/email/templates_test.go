@@ -0,0 +1,237 @@
+package email
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+)
+
+// Run `go test ./utils/email -update` to rewrite the golden files after changing a template.
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+var (
+	guest = data.User{Email: "guest@example.com", NameFirst: "Carmen", NameLast: "Lowell"}
+	admin = data.User{Email: "admin@example.com", NameFirst: "Amy", NameLast: "Apple"}
+)
+
+// goldenValues holds the values with which each template is rendered for its golden files.
+var goldenValues = map[string]any{
+	TemplateCredentials: CredentialsData{
+		Recipient: guest,
+		Action:    ActionCreated,
+		Url:       "https://commons.example.com/login",
+		Password:  "Tmp-Pass-123",
+		Expires:   time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC),
+	},
+	TemplateDigest: DigestData{
+		Recipient: admin,
+		Date:      time.Date(2024, time.March, 1, 13, 0, 0, 0, time.UTC),
+		Pending: []DigestItem{
+			{User: guest, Detail: "Jane Doe", Url: "https://commons.example.com/edit-user?id=guest%40example.com"},
+		},
+		Expiring: []DigestItem{
+			{User: guest, Date: time.Date(2024, time.March, 3, 12, 0, 0, 0, time.UTC), Url: "https://commons.example.com/edit-user?id=guest%40example.com"},
+		},
+		Expired: []DigestItem{
+			{User: guest, Date: time.Date(2024, time.February, 29, 18, 0, 0, 0, time.UTC), Url: "https://commons.example.com/edit-user?id=guest%40example.com"},
+		},
+		Failed: []DigestItem{
+			{User: guest, Detail: "Press release", Url: "https://commons.example.com/edit-user?id=guest%40example.com"},
+		},
+	},
+	TemplateExpiring: ExpiringData{
+		Recipient: guest,
+		Inviter:   admin,
+		Expires:   time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC),
+		Url:       "https://commons.example.com/edit-user?id=guest%40example.com",
+	},
+	TemplateGuestExpiring: GuestExpiringData{
+		Recipient: admin,
+		Guest:     guest,
+		Expires:   time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC),
+		Url:       "https://commons.example.com/edit-user?id=guest%40example.com",
+	},
+	TemplateMFACode: MFACodeData{
+		Recipient: guest,
+		Code:      "123456",
+	},
+	TemplateProposal: ProposalData{
+		Recipient: admin,
+		Proposer:  data.User{NameFirst: "Jane", NameLast: "Doe"},
+		Invitee:   guest,
+		Url:       "https://commons.example.com/pending",
+	},
+	TemplateRejected: RejectedData{
+		Recipient: data.User{Email: "proposer@example.com", NameFirst: "Jane", NameLast: "Doe"},
+		Guest:     guest,
+		Reason:    "The partner's contract has ended.",
+		Url:       "https://commons.example.com/new-user",
+	},
+	TemplateUndeliverable: UndeliverableData{
+		Recipient: admin,
+		Guest:     guest,
+		Url:       "https://commons.example.com/edit-user?id=guest%40example.com",
+	},
+}
+
+// golden renders a message as it is stored in a golden file.
+func golden(message Message) string {
+	return fmt.Sprintf("Subject: %s\n\n%s\n%s", message.Subject, message.Text, message.Html)
+}
+
+func TestTemplatesGolden(t *testing.T) {
+	for name, values := range goldenValues {
+		for _, locale := range data.Locales {
+			message, err := Render(name, locale, values)
+			if err != nil {
+				t.Fatalf("Render %s/%s error: %v", name, locale, err)
+			}
+
+			path := filepath.Join("testdata", fmt.Sprintf("%s.%s.golden", name, locale))
+
+			if *update {
+				if err = os.WriteFile(path, []byte(golden(message)), 0o644); err != nil {
+					t.Fatalf("Write golden file error: %v", err)
+				}
+
+				continue
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Read golden file error: %v", err)
+			}
+
+			if got := golden(message); got != string(want) {
+				t.Fatalf("Render %s/%s result:\n%s\nwant:\n%s", name, locale, got, want)
+			}
+		}
+	}
+}
+
+func TestRenderActions(t *testing.T) {
+	values := goldenValues[TemplateCredentials].(CredentialsData)
+	values.Action = ActionReset
+	values.Expires = time.Time{}
+
+	message, err := Render(TemplateCredentials, "en", values)
+	if err != nil || message.Subject != "Content Commons Password Reset" {
+		t.Fatalf("Render result %q/%v, want the password reset subject/nil", message.Subject, err)
+	}
+
+	if strings.Contains(message.Text, "granted access") {
+		t.Fatalf("Render result %q, want no expiration line", message.Text)
+	}
+}
+
+func TestRenderComplaint(t *testing.T) {
+	values := goldenValues[TemplateUndeliverable].(UndeliverableData)
+	values.Complaint = true
+
+	message, err := Render(TemplateUndeliverable, "en", values)
+	if err != nil || message.Subject != "Content Commons Guest Reported an Email as Spam" {
+		t.Fatalf("Render result %q/%v, want the complaint subject/nil", message.Subject, err)
+	}
+
+	if strings.Contains(message.Text, "could not be delivered") {
+		t.Fatalf("Render result %q, want no bounce explanation", message.Text)
+	}
+}
+
+func TestRenderUnknownInviter(t *testing.T) {
+	values := goldenValues[TemplateExpiring].(ExpiringData)
+	values.Inviter = data.User{}
+
+	message, err := Render(TemplateExpiring, "en", values)
+	if err != nil || !strings.Contains(message.Text, "ask the person who invited you") {
+		t.Fatalf("Render result %q/%v, want the inviter described generically/nil", message.Text, err)
+	}
+}
+
+func TestRenderEscapesHtml(t *testing.T) {
+	values := goldenValues[TemplateMFACode].(MFACodeData)
+	values.Recipient.NameFirst = `<script>alert("hi")</script>`
+
+	message, err := Render(TemplateMFACode, "en", values)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	if strings.Contains(message.Html, "<script>") || !strings.Contains(message.Html, "&lt;script&gt;") {
+		t.Fatalf("Render result %q, want the name escaped", message.Html)
+	}
+}
+
+func TestRenderLocaleFallback(t *testing.T) {
+	for _, locale := range []string{"", "de", "fr-CA", "AR"} {
+		message, err := Render(TemplateMFACode, locale, goldenValues[TemplateMFACode])
+		if err != nil {
+			t.Fatalf("Render error: %v", err)
+		}
+
+		want := map[string]string{"": "en", "de": "en", "fr-CA": "fr", "AR": "ar"}[locale]
+		dir := map[string]string{"en": "ltr", "fr": "ltr", "ar": "rtl"}[want]
+
+		if !strings.Contains(message.Html, fmt.Sprintf(`<html lang="%s" dir="%s">`, want, dir)) {
+			t.Fatalf("Render %q result %q, want lang %s and dir %s", locale, message.Html, want, dir)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, time.August, 2, 0, 0, 0, 0, time.UTC)
+	cases := map[string]string{
+		"en": "August 2, 2024",
+		"fr": "2 août 2024",
+		"es": "2 de agosto de 2024",
+		"pt": "2 de agosto de 2024",
+		"ar": "2 أغسطس 2024",
+	}
+
+	for locale, want := range cases {
+		if got := formatDate(locale, date); got != want {
+			t.Fatalf("formatDate %s result %s, want %s", locale, got, want)
+		}
+	}
+}
+
+func TestRenderDigestSections(t *testing.T) {
+	values := goldenValues[TemplateDigest].(DigestData)
+	values.Pending = nil
+	values.Failed = nil
+
+	message, err := Render(TemplateDigest, "en", values)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	for _, heading := range []string{"awaiting approval", "could not be sent"} {
+		if strings.Contains(message.Text, heading) || strings.Contains(message.Html, heading) {
+			t.Fatalf("Render result %q, want no %q section", message.Text, heading)
+		}
+	}
+
+	if !strings.Contains(message.Text, "ends soon:\n- Carmen Lowell") {
+		t.Fatalf("Render result %q, want the expiring section", message.Text)
+	}
+}
+
+func TestRenderRejectedNoReason(t *testing.T) {
+	values := goldenValues[TemplateRejected].(RejectedData)
+	values.Reason = ""
+
+	message, err := Render(TemplateRejected, "en", values)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	if strings.Contains(message.Text, "reason") || strings.Contains(message.Html, "blockquote") {
+		t.Fatalf("Render result %q, want no reason", message.Text)
+	}
+}
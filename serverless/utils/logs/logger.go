@@ -0,0 +1,81 @@
+package logs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// base writes structured log entries to CloudWatch, redacting any personal or
+// secret values. The level is set by the LOG_LEVEL environment variable.
+var base = newLogger(os.Stdout, levelFromEnv())
+
+// logger is the base logger annotated with the identifiers of the current invocation.
+var logger = base
+
+// levelFromEnv reads the minimum level to log from the environment, defaulting to INFO.
+func levelFromEnv() slog.Level {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(os.Getenv("LOG_LEVEL")))); err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
+}
+
+// newLogger creates a logger which writes JSON entries at or above the given level.
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}))
+}
+
+// Start attaches the Lambda request id, and for API Gateway events the API request id,
+// to every subsequent log entry. It should be called at the beginning of each handler.
+func Start(ctx context.Context, event any) {
+	attrs := []any{}
+
+	if lambdacontext.FunctionName != "" {
+		attrs = append(attrs, slog.String("function", lambdacontext.FunctionName))
+	}
+
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("lambdaRequestId", lc.AwsRequestID))
+	}
+
+	switch e := event.(type) {
+	case events.APIGatewayProxyRequest:
+		if e.RequestContext.RequestID != "" {
+			attrs = append(attrs, slog.String("apiRequestId", e.RequestContext.RequestID))
+		}
+	}
+
+	logger = base.With(attrs...)
+}
+
+// Debug logs a message, with optional key-value pairs, when debug logging is enabled.
+func Debug(message string, args ...any) {
+	logger.Debug(message, args...)
+}
+
+// Info logs a message with optional key-value pairs.
+func Info(message string, args ...any) {
+	logger.Info(message, args...)
+}
+
+// Warn logs a message with optional key-value pairs at the warning level.
+func Warn(message string, args ...any) {
+	logger.Warn(message, args...)
+}
+
+// Error logs a message with optional key-value pairs at the error level.
+func Error(message string, args ...any) {
+	logger.Error(message, args...)
+}
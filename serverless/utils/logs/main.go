@@ -1,23 +1,15 @@
 package logs
 
 import (
-	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 )
 
-// LogError formats error messages with a bespoke message and log it to CloudWatch.
+// LogError logs an error along with a bespoke message describing where it occurred.
 func LogError(err error, message string) {
 	if err == nil {
 		err = errors.New("unknown error")
 	}
 
-	errMessage := map[string]interface{}{
-		"Message": message,
-		"Error":   err.Error(),
-	}
-
-	fullMessage, _ := json.MarshalIndent(errMessage, "", "  ")
-
-	log.Println(string(fullMessage))
+	logger.Error(message, slog.Any("error", err))
 }
@@ -0,0 +1,68 @@
+package logs
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// Attributes whose values are always secret and must never be written to the logs.
+var secretKeys = map[string]bool{
+	"authorization": true,
+	"code":          true,
+	"hash":          true,
+	"passhash":      true,
+	"password":      true,
+	"salt":          true,
+	"secret":        true,
+	"token":         true,
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+\S+`)
+	emailPattern  = regexp.MustCompile(`[\w.+-]+@([\w-]+(?:\.[\w-]+)+)`)
+	hashPattern   = regexp.MustCompile(`\b[0-9a-fA-F]{32,}\b`)
+	tokenPattern  = regexp.MustCompile(`\beyJ[\w-]*\.[\w-]+\.[\w-]+`)
+)
+
+// normalizeKey strips the case and separators from an attribute key, so that
+// `pass_hash`, `passHash`, and `PassHash` are all treated alike.
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// maskEmail hides the local part of an email address while keeping its domain,
+// which is often enough to tell which agency or partner a message concerns.
+func maskEmail(match string) string {
+	return "***@" + emailPattern.FindStringSubmatch(match)[1]
+}
+
+// redactText removes tokens, hashes, and email addresses from free text.
+func redactText(text string) string {
+	text = bearerPattern.ReplaceAllString(text, "Bearer "+redacted)
+	text = tokenPattern.ReplaceAllString(text, redacted)
+	text = hashPattern.ReplaceAllString(text, redacted)
+
+	return emailPattern.ReplaceAllStringFunc(text, maskEmail)
+}
+
+// redactAttr is applied to every attribute of a log entry, including the message,
+// before it is written.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if secretKeys[normalizeKey(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactText(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redactText(err.Error()))
+		}
+	}
+
+	return a
+}
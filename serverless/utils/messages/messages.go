@@ -3,9 +3,10 @@ package messages
 import (
 	"bytes"
 	"encoding/json"
-	"log"
 
 	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
 )
 
 type Response events.APIGatewayProxyResponse
@@ -60,7 +61,8 @@ func SendSuccessMessage() (Response, error) {
 // SendServerError accepts an error and returns it as an API Gateway response with
 // a status code of 500.
 func SendServerError(err error) (Response, error) {
-	log.Println(err.Error())
+	logs.Error("Server Error", "error", err)
+
 	return Response{
 		StatusCode:      500,
 		IsBase64Encoded: false,
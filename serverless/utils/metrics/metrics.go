@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// Namespace is the CloudWatch namespace under which all metrics are published.
+const Namespace = "CommonsGateway"
+
+// The units in which metric values are recorded.
+const (
+	UnitBytes        = "Bytes"
+	UnitCount        = "Count"
+	UnitMilliseconds = "Milliseconds"
+)
+
+// The outcomes recorded for actions which either succeed or fail.
+const (
+	Failure = "failure"
+	Success = "success"
+)
+
+// output receives the metric entries, which CloudWatch extracts from the function logs.
+var output io.Writer = os.Stdout
+
+// now returns the time at which metrics are recorded.
+var now = time.Now
+
+// Metric is a single named value.
+type Metric struct {
+	Name  string
+	Value float64
+	Unit  string
+}
+
+// Dimensions are the properties by which metrics may be grouped. Empty dimensions are
+// omitted. The name of the function emitting the metrics is added automatically.
+type Dimensions struct {
+	Team    string
+	Outcome string
+}
+
+// Counter returns a metric which counts a single occurrence of an event.
+func Counter(name string) Metric {
+	return Metric{Name: name, Value: 1, Unit: UnitCount}
+}
+
+// Bytes returns a metric recording a size in bytes.
+func Bytes(name string, size int64) Metric {
+	return Metric{Name: name, Value: float64(size), Unit: UnitBytes}
+}
+
+// Duration returns a metric recording a length of time in milliseconds.
+func Duration(name string, d time.Duration) Metric {
+	return Metric{Name: name, Value: float64(d.Milliseconds()), Unit: UnitMilliseconds}
+}
+
+// format builds a CloudWatch Embedded Metric Format entry for the given metrics.
+func format(dims Dimensions, metrics []Metric) ([]byte, error) {
+	entry := map[string]any{}
+	keys := []string{}
+
+	for _, dim := range []struct{ key, value string }{
+		{"Function", lambdacontext.FunctionName},
+		{"Team", dims.Team},
+		{"Outcome", dims.Outcome},
+	} {
+		if dim.value != "" {
+			entry[dim.key] = dim.value
+			keys = append(keys, dim.key)
+		}
+	}
+
+	definitions := []map[string]string{}
+
+	for _, metric := range metrics {
+		if _, exists := entry[metric.Name]; exists {
+			return nil, fmt.Errorf("metric name %s conflicts with another field", metric.Name)
+		}
+
+		entry[metric.Name] = metric.Value
+		definitions = append(definitions, map[string]string{"Name": metric.Name, "Unit": metric.Unit})
+	}
+
+	entry["_aws"] = map[string]any{
+		"Timestamp": now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{
+			{
+				"Namespace":  Namespace,
+				"Dimensions": [][]string{keys},
+				"Metrics":    definitions,
+			},
+		},
+	}
+
+	return json.Marshal(entry)
+}
+
+// Emit writes the metrics to the function logs in CloudWatch Embedded Metric Format,
+// from which CloudWatch records them without any further API calls. Failures are
+// logged rather than returned, since a lost metric should never fail a request.
+func Emit(dims Dimensions, metrics ...Metric) {
+	if len(metrics) == 0 {
+		return
+	}
+
+	line, err := format(dims, metrics)
+
+	if err != nil {
+		logs.LogError(err, "Format Metrics Error")
+		return
+	}
+
+	fmt.Fprintln(output, string(line))
+}
+
+// Outcome returns Success if no error occurred and Failure otherwise.
+func Outcome(err error) string {
+	if err != nil {
+		return Failure
+	}
+
+	return Success
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+func capture(write func()) string {
+	body := &bytes.Buffer{}
+	output = body
+	now = func() time.Time { return time.UnixMilli(1700000000000) }
+	lambdacontext.FunctionName = "gateway-test-guest-auth"
+
+	write()
+
+	return body.String()
+}
+
+func TestEmitCounter(t *testing.T) {
+	have := capture(func() {
+		Emit(Dimensions{Outcome: "bad_hash"}, Counter("LoginAttempts"))
+	})
+
+	want := `{"Function":"gateway-test-guest-auth","LoginAttempts":1,"Outcome":"bad_hash",` +
+		`"_aws":{"CloudWatchMetrics":[{"Dimensions":[["Function","Outcome"]],` +
+		`"Metrics":[{"Name":"LoginAttempts","Unit":"Count"}],"Namespace":"CommonsGateway"}],` +
+		`"Timestamp":1700000000000}}` + "\n"
+
+	if have != want {
+		t.Fatalf("Emit wrote %s, want %s", have, want)
+	}
+}
+
+func TestEmitSeveral(t *testing.T) {
+	have := capture(func() {
+		Emit(
+			Dimensions{Team: "team-a", Outcome: Success},
+			Counter("AprimoUploads"),
+			Bytes("AprimoUploadBytes", 2048),
+			Duration("AprimoUploadDuration", 1500*time.Millisecond),
+		)
+	})
+
+	want := `{"AprimoUploadBytes":2048,"AprimoUploadDuration":1500,"AprimoUploads":1,` +
+		`"Function":"gateway-test-guest-auth","Outcome":"success","Team":"team-a",` +
+		`"_aws":{"CloudWatchMetrics":[{"Dimensions":[["Function","Team","Outcome"]],` +
+		`"Metrics":[{"Name":"AprimoUploads","Unit":"Count"},{"Name":"AprimoUploadBytes","Unit":"Bytes"},` +
+		`{"Name":"AprimoUploadDuration","Unit":"Milliseconds"}],"Namespace":"CommonsGateway"}],` +
+		`"Timestamp":1700000000000}}` + "\n"
+
+	if have != want {
+		t.Fatalf("Emit wrote %s, want %s", have, want)
+	}
+}
+
+func TestEmitConflict(t *testing.T) {
+	have := capture(func() {
+		Emit(Dimensions{Team: "team-a"}, Counter("Team"))
+	})
+
+	if have != "" {
+		t.Fatalf("Emit wrote %s, want nothing for a conflicting metric name", have)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	if Outcome(nil) != Success || Outcome(errors.New("test")) != Failure {
+		t.Fatalf("Outcome returned %s/%s, want %s/%s", Outcome(nil), Outcome(errors.New("test")), Success, Failure)
+	}
+}
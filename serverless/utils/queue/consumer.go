@@ -0,0 +1,47 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
+)
+
+// consumeMessage processes a single message within a span continuing the trace of the
+// function which sent it. A panic is converted to an error so that it fails only the
+// message which caused it.
+func consumeMessage(ctx context.Context, name string, message events.SQSMessage, handle func(events.SQSMessage) error) (err error) {
+	end := tracing.StartMessage(ctx, name, message)
+	defer end()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while processing message: %v", r)
+		}
+	}()
+
+	return handle(message)
+}
+
+// Consume processes each message in a batch received from SQS individually. Messages
+// whose handler returns an error are reported as batch item failures, so that SQS retries
+// only those messages rather than redelivering the whole batch. The function consuming
+// the queue must have ReportBatchItemFailures enabled for its SQS event source.
+func Consume(ctx context.Context, name string, event events.SQSEvent, handle func(events.SQSMessage) error) events.SQSEventResponse {
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+
+	for _, message := range event.Records {
+		if err := consumeMessage(ctx, name, message, handle); err != nil {
+			logs.LogError(err, fmt.Sprintf("Process Message %s Error", message.MessageId))
+
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+
+	return response
+}
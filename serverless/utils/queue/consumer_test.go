@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestConsume(t *testing.T) {
+	event := events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "1", Body: "ok"},
+			{MessageId: "2", Body: "fail"},
+			{MessageId: "3", Body: "panic"},
+			{MessageId: "4", Body: "ok"},
+		},
+	}
+
+	processed := []string{}
+
+	resp := Consume(context.TODO(), "test", event, func(message events.SQSMessage) error {
+		processed = append(processed, message.MessageId)
+
+		switch message.Body {
+		case "fail":
+			return errors.New("test")
+		case "panic":
+			panic("test")
+		}
+
+		return nil
+	})
+
+	if len(processed) != 4 {
+		t.Fatalf("Consume processed messages %v, want all four", processed)
+	}
+
+	failures := resp.BatchItemFailures
+
+	if len(failures) != 2 || failures[0].ItemIdentifier != "2" || failures[1].ItemIdentifier != "3" {
+		t.Fatalf("Consume reported failures %v, want messages 2 and 3", failures)
+	}
+}
+
+func TestConsumeSuccess(t *testing.T) {
+	event := events.SQSEvent{Records: []events.SQSMessage{{MessageId: "1"}}}
+
+	resp := Consume(context.TODO(), "test", event, func(message events.SQSMessage) error {
+		return nil
+	})
+
+	if resp.BatchItemFailures == nil || len(resp.BatchItemFailures) != 0 {
+		t.Fatalf("Consume reported failures %v, want an empty list", resp.BatchItemFailures)
+	}
+}
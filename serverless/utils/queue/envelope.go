@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/xid"
+)
+
+// Envelope wraps the payload of every message which the gateway sends to one of its
+// queues, identifying what the payload contains and which version of its format it uses.
+type Envelope struct {
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Id        string          `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// LegacyVersion is the version of a message body sent before payloads were wrapped in
+// envelopes. Each payload kept the format of its legacy body, so such a body is read as
+// a whole as the payload its consumer expects. This is only needed until the queues have
+// drained of legacy messages, and is to be removed in the following release.
+const LegacyVersion = 0
+
+// errUnwrapped is returned by Open for a body which is not wrapped in an envelope.
+var errUnwrapped = errors.New("malformed message envelope: missing type")
+
+// Message is implemented by the payload of each type of queue message.
+type Message interface {
+	// MessageType names the type of message, which the consumer checks before
+	// decoding the payload.
+	MessageType() string
+	// MessageVersion is the version of the payload format. It should be incremented
+	// whenever the payload changes in a way its consumer cannot read.
+	MessageVersion() int
+	// Validate reports whether the payload contains everything its consumer needs.
+	Validate() error
+}
+
+// Encode wraps a payload in a new envelope and serializes it to a message body.
+func Encode[T Message](payload T) (string, error) {
+	if err := payload.Validate(); err != nil {
+		return "", fmt.Errorf("invalid %s message: %w", payload.MessageType(), err)
+	}
+
+	raw, err := json.Marshal(payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(Envelope{
+		Type:      payload.MessageType(),
+		Version:   payload.MessageVersion(),
+		Id:        xid.New().String(),
+		Timestamp: time.Now().UTC(),
+		Payload:   raw,
+	})
+
+	return string(body), err
+}
+
+// Open parses the envelope of a message body without decoding its payload.
+func Open(body string) (Envelope, error) {
+	var envelope Envelope
+
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return envelope, fmt.Errorf("malformed message envelope: %w", err)
+	}
+
+	if envelope.Type == "" {
+		return envelope, errUnwrapped
+	} else if len(envelope.Payload) == 0 {
+		return envelope, errors.New("malformed message envelope: missing payload")
+	}
+
+	return envelope, nil
+}
+
+// Decode reads the payload of a message, checking that the envelope contains the
+// expected type of message in a version this consumer understands. A body without an
+// envelope is read as the legacy version of the expected payload.
+func Decode[T Message](message events.SQSMessage) (T, error) {
+	var payload T
+
+	envelope, err := Open(message.Body)
+	legacy := errors.Is(err, errUnwrapped)
+
+	if legacy {
+		envelope = Envelope{
+			Type:    payload.MessageType(),
+			Version: LegacyVersion,
+			Payload: json.RawMessage(message.Body),
+		}
+	} else if err != nil {
+		return payload, err
+	}
+
+	if envelope.Type != payload.MessageType() {
+		return payload, fmt.Errorf("unexpected message type %q, want %q", envelope.Type, payload.MessageType())
+	}
+
+	if envelope.Version != payload.MessageVersion() && !legacy {
+		return payload, fmt.Errorf(
+			"unsupported version %d of message type %q, this consumer only supports version %d",
+			envelope.Version, envelope.Type, payload.MessageVersion(),
+		)
+	}
+
+	if err = json.Unmarshal(envelope.Payload, &payload); err != nil {
+		return payload, fmt.Errorf("malformed %s payload: %w", envelope.Type, err)
+	}
+
+	if err = payload.Validate(); err != nil {
+		return payload, fmt.Errorf("invalid %s message: %w", envelope.Type, err)
+	}
+
+	return payload, nil
+}
+
+// Send wraps a payload in an envelope and sends it to the given queue, returning the id
+// assigned to the message by SQS.
+func Send[T Message](payload T, queueUrl string) (string, error) {
+	body, err := Encode(payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return SendToQueue(body, queueUrl)
+}
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var exampleUser = data.User{
+	Email:     "guest@example.com",
+	NameFirst: "Ada",
+	NameLast:  "Lovelace",
+}
+
+// roundTrip encodes a payload, decodes it as a received message, and checks the result.
+func roundTrip[T Message](t *testing.T, payload T) {
+	t.Helper()
+
+	body, err := Encode(payload)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	envelope, err := Open(body)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	if envelope.Type != payload.MessageType() || envelope.Version != payload.MessageVersion() {
+		t.Fatalf("Envelope %s/%d, want %s/%d", envelope.Type, envelope.Version, payload.MessageType(), payload.MessageVersion())
+	}
+	if envelope.Id == "" || envelope.Timestamp.IsZero() {
+		t.Fatalf("Envelope id %q/timestamp %v, want both set", envelope.Id, envelope.Timestamp)
+	}
+
+	decoded, err := Decode[T](events.SQSMessage{MessageId: "1", Body: body})
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, payload) {
+		t.Fatalf("Decode result %+v, want %+v", decoded, payload)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Run(TypeMFACode, func(t *testing.T) {
+		roundTrip(t, MFACodeMessage{Code: "123456", User: exampleUser})
+	})
+
+	t.Run(TypeGuestUnlock, func(t *testing.T) {
+		roundTrip(t, GuestUnlockMessage{Username: exampleUser.Email})
+	})
+
+	t.Run(TypeAprimoRecord, func(t *testing.T) {
+		roundTrip(t, AprimoRecordMessage{Key: "uploads/file.jpg", FileType: "image/jpeg", FileToken: "token"})
+	})
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	_, err := Encode(GuestUnlockMessage{})
+	if err == nil || !strings.Contains(err.Error(), "missing username") {
+		t.Fatalf("Encode error %v, want missing username", err)
+	}
+}
+
+// envelopeBody serializes an envelope with the given type, version, and payload.
+func envelopeBody(t *testing.T, messageType string, version int, payload string) string {
+	body, err := json.Marshal(Envelope{Type: messageType, Version: version, Id: "1", Payload: json.RawMessage(payload)})
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestDecodeLegacy(t *testing.T) {
+	unlock, err := Decode[GuestUnlockMessage](events.SQSMessage{Body: `{"username":"guest@example.com"}`})
+	if err != nil || unlock.Username != exampleUser.Email {
+		t.Fatalf("Decode result %+v/%v, want legacy unlock message", unlock, err)
+	}
+
+	code, err := Decode[MFACodeMessage](events.SQSMessage{Body: `{"code":"123456","user":{"email":"guest@example.com"}}`})
+	if err != nil || code.Code != "123456" || code.User.Email != exampleUser.Email {
+		t.Fatalf("Decode result %+v/%v, want legacy 2FA message", code, err)
+	}
+
+	record, err := Decode[AprimoRecordMessage](events.SQSMessage{Body: `{"key":"uploads/file.jpg","filetype":"image/jpeg","fileToken":"token"}`})
+	if err != nil || record.Key != "uploads/file.jpg" || record.FileToken != "token" {
+		t.Fatalf("Decode result %+v/%v, want legacy Aprimo record message", record, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"malformed", `{"type":`, "malformed message envelope"},
+		{"missing payload", `{"type":"guest-unlock","version":1}`, "missing payload"},
+		{"invalid legacy", `{"code":"123456"}`, "invalid guest-unlock message: missing username"},
+		{"wrong type", envelopeBody(t, TypeMFACode, 1, `{"username":"guest@example.com"}`), `unexpected message type "mfa-code"`},
+		{"unknown version", envelopeBody(t, TypeGuestUnlock, 2, `{"username":"guest@example.com"}`), `unsupported version 2 of message type "guest-unlock"`},
+		{"bad payload", envelopeBody(t, TypeGuestUnlock, 1, `["guest@example.com"]`), "malformed guest-unlock payload"},
+		{"invalid payload", envelopeBody(t, TypeGuestUnlock, 1, `{"username":""}`), "invalid guest-unlock message: missing username"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode[GuestUnlockMessage](events.SQSMessage{MessageId: "1", Body: tt.body})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Decode error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,41 +2,42 @@ package queue
 
 import (
 	"context"
-	"os"
 
+	"github.com/IIP-Design/commons-gateway/utils/clients"
 	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/IIP-Design/commons-gateway/utils/tracing"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
+// SendToQueue sends a message with the given body to a queue, returning the id assigned
+// to the message by SQS. The current trace context is attached to the message.
 func SendToQueue(body string, queueUrl string) (string, error) {
-	var cfg aws.Config
-	var err error
 	var messageId string
 
-	dbg := os.Getenv("DEBUG") == "true"
-
-	// Set up AWS configuration needed by SQS client.
-	if dbg {
-		cfg, err = config.LoadDefaultConfig(context.TODO(), config.WithClientLogMode(aws.LogRetries|aws.LogRequestWithBody|aws.LogResponseWithBody|aws.LogRequestEventMessage))
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO())
-	}
+	client, err := clients.GetSQS()
 
 	if err != nil {
-		logs.LogError(err, "Error Loading AWS Config")
 		return messageId, err
 	}
 
-	client := sqs.NewFromConfig(cfg)
-
 	messageInput := &sqs.SendMessageInput{
 		DelaySeconds: 0,
 		MessageBody:  aws.String(body),
 		QueueUrl:     &queueUrl,
 	}
 
+	// Pass on the trace context so that the consumer can continue the current trace.
+	if traceparent := tracing.TraceParent(); traceparent != "" {
+		messageInput.MessageAttributes = map[string]types.MessageAttributeValue{
+			tracing.TraceParentKey: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(traceparent),
+			},
+		}
+	}
+
 	// Send the message to SQS.
 	resp, err := client.SendMessage(context.TODO(), messageInput)
 
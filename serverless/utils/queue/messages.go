@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"errors"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/data"
+)
+
+// The types of message sent to the gateway's queues.
+const (
+	TypeAprimoRecord = "aprimo-record"
+	TypeGuestUnlock  = "guest-unlock"
+	TypeMFACode      = "mfa-code"
+)
+
+// MFACodeMessage requests that a guest be emailed the code completing their login.
+type MFACodeMessage struct {
+	Code string    `json:"code"`
+	User data.User `json:"user"`
+}
+
+func (MFACodeMessage) MessageType() string { return TypeMFACode }
+func (MFACodeMessage) MessageVersion() int { return 1 }
+
+func (m MFACodeMessage) Validate() error {
+	if m.Code == "" {
+		return errors.New("missing code")
+	}
+
+	if m.User.Email == "" {
+		return errors.New("missing user email")
+	}
+
+	return nil
+}
+
+// GuestUnlockMessage requests that a locked guest account be unlocked.
+type GuestUnlockMessage struct {
+	Username string `json:"username"`
+}
+
+func (GuestUnlockMessage) MessageType() string { return TypeGuestUnlock }
+func (GuestUnlockMessage) MessageVersion() int { return 1 }
+
+func (m GuestUnlockMessage) Validate() error {
+	if m.Username == "" {
+		return errors.New("missing username")
+	}
+
+	return nil
+}
+
+// AprimoRecordMessage requests that an Aprimo record be created for a file which has
+// been uploaded to Aprimo. Its fields match those of aprimo.FileRecordInitEvent, to
+// which it can be converted.
+type AprimoRecordMessage struct {
+	Key       string `json:"key"`
+	FileType  string `json:"filetype"`
+	FileToken string `json:"fileToken"`
+}
+
+func (AprimoRecordMessage) MessageType() string { return TypeAprimoRecord }
+func (AprimoRecordMessage) MessageVersion() int { return 1 }
+
+func (m AprimoRecordMessage) Validate() error {
+	if m.Key == "" {
+		return errors.New("missing key")
+	}
+
+	if m.FileToken == "" {
+		return errors.New("missing file token")
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// s3Notification is the body of a message sent to a queue by an S3 event notification.
+// S3 writes these messages itself, so they cannot be wrapped in an envelope. When the
+// notification is first configured, S3 also sends a test event with no records.
+type s3Notification struct {
+	Event   string                 `json:"Event"`
+	Records []events.S3EventRecord `json:"Records"`
+}
+
+// DecodeS3Notification reads the S3 event records from a message sent by an S3 event
+// notification, checking that each names the bucket and key of an object. A test event
+// sent by S3 decodes to no records.
+func DecodeS3Notification(message events.SQSMessage) ([]events.S3EventRecord, error) {
+	var notification s3Notification
+
+	if err := json.Unmarshal([]byte(message.Body), &notification); err != nil {
+		return nil, fmt.Errorf("malformed S3 notification: %w", err)
+	}
+
+	if notification.Event == "s3:TestEvent" {
+		return nil, nil
+	}
+
+	if len(notification.Records) == 0 {
+		return nil, errors.New("malformed S3 notification: missing records")
+	}
+
+	for _, record := range notification.Records {
+		if record.S3.Bucket.Name == "" || record.S3.Object.Key == "" {
+			return nil, errors.New("malformed S3 notification: record missing bucket or key")
+		}
+	}
+
+	return notification.Records, nil
+}
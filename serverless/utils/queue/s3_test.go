@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestDecodeS3Notification(t *testing.T) {
+	body := `{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"uploads"},"object":{"key":"file.jpg","size":1024}}}]}`
+
+	records, err := DecodeS3Notification(events.SQSMessage{Body: body})
+	if err != nil {
+		t.Fatalf("DecodeS3Notification error: %v", err)
+	}
+	if len(records) != 1 || records[0].S3.Object.Key != "file.jpg" || records[0].S3.Object.Size != 1024 {
+		t.Fatalf("DecodeS3Notification result %+v, want one record for file.jpg", records)
+	}
+}
+
+func TestDecodeS3TestEvent(t *testing.T) {
+	records, err := DecodeS3Notification(events.SQSMessage{Body: `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"uploads"}`})
+	if len(records) != 0 || err != nil {
+		t.Fatalf("DecodeS3Notification result %v/%v, want no records/nil", records, err)
+	}
+}
+
+func TestDecodeS3NotificationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"malformed", `{"Records":`, "malformed S3 notification"},
+		{"no records", `{}`, "missing records"},
+		{"no key", `{"Records":[{"s3":{"bucket":{"name":"uploads"},"object":{}}}]}`, "missing bucket or key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeS3Notification(events.SQSMessage{Body: tt.body})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("DecodeS3Notification error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
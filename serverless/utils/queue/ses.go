@@ -0,0 +1,113 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// The kinds of SES notification which affect whether an address can receive email.
+const (
+	SESBounce    = "Bounce"
+	SESComplaint = "Complaint"
+)
+
+// The bounce type SES reports when an address will never accept email, as opposed to
+// a transient bounce such as a full mailbox.
+const SESPermanentBounce = "Permanent"
+
+// snsEnvelope is the body of a message sent to a queue by an SNS subscription without
+// raw message delivery, which wraps the published message.
+type snsEnvelope struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// SESRecipient is an address named by a bounce or complaint notification.
+type SESRecipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	Action         string `json:"action,omitempty"`
+	Status         string `json:"status,omitempty"`
+	DiagnosticCode string `json:"diagnosticCode,omitempty"`
+}
+
+// SESBounceDetail describes an email which the recipient's mail server rejected.
+type SESBounceDetail struct {
+	BounceType        string         `json:"bounceType"`
+	BounceSubType     string         `json:"bounceSubType"`
+	BouncedRecipients []SESRecipient `json:"bouncedRecipients"`
+	FeedbackId        string         `json:"feedbackId"`
+	Timestamp         time.Time      `json:"timestamp"`
+}
+
+// SESComplaintDetail describes an email which its recipient marked as spam.
+type SESComplaintDetail struct {
+	ComplainedRecipients  []SESRecipient `json:"complainedRecipients"`
+	ComplaintFeedbackType string         `json:"complaintFeedbackType,omitempty"`
+	FeedbackId            string         `json:"feedbackId"`
+	Timestamp             time.Time      `json:"timestamp"`
+}
+
+// SESMail identifies the email which a notification concerns.
+type SESMail struct {
+	MessageId   string   `json:"messageId"`
+	Source      string   `json:"source"`
+	Destination []string `json:"destination"`
+}
+
+// SESNotification is a notification published by SES to an SNS topic. Notifications
+// configured on an identity set the notification type, whereas those published by a
+// configuration set's event destination set the event type instead.
+type SESNotification struct {
+	NotificationType string              `json:"notificationType,omitempty"`
+	EventType        string              `json:"eventType,omitempty"`
+	Bounce           *SESBounceDetail    `json:"bounce,omitempty"`
+	Complaint        *SESComplaintDetail `json:"complaint,omitempty"`
+	Mail             SESMail             `json:"mail"`
+}
+
+// Kind returns the type of the notification, e.g. `Bounce` or `Complaint`.
+func (n SESNotification) Kind() string {
+	if n.EventType != "" {
+		return n.EventType
+	}
+
+	return n.NotificationType
+}
+
+// DecodeSESNotification reads an SES notification from a message sent to a queue by
+// an SNS subscription, with or without raw message delivery. Bounce and complaint
+// notifications are checked to name at least one recipient. Other kinds of notification,
+// such as deliveries, are returned without being checked.
+func DecodeSESNotification(message events.SQSMessage) (SESNotification, error) {
+	var notification SESNotification
+
+	body := message.Body
+
+	var envelope snsEnvelope
+	if err := json.Unmarshal([]byte(body), &envelope); err == nil && envelope.Type == "Notification" {
+		body = envelope.Message
+	}
+
+	if err := json.Unmarshal([]byte(body), &notification); err != nil {
+		return notification, fmt.Errorf("malformed SES notification: %w", err)
+	}
+
+	switch notification.Kind() {
+	case "":
+		return notification, errors.New("malformed SES notification: missing notification type")
+	case SESBounce:
+		if notification.Bounce == nil || len(notification.Bounce.BouncedRecipients) == 0 {
+			return notification, errors.New("malformed SES notification: bounce missing recipients")
+		}
+	case SESComplaint:
+		if notification.Complaint == nil || len(notification.Complaint.ComplainedRecipients) == 0 {
+			return notification, errors.New("malformed SES notification: complaint missing recipients")
+		}
+	}
+
+	return notification, nil
+}
@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// sesFixture reads a sample SES notification from the testdata directory.
+func sesFixture(t *testing.T, name string) events.SQSMessage {
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+
+	return events.SQSMessage{MessageId: name, Body: string(body)}
+}
+
+func TestDecodeSESNotification(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		kind      string
+		recipient string
+	}{
+		{"ses-bounce-permanent.json", SESBounce, "Guest@example.com"},
+		{"ses-bounce-sns.json", SESBounce, "Guest@example.com"},
+		{"ses-bounce-transient.json", SESBounce, "guest@example.com"},
+		{"ses-complaint.json", SESComplaint, "guest@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			notification, err := DecodeSESNotification(sesFixture(t, tt.fixture))
+			if err != nil || notification.Kind() != tt.kind {
+				t.Fatalf("DecodeSESNotification result %+v/%v, want a %s/nil", notification, err, tt.kind)
+			}
+
+			var recipient string
+			if notification.Bounce != nil {
+				recipient = notification.Bounce.BouncedRecipients[0].EmailAddress
+			} else {
+				recipient = notification.Complaint.ComplainedRecipients[0].EmailAddress
+			}
+
+			if recipient != tt.recipient {
+				t.Fatalf("DecodeSESNotification recipient %q, want %q", recipient, tt.recipient)
+			}
+		})
+	}
+}
+
+func TestDecodeSESBounceDetail(t *testing.T) {
+	notification, err := DecodeSESNotification(sesFixture(t, "ses-bounce-sns.json"))
+	if err != nil {
+		t.Fatalf("DecodeSESNotification error: %v", err)
+	}
+
+	bounce := notification.Bounce
+	if bounce.BounceType != SESPermanentBounce || bounce.FeedbackId == "" || bounce.Timestamp.IsZero() {
+		t.Fatalf("DecodeSESNotification bounce %+v, want a permanent bounce with feedback", bounce)
+	}
+
+	if code := bounce.BouncedRecipients[0].DiagnosticCode; code != "smtp; 550 5.1.1 user unknown" {
+		t.Fatalf("DecodeSESNotification diagnostic code %q, want the user unknown code", code)
+	}
+}
+
+func TestDecodeSESNotificationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"malformed", `{"notificationType":`, "malformed SES notification"},
+		{"no type", `{"mail":{}}`, "missing notification type"},
+		{"no bounce", `{"notificationType":"Bounce"}`, "bounce missing recipients"},
+		{"no complainants", `{"eventType":"Complaint","complaint":{"complainedRecipients":[]}}`, "complaint missing recipients"},
+		{"wrapped", `{"Type":"Notification","Message":"{}"}`, "missing notification type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeSESNotification(events.SQSMessage{Body: tt.body})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("DecodeSESNotification error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
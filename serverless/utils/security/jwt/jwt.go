@@ -71,9 +71,8 @@ func extractBearerToken(headerVal string) (string, error) {
 	}
 }
 
-func parseToken(tokenString string) (string, error) {
-	var scope string
-
+// parseClaims verifies the signature on a token string and returns its claims.
+func parseClaims(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -84,17 +83,29 @@ func parseToken(tokenString string) (string, error) {
 
 	if err != nil {
 		logs.LogError(err, "Error Parsing JWT Token")
-		return scope, err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 
 	if !ok || !token.Valid {
 		logs.LogError(err, "Bearer Token is Not Valid")
-		return scope, errors.New("token is not valid")
+		return nil, errors.New("token is not valid")
 	}
 
-	scope = claims["scope"].(string)
+	return claims, err
+}
+
+func parseToken(tokenString string) (string, error) {
+	var scope string
+
+	claims, err := parseClaims(tokenString)
+
+	if err != nil {
+		return scope, err
+	}
+
+	scope, _ = claims["scope"].(string)
 
 	return scope, err
 }
@@ -142,3 +153,26 @@ func ExtractClientRole(token string) (string, error) {
 
 	return parseToken(tokenString)
 }
+
+// ExtractClientUser returns the username (email) of the user to whom
+// the token in the provided authorization header was issued.
+func ExtractClientUser(token string) (string, error) {
+	tokenString, err := extractBearerToken(token)
+	if err != nil {
+		logs.LogError(err, "Error Extracting Bearer Token")
+		return "", err
+	}
+
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	user, ok := claims["user"].(string)
+
+	if !ok || user == "" {
+		return "", errors.New("token does not identify a user")
+	}
+
+	return user, nil
+}
@@ -78,3 +78,11 @@ func TestFormatJwt(t *testing.T) {
 		t.Fatalf(`FormatJWT = %q, %v, want match for %#q, nil`, fmt, err, want)
 	}
 }
+
+func TestExtractClientUser(t *testing.T) {
+	token, _ := GenerateJWT(username, scope, firstLogin)
+	user, err := ExtractClientUser("Bearer " + token)
+	if user != username || err != nil {
+		t.Fatalf(`ExtractClientUser = %s, %v, want %s, nil`, user, err, username)
+	}
+}
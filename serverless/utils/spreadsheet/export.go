@@ -0,0 +1,119 @@
+package spreadsheet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/xid"
+
+	"github.com/IIP-Design/commons-gateway/utils/data/users"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	msgs "github.com/IIP-Design/commons-gateway/utils/messages"
+	"github.com/IIP-Design/commons-gateway/utils/security/jwt"
+	"github.com/IIP-Design/commons-gateway/utils/storage"
+)
+
+const (
+	ExportLifetimeSecs = 900
+)
+
+// ExportRequest holds the options shared by every spreadsheet export.
+type ExportRequest struct {
+	Format string `json:"format"`
+	Team   string `json:"team"`
+}
+
+// Export describes a listing which can be exported as a spreadsheet.
+type Export struct {
+	// Name identifies the listing in the key of the saved spreadsheet.
+	Name   string
+	Header []string
+	// Filters, if set, is a pointer into which any listing specific options are
+	// decoded from the request body.
+	Filters any
+	// Rows retrieves the rows of the listing, restricted to the given team unless
+	// it is empty.
+	Rows func(team string) ([][]string, error)
+}
+
+// HandleExport handles a request to export a listing as a CSV or XLSX spreadsheet. Only
+// super admins may export records outside of their own team, for all other users the
+// requested team is ignored. The spreadsheet is saved to S3 and a short-lived link to
+// download it is returned.
+func HandleExport(event events.APIGatewayProxyRequest, export Export) (msgs.Response, error) {
+	var request ExportRequest
+
+	if event.Body != "" {
+		err := json.Unmarshal([]byte(event.Body), &request)
+
+		if err == nil && export.Filters != nil {
+			err = json.Unmarshal([]byte(event.Body), export.Filters)
+		}
+
+		if err != nil {
+			logs.LogError(err, "Parsing Body Error")
+			return msgs.SendCustomError(errors.New("unable to parse request body"), 400)
+		}
+	}
+
+	format, err := ParseFormat(request.Format)
+
+	if err != nil {
+		return msgs.SendCustomError(err, 400)
+	}
+
+	clientUser, err := jwt.ExtractClientUser(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client User Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	clientRole, err := jwt.ExtractClientRole(event.Headers["Authorization"])
+
+	if err != nil {
+		logs.LogError(err, "Extract Client Role Error")
+		return msgs.SendCustomError(errors.New("unable to identify requesting user"), 403)
+	}
+
+	team, err := users.ScopeTeamFilter(clientUser, clientRole, request.Team)
+
+	if err != nil {
+		logs.LogError(err, "Scope Team Filter Error")
+		return msgs.SendCustomError(errors.New("unable to determine the requesting user's team"), 403)
+	}
+
+	rows, err := export.Rows(team)
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	key := fmt.Sprintf("reports/%s-%s.%s", export.Name, xid.New().String(), format)
+	lifetime := time.Duration(ExportLifetimeSecs * int64(time.Second))
+
+	url, err := storage.StreamExport(key, ContentType(format), lifetime, func(w io.Writer) error {
+		return Write(w, format, export.Header, rows)
+	})
+
+	if err != nil {
+		logs.LogError(err, "Stream Export Error")
+		return msgs.SendServerError(err)
+	}
+
+	body, err := msgs.MarshalBody(map[string]any{
+		"downloadURL": url,
+		"expires":     time.Now().Add(lifetime),
+		"count":       len(rows),
+	})
+
+	if err != nil {
+		return msgs.SendServerError(err)
+	}
+
+	return msgs.PrepareResponse(body)
+}
@@ -0,0 +1,88 @@
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	testConfig "github.com/IIP-Design/commons-gateway/test/config"
+	testHelpers "github.com/IIP-Design/commons-gateway/test/helpers"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestMain(m *testing.M) {
+	testConfig.ConfigureDb()
+
+	err := testHelpers.SetUpTestDb()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	exitVal := m.Run()
+
+	testHelpers.TearDownTestDb()
+
+	os.Exit(exitVal)
+}
+
+// failingExport is an export whose rows cannot be retrieved, so that it never reaches S3.
+func failingExport(filters any) Export {
+	return Export{
+		Name:    "test",
+		Header:  testHeader,
+		Filters: filters,
+		Rows: func(team string) ([][]string, error) {
+			return nil, errors.New("rows unavailable")
+		},
+	}
+}
+
+func TestExportBadFormat(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"format": "pdf"}`,
+		Headers: testHelpers.AuthHeaders(testHelpers.ExampleAdmin["email"], testHelpers.ExampleAdmin["role"]),
+	}
+
+	resp, err := HandleExport(event, failingExport(nil))
+	if resp.StatusCode != 400 || err != nil {
+		t.Fatalf("HandleExport result %d/%v, want 400/nil", resp.StatusCode, err)
+	}
+}
+
+func TestExportUnknownAdmin(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"format": "xlsx"}`,
+		Headers: testHelpers.AuthHeaders("fake@test.fail", "admin"),
+	}
+
+	resp, err := HandleExport(event, failingExport(nil))
+	if resp.StatusCode != 403 || err != nil {
+		t.Fatalf("HandleExport result %d/%v, want 403/nil", resp.StatusCode, err)
+	}
+}
+
+func TestExportFilters(t *testing.T) {
+	var filters struct {
+		Role string `json:"role"`
+	}
+	var scoped string
+
+	export := failingExport(&filters)
+	rows := export.Rows
+	export.Rows = func(team string) ([][]string, error) {
+		scoped = team
+		return rows(team)
+	}
+
+	event := events.APIGatewayProxyRequest{
+		Body:    `{"format": "csv", "team": "fox", "role": "guest admin"}`,
+		Headers: testHelpers.AuthHeaders("super@test.test", "super admin"),
+	}
+
+	resp, err := HandleExport(event, export)
+	if resp.StatusCode != 500 || err != nil || scoped != "fox" || filters.Role != "guest admin" {
+		t.Fatalf("HandleExport result %d/%v with team %q and role %q, want 500/nil with fox and guest admin", resp.StatusCode, err, scoped, filters.Role)
+	}
+}
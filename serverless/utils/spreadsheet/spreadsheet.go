@@ -0,0 +1,196 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The file formats in which a spreadsheet can be written.
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// The static parts of a single sheet XLSX workbook.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{
+		"[Content_Types].xml",
+		`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`,
+	},
+	{
+		"_rels/.rels",
+		`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+	},
+	{
+		"xl/workbook.xml",
+		`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`,
+	},
+	{
+		"xl/_rels/workbook.xml.rels",
+		`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+	},
+}
+
+// ContentType returns the MIME type of the given spreadsheet format.
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv"
+}
+
+// escapeFormula prevents a CSV cell from being interpreted as a formula when the
+// file is opened in a spreadsheet application, by prefixing it with an apostrophe.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+		return "'" + cell
+	}
+
+	return cell
+}
+
+// writeCSV writes the header and rows to the provided writer as comma separated values.
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		escaped := make([]string, len(row))
+
+		for i, cell := range row {
+			escaped[i] = escapeFormula(cell)
+		}
+
+		if err := writer.Write(escaped); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// writeSheetRow appends a single row of inline string cells to an XLSX worksheet.
+func writeSheetRow(w *bufio.Writer, index int, cells []string) error {
+	fmt.Fprintf(w, `<row r="%d">`, index)
+
+	for _, cell := range cells {
+		w.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+
+		if err := xml.EscapeText(w, []byte(cell)); err != nil {
+			return err
+		}
+
+		w.WriteString(`</t></is></c>`)
+	}
+
+	_, err := w.WriteString(`</row>`)
+
+	return err
+}
+
+// writeXLSX writes the header and rows to the provided writer as a single sheet
+// XLSX workbook. Rows are written to the archive as they are processed.
+func writeXLSX(w io.Writer, header []string, rows [][]string) error {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		file, err := archive.Create(part.name)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+
+	if err != nil {
+		return err
+	}
+
+	sheet := bufio.NewWriter(file)
+
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	if err = writeSheetRow(sheet, 1, header); err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		if err = writeSheetRow(sheet, i+2, row); err != nil {
+			return err
+		}
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	if err = sheet.Flush(); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// Write outputs the header and rows to the provided writer in the requested format,
+// which must be one of CSV or XLSX. Every row must have a cell for each column.
+func Write(w io.Writer, format string, header []string, rows [][]string) error {
+	for i, row := range rows {
+		if len(row) != len(header) {
+			return fmt.Errorf("row %d has %d cells, want %d", i+1, len(row), len(header))
+		}
+	}
+
+	switch format {
+	case CSV:
+		return writeCSV(w, header, rows)
+	case XLSX:
+		return writeXLSX(w, header, rows)
+	default:
+		return fmt.Errorf("unsupported spreadsheet format %s", format)
+	}
+}
+
+// ParseFormat validates a requested spreadsheet format, defaulting to CSV if none is given.
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", CSV:
+		return CSV, nil
+	case XLSX:
+		return XLSX, nil
+	default:
+		return "", fmt.Errorf("format must be one of %s or %s", CSV, XLSX)
+	}
+}
@@ -0,0 +1,80 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+var testHeader = []string{"email", "note"}
+var testRows = [][]string{
+	{"guest@test.test", "=HYPERLINK(\"http://test.fail\")"},
+	{"other@test.test", "<b>bold</b> & more"},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, CSV, testHeader, testRows)
+	if err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "email,note" {
+		t.Fatalf("Write result %q, want header and two rows", buf.String())
+	}
+
+	if !strings.HasPrefix(lines[1], `guest@test.test,"'=HYPERLINK`) {
+		t.Fatalf("Write result %q, want escaped formula", lines[1])
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, XLSX, testHeader, testRows)
+	if err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader error: %v", err)
+	}
+
+	if len(archive.File) != 5 {
+		t.Fatalf("XLSX contains %d parts, want 5", len(archive.File))
+	}
+
+	sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("Open sheet error: %v", err)
+	}
+
+	content, _ := io.ReadAll(sheet)
+
+	if !strings.Contains(string(content), `<row r="3">`) || !strings.Contains(string(content), "&lt;b&gt;bold&lt;/b&gt; &amp; more") {
+		t.Fatalf("sheet content %s, want three escaped rows", content)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, "pdf", testHeader, testRows)
+	if err == nil {
+		t.Fatalf("Write result nil, want unsupported format error")
+	}
+}
+
+func TestWriteRaggedRows(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, CSV, testHeader, [][]string{{"guest@test.test"}})
+	if err == nil {
+		t.Fatal("Write returned nil error, want error for row missing a cell")
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/IIP-Design/commons-gateway/utils/clients"
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// presignExport generates a URL from which an object in the exports bucket can be
+// downloaded until the lifetime elapses.
+func presignExport(client *s3.Client, bucket string, key string, lifetime time.Duration) (string, error) {
+	presigner := s3.NewPresignClient(client)
+
+	req, err := presigner.PresignGetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = lifetime
+	})
+
+	if err != nil {
+		logs.LogError(err, "Failed to Presign Export")
+		return "", err
+	}
+
+	return req.URL, err
+}
+
+// ShareExport saves the provided content to the exports bucket under the given key
+// and returns a presigned URL from which it can be downloaded until the lifetime elapses.
+// Objects in the exports bucket are expired by a lifecycle rule shortly thereafter.
+func ShareExport(key string, body []byte, contentType string, lifetime time.Duration) (string, error) {
+	var url string
+
+	bucket := os.Getenv("S3_EXPORT_BUCKET")
+
+	client, err := clients.GetS3()
+
+	if err != nil {
+		return url, err
+	}
+
+	_, err = client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+
+	if err != nil {
+		logs.LogError(err, "Failed to Save Export")
+		return url, err
+	}
+
+	return presignExport(client, bucket, key, lifetime)
+}
+
+// StreamExport uploads the output of the provided write function to the exports bucket
+// under the given key as it is produced, so that large exports need not be held in memory
+// in full. It returns a presigned URL from which the export can be downloaded until the
+// lifetime elapses.
+func StreamExport(key string, contentType string, lifetime time.Duration, write func(io.Writer) error) (string, error) {
+	var url string
+
+	bucket := os.Getenv("S3_EXPORT_BUCKET")
+
+	client, err := clients.GetS3()
+
+	if err != nil {
+		return url, err
+	}
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(write(writer))
+	}()
+
+	uploader := manager.NewUploader(client)
+
+	_, err = uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        reader,
+		ContentType: aws.String(contentType),
+	})
+
+	// Ensure the writer is not left blocked if the upload was abandoned.
+	reader.Close()
+
+	if err != nil {
+		logs.LogError(err, "Failed to Stream Export")
+		return url, err
+	}
+
+	return presignExport(client, bucket, key, lifetime)
+}
@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IIP-Design/commons-gateway/utils/logs"
+)
+
+// TraceParentKey is the name of the header or message attribute carrying the W3C trace context.
+const TraceParentKey = "traceparent"
+
+const instrumentationName = "github.com/IIP-Design/commons-gateway"
+
+var (
+	setupOnce  sync.Once
+	provider   *sdktrace.TracerProvider
+	propagator = propagation.TraceContext{}
+)
+
+// current holds the context of the innermost open span. Since a Lambda container handles
+// one invocation at a time, the active span is tracked here rather than passed through
+// every function which makes a call worth tracing.
+var current = context.Background()
+
+// configure replaces the tracer provider with one built from the given options.
+func configure(opts ...sdktrace.TracerProviderOption) {
+	service := lambdacontext.FunctionName
+
+	if service == "" {
+		service = "commons-gateway"
+	}
+
+	opts = append(opts, sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))))
+	provider = sdktrace.NewTracerProvider(opts...)
+}
+
+// setup creates the tracer provider on first use. Spans are only exported when an OTLP
+// endpoint is configured, such as a local collector at http://localhost:4318, but trace
+// context is always generated and propagated.
+func setup() {
+	setupOnce.Do(func() {
+		opts := []sdktrace.TracerProviderOption{}
+
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+			exporter, err := otlptracehttp.New(context.Background())
+
+			if err != nil {
+				logs.LogError(err, "Create Trace Exporter Error")
+			} else {
+				opts = append(opts, sdktrace.WithBatcher(exporter))
+			}
+		}
+
+		configure(opts...)
+	})
+}
+
+// begin opens a span as a child of the given context and makes it the current span.
+// The returned function ends the span, records any error, and restores the previous span.
+func begin(parent context.Context, name string, kind trace.SpanKind, attrs []attribute.KeyValue, links []trace.Link) func(error) {
+	setup()
+
+	previous := current
+
+	ctx, span := provider.Tracer(instrumentationName).Start(
+		parent,
+		name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attrs...),
+		trace.WithLinks(links...),
+	)
+
+	current = ctx
+
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+		current = previous
+	}
+}
+
+// invocation opens the root span of a handler invocation. Ending it flushes all the
+// spans of the invocation to the exporter, since the container may be frozen afterwards.
+func invocation(ctx context.Context, parent context.Context, name string, kind trace.SpanKind) func() {
+	end := begin(parent, name, kind, nil, nil)
+
+	return func() {
+		end(nil)
+		provider.ForceFlush(ctx)
+	}
+}
+
+// headerValue performs a case-insensitive lookup, since API Gateway passes headers
+// through in whatever case the client sent them.
+func headerValue(headers map[string]string, key string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// withParent returns a context continuing the trace described by a traceparent value.
+// Should the value be missing or invalid, the context is returned unchanged.
+func withParent(ctx context.Context, traceparent string) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier{TraceParentKey: traceparent})
+}
+
+// StartRequest opens the span for an API Gateway request, continuing the caller's trace
+// if the request has a traceparent header. The returned function must be called once the
+// request has been handled.
+func StartRequest(ctx context.Context, name string, event events.APIGatewayProxyRequest) func() {
+	parent := withParent(ctx, headerValue(event.Headers, TraceParentKey))
+
+	return invocation(ctx, parent, name, trace.SpanKindServer)
+}
+
+// StartMessage opens the span for processing an SQS message, continuing the trace of
+// the function which sent it. The returned function must be called once the message
+// has been processed.
+func StartMessage(ctx context.Context, name string, message events.SQSMessage) func() {
+	traceparent := ""
+
+	if attr, ok := message.MessageAttributes[TraceParentKey]; ok && attr.StringValue != nil {
+		traceparent = *attr.StringValue
+	}
+
+	return invocation(ctx, withParent(ctx, traceparent), name, trace.SpanKindConsumer)
+}
+
+// StartSpan opens a child of the current span. The returned function ends the span,
+// marking it as failed if passed an error.
+func StartSpan(name string, attrs ...attribute.KeyValue) func(error) {
+	return begin(current, name, trace.SpanKindInternal, attrs, nil)
+}
+
+// StartFrom opens a span continuing a trace which was recorded earlier, for instance one
+// stored alongside a record while the trace passed through a service which cannot carry
+// it. The span is linked to the current span. Should the traceparent be invalid, the span
+// is opened as a child of the current span instead.
+func StartFrom(traceparent string, name string, attrs ...attribute.KeyValue) func(error) {
+	parent := withParent(context.Background(), traceparent)
+
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		return StartSpan(name, attrs...)
+	}
+
+	links := []trace.Link{}
+
+	if sc := trace.SpanContextFromContext(current); sc.IsValid() {
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+
+	return begin(parent, name, trace.SpanKindInternal, attrs, links)
+}
+
+// StartDBSpan opens a child of the current span for a database query.
+func StartDBSpan(operation string, table string) func(error) {
+	return begin(current, operation+" "+table, trace.SpanKindClient, []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", operation),
+		attribute.String("db.sql.table", table),
+	}, nil)
+}
+
+// StartHTTP opens a child of the current span for an outbound HTTP request and adds
+// the trace context to the request headers. The returned function ends the span,
+// recording the response status.
+func StartHTTP(request *http.Request) func(*http.Response, error) {
+	end := begin(current, "HTTP "+request.Method, trace.SpanKindClient, []attribute.KeyValue{
+		attribute.String("http.request.method", request.Method),
+		attribute.String("server.address", request.URL.Hostname()),
+		attribute.String("url.path", request.URL.Path),
+	}, nil)
+
+	propagator.Inject(current, propagation.HeaderCarrier(request.Header))
+
+	span := trace.SpanFromContext(current)
+
+	return func(resp *http.Response, err error) {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+		}
+
+		end(err)
+	}
+}
+
+// TraceParent returns the W3C traceparent of the current span, or an empty string if
+// there is no current span.
+func TraceParent() string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(current, carrier)
+
+	return carrier[TraceParentKey]
+}
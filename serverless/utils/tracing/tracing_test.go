@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	traceId     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentId    = "00f067aa0ba902b7"
+	traceparent = "00-" + traceId + "-" + parentId + "-01"
+)
+
+func record() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+
+	setupOnce.Do(func() {})
+	configure(sdktrace.WithSyncer(exporter))
+	current = context.Background()
+
+	return exporter
+}
+
+func TestStartRequest(t *testing.T) {
+	exporter := record()
+
+	end := StartRequest(context.TODO(), "test-request", events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Traceparent": traceparent},
+	})
+
+	if !strings.HasPrefix(TraceParent(), "00-"+traceId+"-") {
+		t.Fatalf("TraceParent returned %s, want trace %s", TraceParent(), traceId)
+	}
+
+	end()
+
+	spans := exporter.GetSpans()
+
+	if len(spans) != 1 || spans[0].Parent.SpanID().String() != parentId {
+		t.Fatalf("StartRequest recorded %v, want one span with parent %s", spans, parentId)
+	}
+
+	if TraceParent() != "" {
+		t.Fatalf("TraceParent returned %s after the request ended, want none", TraceParent())
+	}
+}
+
+func TestStartMessage(t *testing.T) {
+	exporter := record()
+	value := traceparent
+
+	end := StartMessage(context.TODO(), "test-message", events.SQSMessage{
+		MessageAttributes: map[string]events.SQSMessageAttribute{
+			TraceParentKey: {DataType: "String", StringValue: &value},
+		},
+	})
+
+	done := StartDBSpan("SELECT", "uploads")
+	done(errors.New("test"))
+	end()
+
+	spans := exporter.GetSpans()
+
+	if len(spans) != 2 {
+		t.Fatalf("StartMessage recorded %d spans, want 2", len(spans))
+	}
+
+	query, message := spans[0], spans[1]
+
+	if message.SpanContext.TraceID().String() != traceId || query.Parent.SpanID() != message.SpanContext.SpanID() {
+		t.Fatalf("Message span %v and query span %v are not linked to trace %s", message, query, traceId)
+	}
+
+	if query.Status.Code != codes.Error {
+		t.Fatalf("Query span status %v, want an error", query.Status)
+	}
+}
+
+func TestStartFrom(t *testing.T) {
+	exporter := record()
+
+	end := StartRequest(context.TODO(), "test-request", events.APIGatewayProxyRequest{})
+	request := TraceParent()
+
+	StartFrom("invalid", "fallback")(nil)
+	StartFrom(traceparent, "continued")(nil)
+	end()
+
+	spans := exporter.GetSpans()
+
+	if len(spans) != 3 {
+		t.Fatalf("StartFrom recorded %d spans, want 3", len(spans))
+	}
+
+	fallback, continued, root := spans[0], spans[1], spans[2]
+
+	if fallback.Parent.SpanID() != root.SpanContext.SpanID() {
+		t.Fatalf("Span with an invalid traceparent has parent %s, want %s", fallback.Parent.SpanID(), root.SpanContext.SpanID())
+	}
+
+	if continued.Parent.SpanID().String() != parentId || len(continued.Links) != 1 {
+		t.Fatalf("Continued span has parent %s and %d links, want %s and one link", continued.Parent.SpanID(), len(continued.Links), parentId)
+	}
+
+	if !strings.Contains(request, root.SpanContext.SpanID().String()) {
+		t.Fatalf("Request traceparent %s does not identify the request span", request)
+	}
+}
+
+func TestStartHTTP(t *testing.T) {
+	exporter := record()
+
+	request, _ := http.NewRequest(http.MethodPost, "https://example.com/api/uploads", nil)
+
+	end := StartMessage(context.TODO(), "test-message", events.SQSMessage{})
+	done := StartHTTP(request)
+	done(&http.Response{StatusCode: 503, Status: "503 Service Unavailable"}, nil)
+	end()
+
+	spans := exporter.GetSpans()
+
+	if len(spans) != 2 {
+		t.Fatalf("StartHTTP recorded %d spans, want 2", len(spans))
+	}
+
+	header := request.Header.Get(TraceParentKey)
+
+	if !strings.Contains(header, spans[0].SpanContext.SpanID().String()) {
+		t.Fatalf("Request traceparent header %s does not identify the HTTP span", header)
+	}
+
+	if spans[0].Status.Code != codes.Error {
+		t.Fatalf("HTTP span status %v, want an error for a 503 response", spans[0].Status)
+	}
+}
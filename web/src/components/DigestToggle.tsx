@@ -0,0 +1,63 @@
+// ////////////////////////////////////////////////////////////////////////////
+// React Imports
+// ////////////////////////////////////////////////////////////////////////////
+import { useEffect, useState } from 'react';
+import type { FC } from 'react';
+
+// ////////////////////////////////////////////////////////////////////////////
+// Local Imports
+// ////////////////////////////////////////////////////////////////////////////
+import currentUser from '../stores/current-user';
+import { showError } from '../utils/alert';
+import { buildQuery } from '../utils/api';
+import { escapeQueryStrings } from '../utils/string';
+
+// ////////////////////////////////////////////////////////////////////////////
+// Interface and Implementation
+// ////////////////////////////////////////////////////////////////////////////
+const DigestToggle: FC = () => {
+  const [digest, setDigest] = useState<boolean|null>( null );
+
+  // Retrieve the current admin's digest preference.
+  useEffect( () => {
+    const getPreference = async () => {
+      const escaped = escapeQueryStrings( currentUser.get().email || '' );
+      const response = await buildQuery( `admin?username=${escaped}`, null, 'GET' );
+      const { data } = await response.json();
+
+      setDigest( data?.digest ?? false );
+    };
+
+    getPreference();
+  }, [] );
+
+  const handleToggle = async ( checked: boolean ) => {
+    setDigest( checked );
+
+    const { ok } = await buildQuery( 'admin/digest-preference', { digest: checked }, 'PUT' );
+
+    if ( !ok ) {
+      setDigest( !checked );
+      showError( 'Unable to update your daily digest preference' );
+    }
+  };
+
+  if ( digest === null ) {
+    return null;
+  }
+
+  return (
+    <label htmlFor="digest-toggle" style={ { display: 'block', marginBottom: '0.75em' } }>
+      <input
+        id="digest-toggle"
+        type="checkbox"
+        checked={ digest }
+        onChange={ e => handleToggle( e.target.checked ) }
+      />
+      { ' ' }
+      Email me a daily digest of pending invites and other team activity
+    </label>
+  );
+};
+
+export default DigestToggle;
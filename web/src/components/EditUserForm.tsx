@@ -13,6 +13,7 @@ import { parse } from 'date-fns';
 // Local Imports
 // ////////////////////////////////////////////////////////////////////////////
 import BackButton from './BackButton';
+import EmailHistory from './EmailHistory';
 
 import currentUser from '../stores/current-user';
 import { showConfirm, showError, showSuccess } from '../utils/alert';
@@ -22,7 +23,7 @@ import { MAX_ACCESS_GRANT_DAYS } from '../utils/constants';
 import { addDaysToNow, dateSelectionIsValid, getYearMonthDay, userWillNeedNewPassword } from '../utils/dates';
 import { escapeQueryStrings } from '../utils/string';
 
-import type { IInvite } from '../utils/types';
+import type { IInvite, IOutboxEmail } from '../utils/types';
 import { makeDummyUserForm, type IUserFormData, makeApproveUserHandler } from '../utils/users';
 import { InviteModal } from './InviteModal';
 
@@ -55,6 +56,9 @@ interface IRawInvite {
   passwordReset: boolean;
   dateInvited: string;
   expiration: string;
+  rejectedAt?: string;
+  rejectedBy?: string;
+  rejectReason?: string;
 }
 
 // ////////////////////////////////////////////////////////////////////////////
@@ -287,6 +291,8 @@ const UserForm: FC = () => {
   const [pendingInvite, setPendingInvite] = useState<IInvite|null>( null );
   const [currentInvite, setCurrentInvite] = useState<IInvite|null>( null );
   const [invites, setInvites] = useState<IInvite[]>( [] );
+  const [emails, setEmails] = useState<IOutboxEmail[]>( [] );
+  const [suppressedAt, setSuppressedAt] = useState<string|undefined>( undefined );
 
   const partnerRoles = [{ name: 'External Partner', value: 'guest' }, { name: 'External Team Lead', value: 'guest admin' }];
 
@@ -334,11 +340,20 @@ const UserForm: FC = () => {
           expired: invite.expired,
           dateInvited: getYearMonthDay( parse( invite.dateInvited, "yyyy-MM-dd'T'HH:mm:ssX", new Date() ) ),
           accessEndDate: getYearMonthDay( parse( invite.expiration, "yyyy-MM-dd'T'HH:mm:ssX", new Date() ) ),
+          rejectedAt: invite.rejectedAt
+            ? getYearMonthDay( parse( invite.rejectedAt, "yyyy-MM-dd'T'HH:mm:ssX", new Date() ) )
+            : undefined,
+          rejectedBy: invite.rejectedBy,
+          rejectReason: invite.rejectReason,
         } ) );
 
         setPendingInvite( fmtInvites[0].pending ? fmtInvites[0] : null );
-        setCurrentInvite( fmtInvites.find( val => !val.pending ) || null );
+        setCurrentInvite( fmtInvites.find( val => !val.pending && !val.rejectedAt ) || null );
         setInvites( fmtInvites );
+
+        // The delivery status of emails is only provided to admins.
+        setEmails( data.emails || [] );
+        setSuppressedAt( data.emailSuppressedAt );
       }
     };
 
@@ -500,6 +515,9 @@ const UserForm: FC = () => {
       { currentInvite && (
         <CurrentInvite userData={ userData } invite={ currentInvite } isAdmin={ isAdmin } />
       ) }
+      { ( emails.length > 0 || suppressedAt ) && (
+        <EmailHistory emails={ emails } suppressedAt={ suppressedAt } />
+      ) }
       <div id="additional-options">
         <h3>Additional Options</h3>
         <InviteModal invites={ invites } anchor="Invite History" />
@@ -0,0 +1,129 @@
+// ////////////////////////////////////////////////////////////////////////////
+// React Imports
+// ////////////////////////////////////////////////////////////////////////////
+import { useState } from 'react';
+import type { FC } from 'react';
+
+// ////////////////////////////////////////////////////////////////////////////
+// Local Imports
+// ////////////////////////////////////////////////////////////////////////////
+import { showError, showSuccess } from '../utils/alert';
+import { buildQuery } from '../utils/api';
+import type { IOutboxEmail, TEmailStatus } from '../utils/types';
+
+// ////////////////////////////////////////////////////////////////////////////
+// Styles and CSS
+// ////////////////////////////////////////////////////////////////////////////
+import btnStyles from '../styles/button.module.scss';
+import tblStyles from '../styles/table.module.scss';
+
+// ////////////////////////////////////////////////////////////////////////////
+// Interfaces and Types
+// ////////////////////////////////////////////////////////////////////////////
+interface IEmailHistoryProps {
+  readonly emails: IOutboxEmail[];
+  readonly suppressedAt?: string;
+}
+
+// ////////////////////////////////////////////////////////////////////////////
+// Helpers
+// ////////////////////////////////////////////////////////////////////////////
+const statusLabels: Record<TEmailStatus, string> = {
+  pending: 'Retrying',
+  sending: 'Sending',
+  sent: 'Sent',
+  failed: 'Failed',
+};
+
+const statusStyles: Record<TEmailStatus, string> = {
+  pending: tblStyles.pending,
+  sending: tblStyles.pending,
+  sent: tblStyles.active,
+  failed: tblStyles.inactive,
+};
+
+const formatDate = ( date: string ) => new Date( date ).toLocaleString();
+
+// ////////////////////////////////////////////////////////////////////////////
+// Implementation
+// ////////////////////////////////////////////////////////////////////////////
+const EmailHistory: FC<IEmailHistoryProps> = ( { emails, suppressedAt }: IEmailHistoryProps ) => {
+  const [history, setHistory] = useState<IOutboxEmail[]>( emails );
+
+  /**
+   * Returns a failed email to the outbox, updating its status with the outcome of the
+   * immediate attempt to resend it.
+   * @param id The id of the failed email.
+   */
+  const handleResend = async ( id: string ) => {
+    const response = await buildQuery( 'guest/email', { id }, 'POST' );
+
+    if ( !response.ok ) {
+      showError( 'Unable to resend email' );
+
+      return;
+    }
+
+    const { data } = await response.json();
+
+    setHistory( history.map( email => ( email.id === id ? data : email ) ) );
+
+    if ( data?.status === 'sent' ) {
+      showSuccess( 'Email resent' );
+    } else {
+      showError( 'The email could not be sent yet, it will be retried automatically' );
+    }
+  };
+
+  return (
+    <div id="email-history">
+      <h3>Email Delivery</h3>
+      { suppressedAt && (
+        <p>
+          { `No emails have been sent to this guest since ${formatDate( suppressedAt )}, ` }
+          because an email to their address bounced or they marked one as spam.
+          Check that their address is correct.
+        </p>
+      ) }
+      <table className={ tblStyles.table }>
+        <thead>
+          <tr>
+            <th>Created</th>
+            <th>Subject</th>
+            <th>Status</th>
+            <th>Attempts</th>
+            <th aria-label="Actions" />
+          </tr>
+        </thead>
+        <tbody>
+          { history.map( ( { id, dateCreated, dateSent, subject, status, attempts, lastError } ) => (
+            <tr key={ id }>
+              <td>{ formatDate( dateCreated ) }</td>
+              <td>{ subject }</td>
+              <td title={ dateSent ? `Sent ${formatDate( dateSent )}` : lastError }>
+                <span className={ tblStyles.status }>
+                  <span className={ statusStyles[status] } />
+                  { statusLabels[status] }
+                </span>
+              </td>
+              <td>{ attempts }</td>
+              <td>
+                { status === 'failed' && !suppressedAt && (
+                  <button
+                    className={ btnStyles['btn-light'] }
+                    type="button"
+                    onClick={ () => handleResend( id ) }
+                  >
+                    Resend
+                  </button>
+                ) }
+              </td>
+            </tr>
+          ) ) }
+        </tbody>
+      </table>
+    </div>
+  );
+};
+
+export default EmailHistory;
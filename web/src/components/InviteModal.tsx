@@ -27,7 +27,18 @@ interface IModalProps {
 // ////////////////////////////////////////////////////////////////////////////
 // Interfaces and Types
 // ////////////////////////////////////////////////////////////////////////////
-const InviteEntry = ( { dateInvited, accessEndDate, pending, expired }: IInvite, idx: number ) => (
+const inviteStatus = ( pending: boolean, rejectedAt?: string ) => {
+  if ( rejectedAt ) {
+    return 'Rejected';
+  }
+
+  return pending ? 'Pending' : 'Approved';
+};
+
+const InviteEntry = (
+  { dateInvited, accessEndDate, pending, expired, rejectedAt, rejectedBy, rejectReason }: IInvite,
+  idx: number,
+) => (
   <div key={ `${dateInvited}-${idx}` }>
     <hr style={ { marginTop: '10px' } } />
     <div className="field-group">
@@ -59,8 +70,8 @@ const InviteEntry = ( { dateInvited, accessEndDate, pending, expired }: IInvite,
         <tr>
           <td>
             <span className={ tableStyles.status }>
-              <span className={ !pending ? tableStyles.active : tableStyles.inactive } />
-              { pending ? 'Pending' : 'Approved' }
+              <span className={ !pending && !rejectedAt ? tableStyles.active : tableStyles.inactive } />
+              { inviteStatus( pending, rejectedAt ) }
             </span>
           </td>
           <td>
@@ -72,6 +83,11 @@ const InviteEntry = ( { dateInvited, accessEndDate, pending, expired }: IInvite,
         </tr>
       </tbody>
     </table>
+    { rejectedAt && (
+      <p>
+        { `Rejected by ${rejectedBy} on ${rejectedAt}: ${rejectReason}` }
+      </p>
+    ) }
   </div>
 );
 
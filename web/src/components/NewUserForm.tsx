@@ -18,7 +18,7 @@ import currentUser from '../stores/current-user';
 import { showError } from '../utils/alert';
 import { buildQuery } from '../utils/api';
 import { userIsAdmin } from '../utils/auth';
-import { MAX_ACCESS_GRANT_DAYS } from '../utils/constants';
+import { EMAIL_LOCALES, MAX_ACCESS_GRANT_DAYS } from '../utils/constants';
 import { addDaysToNow, dateSelectionIsValid, getYearMonthDay } from '../utils/dates';
 import { makeDummyUserForm } from '../utils/users';
 import type { IUserFormData } from '../utils/users';
@@ -34,11 +34,13 @@ import btnStyles from '../styles/button.module.scss';
 // ////////////////////////////////////////////////////////////////////////////
 export interface INewUserFormData extends IUserFormData {
   accessEndDate: string;
+  locale: string;
 }
 
 const initialState = {
   ...makeDummyUserForm(),
   accessEndDate: getYearMonthDay( addDays( new Date(), 14 ) ),
+  locale: 'en',
 };
 
 // ////////////////////////////////////////////////////////////////////////////
@@ -131,6 +133,7 @@ const UserForm: FC = () => {
       familyName: userData.familyName.trim(),
       team: userData.team || currentUser.get().team,
       role: userData.role,
+      locale: userData.locale,
     };
 
     if ( isAdmin ) {
@@ -241,6 +244,18 @@ const UserForm: FC = () => {
           </label>
         ) }
       </div>
+      <div className="field-group">
+        <label>
+          <span>Email Language</span>
+          <select
+            id="locale-input"
+            value={ userData.locale }
+            onChange={ e => handleUpdate( 'locale', e.target.value ) }
+          >
+            { EMAIL_LOCALES.map( ( { name, value } ) => <option key={ value } value={ value }>{ name }</option> ) }
+          </select>
+        </label>
+      </div>
       <div style={ { textAlign: 'center' } }>
         <button
           className={ `${btnStyles.btn} ${btnStyles['spaced-btn']} ${updated ? '' : btnStyles['disabled-btn']}` }
@@ -55,7 +55,7 @@ const UploaderTable: FC = () => {
           data.map( ( user: IUploader ) => ( {
             ...user,
             name: `${user.givenName} ${user.familyName}`,
-            active: isGuestActive( user.expires ),
+            active: !user.rejected && isGuestActive( user.expires ),
           } ) ),
         );
       }
@@ -64,7 +64,7 @@ const UserTable: FC<IUserTableProps> = ( { role }: IUserTableProps ) => {
           data.map( ( user: IUserEntry ) => ( {
             ...user,
             name: `${user.givenName} ${user.familyName}`,
-            active: isGuestActive( user.expires ),
+            active: !user.rejected && isGuestActive( user.expires ),
             team: getTeamName( user.team, teams ),
           } ) ),
         );
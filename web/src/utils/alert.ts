@@ -104,3 +104,20 @@ export const showTernary = ( text: string, buttons: IBtnText = {} ) => Swal.fire
   confirmButtonText: buttons.confirmButtonText || 'Confirm',
   denyButtonText: buttons.denyButtonText || 'Deny',
 } );
+
+export const showPrompt = ( title: string, text: string, confirmButtonText = 'Submit' ) => Swal.fire( {
+  icon: 'question',
+  title,
+  text,
+  input: 'textarea',
+  inputValidator: value => ( !value?.trim() ? 'A response is required' : null ),
+  showCancelButton: true,
+  showConfirmButton: true,
+  customClass: {
+    cancelButton: NEUTRAL_BTN_STYLE_CLASSES,
+    confirmButton: REJECT_BTN_STYLE_CLASSES,
+    popup: alertStyles.text,
+  },
+  buttonsStyling: false,
+  confirmButtonText,
+} );
@@ -1,5 +1,16 @@
 export const MAX_ACCESS_GRANT_DAYS = 60;
 
+/**
+ * The languages in which emails can be sent to guest users.
+ */
+export const EMAIL_LOCALES = [
+  { name: 'English', value: 'en' },
+  { name: 'Français', value: 'fr' },
+  { name: 'Español', value: 'es' },
+  { name: 'العربية', value: 'ar' },
+  { name: 'Português', value: 'pt' },
+];
+
 /**
  * Variables used to initialize AWS Amplify to work with our Cognito instance.
  */
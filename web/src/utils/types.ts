@@ -13,6 +13,7 @@ export interface IUserEntry {
   name: string
   givenName: string;
   pending: boolean;
+  rejected?: boolean;
   role: TUserRole;
   team: string;
 }
@@ -36,6 +37,24 @@ export interface IInvite {
   accessEndDate: string;
   proposer?: string;
   inviter?: string
+  rejectedAt?: string;
+  rejectedBy?: string;
+  rejectReason?: string;
+}
+
+export type TEmailStatus = 'pending' | 'sending' | 'sent' | 'failed';
+
+export interface IOutboxEmail {
+  id: string;
+  recipient: string;
+  template: string;
+  subject: string;
+  status: TEmailStatus;
+  attempts: number;
+  lastError?: string;
+  nextAttemptAt: string;
+  dateCreated: string;
+  dateSent?: string;
 }
 
 // ////////////////////////////////////////////////////////////////////////////
@@ -17,6 +17,7 @@ interface IMetadata {
 interface IFileUploadMeta extends IMetadata {
   key: string;
   fileType: string;
+  traceparent?: string;
 }
 
 // ////////////////////////////////////////////////////////////////////////////
@@ -32,9 +33,9 @@ const getPresignedUrl = async ( filename: string, contentType: string ) => {
   const queryType = `contentType=${encodeURIComponent( contentType )}`;
 
   const response = await buildQuery( `${UPLOAD_ENDPOINT}?${queryFilename}&${queryType}`, null, 'GET' );
-  const { uploadURL, key } = await response.json();
+  const { uploadURL, key, traceparent } = await response.json();
 
-  return { uploadURL, key };
+  return { uploadURL, key, traceparent };
 };
 
 const uploadFile = async ( fqUrl: string, file: File ) => {
@@ -56,7 +57,7 @@ const submitFileMetadata = async ( body: IFileUploadMeta ) => {
 // Exports
 // ////////////////////////////////////////////////////////////////////////////
 export const submitFiles = async ( file: File, meta: IMetadata ): Promise<TUploadStatus> => {
-  const { uploadURL, key } = await getPresignedUrl( file.name, file.type );
+  const { uploadURL, key, traceparent } = await getPresignedUrl( file.name, file.type );
 
   if ( !uploadURL ) { return 'urlFailed'; }
 
@@ -64,7 +65,8 @@ export const submitFiles = async ( file: File, meta: IMetadata ): Promise<TUploa
 
   if ( !fileSuccess ) { return 'uploadFailed'; }
 
-  const metaSuccess = await submitFileMetadata( { ...meta, key, fileType: file.type || 'unknown' } );
+  // Pass back the trace context so the upload can be followed through to Aprimo.
+  const metaSuccess = await submitFileMetadata( { ...meta, key, fileType: file.type || 'unknown', traceparent } );
 
   if ( !metaSuccess ) { return 'metadataFailed'; }
 
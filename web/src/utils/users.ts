@@ -2,9 +2,8 @@
 // Local Imports
 // ////////////////////////////////////////////////////////////////////////////
 import currentUser from '../stores/current-user';
-import { showError, showTernary } from './alert';
+import { showError, showPrompt, showTernary } from './alert';
 import { buildQuery } from './api';
-import { escapeQueryStrings } from './string';
 import type { TUserRole } from './types';
 
 // ////////////////////////////////////////////////////////////////////////////
@@ -34,8 +33,8 @@ export const makeApproveUserHandler = ( inviteeEmail: string ) => {
 
   return async () => {
     const { isConfirmed, isDenied } = await showTernary(
-      'By approving this user they will be allowed to upload media to the Content Commons system until deactivated or their login expires.  Denying access will allow an external partner to re-propose an invitation.',
-      { confirmButtonText: 'Approve' },
+      'By approving this user they will be allowed to upload media to the Content Commons system until deactivated or their login expires.  Denying access will notify the proposer and allow them to re-propose an invitation.',
+      { confirmButtonText: 'Approve', denyButtonText: 'Reject' },
     );
     let wasUpdated = false;
 
@@ -48,8 +47,17 @@ export const makeApproveUserHandler = ( inviteeEmail: string ) => {
         wasUpdated = true;
       }
     } else if ( isDenied ) {
-      const escaped = escapeQueryStrings( inviteeEmail );
-      const { ok } = await buildQuery( `guest?id=${escaped}`, null, 'DELETE' );
+      const { isConfirmed: hasReason, value: reason } = await showPrompt(
+        'Reject Invitation',
+        'Please explain why this invitation was rejected. The reason will be shared with the user who proposed it.',
+        'Reject',
+      );
+
+      if ( !hasReason ) {
+        return;
+      }
+
+      const { ok } = await buildQuery( 'guest/reject', { id: inviteeEmail, reason: reason.trim() }, 'POST' );
 
       if ( !ok ) {
         showError( 'Unable to reject invite' );